@@ -3,9 +3,12 @@ module getclient
 go 1.21
 
 require (
+	github.com/expr-lang/expr v1.17.8
 	github.com/gotd/td v0.90.0
 	go.uber.org/zap v1.26.0
+	golang.org/x/net v0.19.0
 	golang.org/x/sync v0.5.0
+	golang.org/x/text v0.14.0
 )
 
 require (
@@ -22,7 +25,6 @@ require (
 	go.uber.org/atomic v1.11.0 // indirect
 	go.uber.org/multierr v1.11.0 // indirect
 	golang.org/x/crypto v0.17.0 // indirect
-	golang.org/x/net v0.19.0 // indirect
 	golang.org/x/sys v0.15.0 // indirect
 	nhooyr.io/websocket v1.8.10 // indirect
 	rsc.io/qr v0.2.0 // indirect
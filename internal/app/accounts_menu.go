@@ -3,12 +3,14 @@ package app
 import (
 	"context"
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
 
 	"getclient/internal/store"
+	"getclient/internal/telegramutil"
 	"getclient/internal/ui"
 )
 
@@ -32,8 +34,11 @@ func menuAddAccount(ctx context.Context, m *ui.Menu, st *store.State) error {
 		}
 	}
 
-	sessionPath := filepath.Join("data", "sessions", name+".bin")
-	ok, err := m.Confirm(fmt.Sprintf("Войти сейчас и создать session-файл %q?", sessionPath))
+	acc := store.Account{Name: name, SessionPath: filepath.Join("data", "sessions", name+".bin")}
+	if err := promptProxy(m, &acc.Proxy); err != nil {
+		return err
+	}
+	ok, err := m.Confirm(fmt.Sprintf("Войти сейчас и создать session-файл %q?", acc.SessionPath))
 	if err != nil {
 		return err
 	}
@@ -41,33 +46,167 @@ func menuAddAccount(ctx context.Context, m *ui.Menu, st *store.State) error {
 		return nil
 	}
 
-	if err := loginAndSaveSession(ctx, name, sessionPath); err != nil {
+	if err := loginAndSaveSession(ctx, acc); err != nil {
 		return err
 	}
 
-	st.Accounts = append(st.Accounts, store.Account{Name: name, SessionPath: sessionPath})
+	st.Accounts = append(st.Accounts, acc)
 	return saveAccountsJSON(st.AccountsFile, st.Accounts)
 }
 
-func menuRemoveAccount(m *ui.Menu, st *store.State) error {
-	m.Title("Удалить аккаунт")
+func menuAccounts(m *ui.Menu, st *store.State) error {
+	m.Title("Аккаунты")
 	if len(st.Accounts) == 0 {
 		m.Linef("Аккаунтов нет.")
 		return nil
 	}
 	for i, a := range st.Accounts {
-		m.Linef("%d) %s (%s)", i+1, a.Name, a.SessionPath)
+		line := fmt.Sprintf("%d) %s (%s)", i+1, a.Name, a.SessionPath)
+		if s := accountSummary(a); s != "" {
+			line += " — " + s
+		}
+		m.Linef("%s", line)
+	}
+	m.Linef("")
+	m.Linef("1) Настройки аккаунта")
+	m.Linef("2) Удалить аккаунт")
+	m.Linef("0) Назад")
+	s, err := m.Prompt("Выберите пункт")
+	if err != nil {
+		return err
 	}
-	s, err := m.Prompt("Введите номер аккаунта для удаления")
+	switch s {
+	case "1":
+		return menuAccountSettings(m, st)
+	case "2":
+		return menuRemoveAccount(m, st)
+	}
+	return nil
+}
+
+// menuAccountSettings — прокси, устройство, наборы правил и отключение аккаунта.
+// Изменения применяются при следующем запуске мониторинга.
+func menuAccountSettings(m *ui.Menu, st *store.State) error {
+	idx, err := promptAccountIndex(m, st, "Номер аккаунта")
 	if err != nil {
 		return err
 	}
+	acc := st.Accounts[idx]
+	if err := promptProxy(m, &acc.Proxy); err != nil {
+		return err
+	}
+	m.Linef("Устройство, которым аккаунт представляется Telegram (пусто везде = по умолчанию).")
+	for _, f := range []struct {
+		label string
+		v     *string
+	}{
+		{"Модель устройства", &acc.Device.Model},
+		{"Версия системы", &acc.Device.SystemVersion},
+		{"Версия приложения", &acc.Device.AppVersion},
+		{"Код языка (ru, en…)", &acc.Device.LangCode},
+	} {
+		if err := promptText(m, f.label, f.v); err != nil {
+			return err
+		}
+	}
+	m.Linef("Наборы правил: %s", strings.Join(ruleSetNames(st), ", "))
+	if err := promptSenderList(m, "Наборы правил аккаунта через запятую (пустой список = все)", &acc.RuleSets); err != nil {
+		return err
+	}
+	for _, name := range acc.RuleSets {
+		if name != defaultRuleSet && findRuleSet(st, name) == nil {
+			return fmt.Errorf("набор %q не найден", name)
+		}
+	}
+	if err := promptToggle(m, "Аккаунт отключён?", &acc.Disabled); err != nil {
+		return err
+	}
+	st.Accounts[idx] = acc
+	return saveAccountsJSON(st.AccountsFile, st.Accounts)
+}
+
+func promptAccountIndex(m *ui.Menu, st *store.State, label string) (int, error) {
+	s, err := m.Prompt(label)
+	if err != nil {
+		return 0, err
+	}
 	idx := 0
 	_, _ = fmt.Sscanf(s, "%d", &idx)
 	if idx < 1 || idx > len(st.Accounts) {
-		return fmt.Errorf("неверный выбор")
+		return 0, fmt.Errorf("неверный выбор")
+	}
+	return idx - 1, nil
+}
+
+func promptProxy(m *ui.Menu, proxy *string) error {
+	current := "нет"
+	if *proxy != "" {
+		current = *proxy
+	}
+	s, err := m.Prompt(fmt.Sprintf("Прокси: socks5://, http://, mtproxy://host:port?secret= или ссылка t.me/proxy (сейчас: %s; пусто = оставить, - = без прокси)", current))
+	if err != nil {
+		return err
+	}
+	switch s = strings.TrimSpace(s); s {
+	case "":
+	case "-":
+		*proxy = ""
+	default:
+		if _, err := telegramutil.ProxyResolver(s); err != nil {
+			return err
+		}
+		*proxy = s
+	}
+	return nil
+}
+
+func promptText(m *ui.Menu, label string, v *string) error {
+	current := "пусто"
+	if *v != "" {
+		current = *v
+	}
+	s, err := m.Prompt(fmt.Sprintf("%s (сейчас: %s; пусто = оставить, - = очистить)", label, current))
+	if err != nil {
+		return err
+	}
+	switch s = strings.TrimSpace(s); s {
+	case "":
+	case "-":
+		*v = ""
+	default:
+		*v = s
+	}
+	return nil
+}
+
+func accountSummary(a store.Account) string {
+	var parts []string
+	if a.Disabled {
+		parts = append(parts, ui.Red("отключён"))
+	}
+	if a.Proxy != "" {
+		u, err := url.Parse(a.Proxy)
+		if err == nil && u.Scheme != "" {
+			parts = append(parts, "прокси: "+u.Scheme)
+		} else {
+			parts = append(parts, "прокси")
+		}
+	}
+	if a.Device.Model != "" {
+		parts = append(parts, "устройство: "+a.Device.Model)
+	}
+	if len(a.RuleSets) > 0 {
+		parts = append(parts, "наборы: "+strings.Join(a.RuleSets, ", "))
+	}
+	return strings.Join(parts, "; ")
+}
+
+func menuRemoveAccount(m *ui.Menu, st *store.State) error {
+	idx, err := promptAccountIndex(m, st, "Введите номер аккаунта для удаления")
+	if err != nil {
+		return err
 	}
-	acc := st.Accounts[idx-1]
+	acc := st.Accounts[idx]
 	ok, err := m.Confirm(fmt.Sprintf("Удалить аккаунт %q?", acc.Name))
 	if err != nil {
 		return err
@@ -76,7 +215,7 @@ func menuRemoveAccount(m *ui.Menu, st *store.State) error {
 		return nil
 	}
 
-	st.Accounts = append(st.Accounts[:idx-1], st.Accounts[idx:]...)
+	st.Accounts = append(st.Accounts[:idx], st.Accounts[idx+1:]...)
 	_ = saveAccountsJSON(st.AccountsFile, st.Accounts)
 
 	del, err := m.Confirm("Также удалить session-файл?")
@@ -0,0 +1,64 @@
+package app
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"getclient/internal/monitor"
+)
+
+const (
+	statusConnecting = "подключение"
+	statusOnline     = "в сети"
+	statusStopped    = "остановлен"
+)
+
+type accountState struct {
+	name    string
+	status  atomic.Value
+	monitor atomic.Pointer[monitor.Monitor]
+}
+
+func (a *accountState) setStatus(s string) {
+	a.status.Store(s)
+}
+
+func (a *accountState) Status() string {
+	if s, ok := a.status.Load().(string); ok {
+		return s
+	}
+	return statusConnecting
+}
+
+func (a *accountState) setMonitor(m *monitor.Monitor) {
+	a.monitor.Store(m)
+}
+
+func (a *accountState) Monitor() *monitor.Monitor {
+	return a.monitor.Load()
+}
+
+type accountRegistry struct {
+	mu       sync.Mutex
+	accounts []*accountState
+}
+
+func (r *accountRegistry) add(name string) *accountState {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, a := range r.accounts {
+		if a.name == name {
+			return a
+		}
+	}
+	a := &accountState{name: name}
+	a.setStatus(statusConnecting)
+	r.accounts = append(r.accounts, a)
+	return a
+}
+
+func (r *accountRegistry) list() []*accountState {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*accountState(nil), r.accounts...)
+}
@@ -6,8 +6,9 @@ import (
 	"fmt"
 	"os"
 
+	"getclient/internal/classifier"
 	"getclient/internal/config"
-	"getclient/internal/notifier"
+	"getclient/internal/monitor"
 	"getclient/internal/store"
 	"getclient/internal/ui"
 	"sync"
@@ -39,15 +40,27 @@ func RunWithConfig(ctx context.Context, cfg config.Config) int {
 	logger, _ := loggerCfg.Build()
 	defer logger.Sync()
 
-	bot := notifier.NewTelegramBot(cfg.BotToken, cfg.BotChatID)
-	var n notifier.Notifier
-	if bot.Enabled() {
-		n = bot
+	rules := monitor.NewRules(loadRuleSets(cfg, logger), cfg.MutedSenders, cfg.MutedChats)
+	rules.SetPaused(cfg.Paused)
+	if cfg.Paused {
+		logger.Warn("Мониторинг на паузе (команда /resume в боте)")
 	}
-	logger.Info("Бот-уведомления",
-		zap.Bool("enabled", bot.Enabled()),
-		zap.Int64("chat_id", cfg.BotChatID),
-	)
+
+	scripts, err := monitor.LoadScripts(scriptsDir, logger)
+	if err != nil {
+		logger.Error("Ошибка в скриптах", zap.String("dir", scriptsDir), zap.Error(err))
+		return 2
+	}
+	if names := scripts.Names(); len(names) > 0 {
+		logger.Info("Загружены скрипты", zap.Strings("rule_sets", names))
+	}
+	rules.SetScripts(scripts)
+	go scripts.Watch(ctx, scriptsReload)
+
+	bot := newMainBot(cfg, logger)
+	n, outboxes := buildNotifier(cfg, bot, logger)
+	stopOutboxes := runOutboxes(ctx, outboxes, logger)
+	defer stopOutboxes()
 
 	db, err := store.OpenBaseDB("data/base.json")
 	if err != nil {
@@ -56,13 +69,94 @@ func RunWithConfig(ctx context.Context, cfg config.Config) int {
 	}
 	defer db.Close()
 
-	var globalSeen sync.Map
+	replyLog, err := monitor.OpenReplyLog(autoReplyLogPath)
+	if err != nil {
+		logger.Error("Журнал автоответов не открыт", zap.Error(err))
+		return 2
+	}
+	replied, err := store.OpenBaseDBTTL(autoReplyDBPath, autoReplyRepeat)
+	if err != nil {
+		logger.Error("Base error", zap.Error(err))
+		return 2
+	}
+	defer replied.Close()
+	if cfg.AutoReplyDryRun {
+		logger.Warn("Автоответы в пробном режиме: ничего не отправляется, только журнал", zap.String("log", autoReplyLogPath))
+	}
+
+	var cls *classifier.Classifier
+	if cfg.Classifier {
+		if cls, err = classifier.Open(classifierPath); err != nil {
+			logger.Warn("Классификатор не загружен", zap.Error(err))
+		} else {
+			rel, irr := cls.Stats()
+			logger.Info("Классификатор", zap.Int("relevant", rel), zap.Int("irrelevant", irr), zap.Float64("threshold", classifierThreshold(cfg.ClassifierThreshold)))
+		}
+	}
 
-	g, ctx := errgroup.WithContext(ctx)
+	var hook *monitor.Hook
+	if cfg.Hook != "" {
+		hook = monitor.NewHook(cfg.Hook, cfg.HookTimeout, !cfg.HookFailClosed)
+		logger.Info("Внешний фильтр алертов", zap.String("hook", cfg.Hook), zap.Bool("fail_closed", cfg.HookFailClosed))
+	}
+
+	var accounts []config.Account
 	for _, acc := range cfg.Accounts {
+		if acc.Disabled {
+			logger.Info("Аккаунт отключён", zap.String("account", acc.Name))
+			continue
+		}
+		for _, name := range acc.RuleSets {
+			if !hasRuleSet(rules, name) {
+				logger.Warn("Набор правил аккаунта не найден", zap.String("account", acc.Name), zap.String("rule_set", name))
+			}
+		}
+		accounts = append(accounts, acc)
+	}
+	if len(accounts) == 0 {
+		logger.Error("Нет включённых аккаунтов")
+		return 2
+	}
+
+	// С несколькими аккаунтами каждый чат ведёт один из них. Аккаунты со своими
+	// наборами правил в распределении не участвуют и проверяют свои чаты сами.
+	var planner *monitor.Planner
+	var planned []string
+	for _, acc := range accounts {
+		if len(acc.RuleSets) == 0 {
+			planned = append(planned, acc.Name)
+		}
+	}
+	if len(planned) > 1 {
+		planner = monitor.NewPlanner(planned)
+		go writeCoverage(ctx, planner, logger)
+	}
+
+	r := &runner{
+		cfg:        cfg,
+		rules:      rules,
+		notify:     n,
+		limiter:    db,
+		globalSeen: &sync.Map{},
+		accounts:   &accountRegistry{},
+		classifier: cls,
+		hook:       hook,
+		replyLog:   replyLog,
+		replied:    replied,
+		planner:    planner,
+		logger:     logger,
+	}
+
+	if cfg.BotControl && bot.Enabled() {
+		stopControl := runBotControl(ctx, newBotControl(r, bot, outboxes))
+		defer stopControl()
+	}
+
+	g, ctx := errgroup.WithContext(ctx)
+	for _, acc := range accounts {
 		acc := acc
 		g.Go(func() error {
-			return runAccount(ctx, cfg, acc, n, db, &globalSeen, logger)
+			return r.runAccount(ctx, acc)
 		})
 	}
 
@@ -76,5 +170,11 @@ func RunWithConfig(ctx context.Context, cfg config.Config) int {
 	return 0
 }
 
-
-
+func hasRuleSet(rules *monitor.Rules, name string) bool {
+	for _, rs := range rules.RuleSets() {
+		if rs.Name == name {
+			return true
+		}
+	}
+	return false
+}
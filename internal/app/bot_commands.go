@@ -0,0 +1,204 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"getclient/internal/store"
+
+	"go.uber.org/zap"
+)
+
+const botHelp = `Команды:
+/status — состояние мониторинга
+/pause, /resume — приостановить и продолжить
+/kw [набор] add|del|list [фраза] — ключевые фразы
+/stop [набор] add|del|list [слово] — стоп-слова
+/accounts — аккаунты
+/coverage — какой аккаунт ведёт какие чаты
+/stats — статистика`
+
+func (c *botControl) command(text string) string {
+	cmd, args := cutWord(text)
+	cmd = strings.ToLower(cmd)
+	if i := strings.IndexByte(cmd, '@'); i > 0 {
+		cmd = cmd[:i]
+	}
+	c.logger.Info("Команда бота", zap.String("command", cmd))
+
+	switch cmd {
+	case "/start", "/help":
+		return botHelp
+	case "/status":
+		return c.status()
+	case "/pause":
+		return c.setPaused(true)
+	case "/resume":
+		return c.setPaused(false)
+	case "/kw":
+		return c.words(args, false)
+	case "/stop":
+		return c.words(args, true)
+	case "/accounts":
+		return c.accountsList()
+	case "/coverage":
+		return c.coverage()
+	case "/stats":
+		return c.stats()
+	}
+	return "Неизвестная команда.\n\n" + botHelp
+}
+
+func (c *botControl) status() string {
+	state := "работает"
+	if c.rules.Paused() {
+		state = "на паузе"
+	}
+	online := 0
+	accounts := c.accounts.list()
+	for _, a := range accounts {
+		if a.Status() == statusOnline {
+			online++
+		}
+	}
+	keywords := 0
+	ruleSets := cfgRuleSets(c.cfg)
+	for _, rs := range ruleSets {
+		keywords += len(readLines(rs.KeywordsFile))
+	}
+	return fmt.Sprintf("Мониторинг: %s\nАккаунтов в сети: %d из %d\nНаборов правил: %d (фраз: %d)\nВ очереди уведомлений: %d",
+		state, online, len(accounts), len(ruleSets), keywords, c.queueDepth())
+}
+
+func (c *botControl) setPaused(paused bool) string {
+	c.rules.SetPaused(paused)
+	c.logger.Info("Пауза мониторинга", zap.Bool("paused", paused))
+	msg := "Мониторинг продолжен"
+	if paused {
+		msg = "Мониторинг приостановлен"
+	}
+	if err := c.persist(func(st *store.State) { st.Paused = paused }); err != nil {
+		msg += " (не сохранено: " + err.Error() + ")"
+	}
+	return msg
+}
+
+func (c *botControl) words(args string, stop bool) string {
+	label := "фраза"
+	if stop {
+		label = "стоп-слово"
+	}
+
+	ruleSet := defaultRuleSet
+	op, rest := cutWord(args)
+	op = strings.ToLower(op)
+	if !isWordsOp(op) {
+		if op2, rest2 := cutWord(rest); isWordsOp(strings.ToLower(op2)) {
+			ruleSet, op, rest = op, strings.ToLower(op2), rest2
+		}
+	}
+	if !isWordsOp(op) {
+		return botHelp
+	}
+
+	file := ruleSetWordsFile(c.cfg, ruleSet, stop)
+	if file == "" {
+		return fmt.Sprintf("У набора «%s» нет такого файла", ruleSet)
+	}
+
+	switch op {
+	case "list":
+		lines := readLines(file)
+		if len(lines) == 0 {
+			return fmt.Sprintf("Набор «%s»: список пуст", ruleSet)
+		}
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "Набор «%s» (%d):\n", ruleSet, len(lines))
+		for i, l := range lines {
+			if i == 100 {
+				fmt.Fprintf(&sb, "… и ещё %d", len(lines)-i)
+				break
+			}
+			fmt.Fprintf(&sb, "%d. %s\n", i+1, l)
+		}
+		return strings.TrimSpace(sb.String())
+	case "add":
+		if rest == "" {
+			return "Укажите " + label
+		}
+		if err := appendWord(file, rest); err != nil {
+			return "Ошибка: " + err.Error()
+		}
+		c.reloadRules()
+		return fmt.Sprintf("Добавлено в «%s»: %s", ruleSet, rest)
+	case "del":
+		found, err := removeWord(file, rest)
+		if err != nil {
+			return "Ошибка: " + err.Error()
+		}
+		if !found {
+			return "Не найдено: " + rest
+		}
+		c.reloadRules()
+		return fmt.Sprintf("Удалено из «%s»: %s", ruleSet, rest)
+	}
+	return botHelp
+}
+
+func (c *botControl) accountsList() string {
+	accounts := c.accounts.list()
+	if len(accounts) == 0 {
+		return "Аккаунтов нет"
+	}
+	var sb strings.Builder
+	for _, a := range accounts {
+		fmt.Fprintf(&sb, "%s — %s\n", a.name, a.Status())
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+func (c *botControl) coverage() string {
+	if c.planner == nil {
+		return "Распределение чатов работает, когда аккаунтов больше одного"
+	}
+	return c.planner.Report().String()
+}
+
+func (c *botControl) stats() string {
+	var sb strings.Builder
+	var total [3]int64
+	for _, a := range c.accounts.list() {
+		mon := a.Monitor()
+		if mon == nil {
+			continue
+		}
+		s := mon.Stats()
+		total[0] += s.Processed
+		total[1] += s.Matched
+		total[2] += s.Alerted
+		fmt.Fprintf(&sb, "%s: сообщений %d, совпадений %d, алертов %d\n", a.name, s.Processed, s.Matched, s.Alerted)
+	}
+	fmt.Fprintf(&sb, "Всего: сообщений %d, совпадений %d, алертов %d\nВ очереди уведомлений: %d",
+		total[0], total[1], total[2], c.queueDepth())
+	return sb.String()
+}
+
+func (c *botControl) queueDepth() int {
+	n := 0
+	for _, ob := range c.outboxes {
+		n += ob.Depth()
+	}
+	return n
+}
+
+func isWordsOp(op string) bool {
+	return op == "add" || op == "del" || op == "list"
+}
+
+func cutWord(s string) (string, string) {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexAny(s, " \t\n"); i >= 0 {
+		return s[:i], strings.TrimSpace(s[i+1:])
+	}
+	return s, ""
+}
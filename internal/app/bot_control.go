@@ -0,0 +1,239 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"getclient/internal/classifier"
+	"getclient/internal/config"
+	"getclient/internal/monitor"
+	"getclient/internal/notifier"
+	"getclient/internal/store"
+
+	"go.uber.org/zap"
+)
+
+type botControl struct {
+	cfg      config.Config
+	bot      *notifier.TelegramBot
+	rules    *monitor.Rules
+	accounts *accountRegistry
+	outboxes []*notifier.Outbox
+	cls      *classifier.Classifier
+	planner  *monitor.Planner
+	logger   *zap.Logger
+
+	mu      sync.Mutex
+	prompts map[int]string
+}
+
+func newBotControl(r *runner, bot *notifier.TelegramBot, outboxes []*notifier.Outbox) *botControl {
+	return &botControl{
+		cfg:      r.cfg,
+		bot:      bot,
+		rules:    r.rules,
+		accounts: r.accounts,
+		outboxes: outboxes,
+		cls:      r.classifier,
+		planner:  r.planner,
+		logger:   r.logger,
+		prompts:  make(map[int]string),
+	}
+}
+
+func runBotControl(ctx context.Context, c *botControl) func() {
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		c.logger.Info("Управление через бота включено", zap.Int64("chat_id", c.bot.ChatID()))
+		c.bot.PollUpdates(ctx, c.handle, func(err error) {
+			c.logger.Warn("Bot getUpdates failed", zap.Error(err))
+		})
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+func (c *botControl) allowed(chatID int64) bool {
+	if chatID == c.cfg.BotChatID {
+		return true
+	}
+	for _, id := range c.cfg.ControlChatIDs {
+		if id == chatID {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *botControl) handle(ctx context.Context, u notifier.Update) {
+	switch {
+	case u.CallbackQuery != nil:
+		c.handleCallback(ctx, u.CallbackQuery)
+	case u.Message != nil:
+		c.handleMessage(ctx, u.Message)
+	}
+}
+
+func (c *botControl) handleCallback(ctx context.Context, q *notifier.CallbackQuery) {
+	if q.Message == nil || !c.allowed(q.Message.Chat.ID) {
+		_ = c.bot.AnswerCallback(ctx, q.ID, "Нет доступа")
+		return
+	}
+
+	var reply string
+	var err error
+	action, arg := notifier.ParseAction(q.Data)
+	switch action {
+	case notifier.ActionMuteSender:
+		reply, err = c.muteSender(arg)
+	case notifier.ActionMuteChat:
+		reply, err = c.muteChat(arg)
+	case notifier.ActionStopword:
+		reply, err = c.askStopword(ctx, q.Message.Chat.ID, arg)
+	case notifier.ActionLabel:
+		reply, err = c.label(arg)
+	case notifier.ActionDone:
+		err = c.bot.EditReplyMarkup(ctx, q.Message.Chat.ID, q.Message.MessageID, notifier.DoneKeyboard())
+		reply = "Отмечено как обработанное"
+	}
+	if err != nil {
+		c.logger.Warn("Bot action failed", zap.String("action", q.Data), zap.Error(err))
+		reply = "Ошибка: " + err.Error()
+	}
+	if err := c.bot.AnswerCallback(ctx, q.ID, reply); err != nil {
+		c.logger.Warn("answerCallbackQuery failed", zap.Error(err))
+	}
+}
+
+func (c *botControl) handleMessage(ctx context.Context, msg *notifier.Message) {
+	if !c.allowed(msg.Chat.ID) {
+		if strings.HasPrefix(msg.Text, "/") {
+			c.logger.Warn("Команда из чужого чата отклонена", zap.Int64("chat_id", msg.Chat.ID), zap.String("text", msg.Text))
+		}
+		return
+	}
+	if msg.ReplyToMessage != nil {
+		if ruleSet, ok := c.takePrompt(msg.ReplyToMessage.MessageID); ok {
+			c.reply(ctx, msg.Chat.ID, c.addStopword(ruleSet, msg.Text))
+			return
+		}
+	}
+	if strings.HasPrefix(msg.Text, "/") {
+		c.reply(ctx, msg.Chat.ID, c.command(msg.Text))
+	}
+}
+
+func (c *botControl) label(arg string) (string, error) {
+	key, relevant, ok := notifier.ParseLabel(arg)
+	if !ok {
+		return "", fmt.Errorf("неверная метка")
+	}
+	if c.cls == nil {
+		return "", fmt.Errorf("классификатор выключен")
+	}
+	if err := c.cls.Label(key, relevant); err != nil {
+		return "", err
+	}
+	rel, irr := c.cls.Stats()
+	if relevant {
+		return fmt.Sprintf("Учтено: в тему (%d / %d)", rel, irr), nil
+	}
+	return fmt.Sprintf("Учтено: мимо (%d / %d)", rel, irr), nil
+}
+
+func (c *botControl) muteSender(arg string) (string, error) {
+	id, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil || id == 0 {
+		return "", fmt.Errorf("неверный id отправителя")
+	}
+	c.rules.MuteSender(id)
+	c.logger.Info("Отправитель заглушён", zap.Int64("sender_id", id))
+	return "Автор заглушён", c.persist(func(st *store.State) {
+		for _, x := range st.MutedSenders {
+			if x == id {
+				return
+			}
+		}
+		st.MutedSenders = append(st.MutedSenders, id)
+	})
+}
+
+func (c *botControl) muteChat(key string) (string, error) {
+	if key == "" {
+		return "", fmt.Errorf("неизвестный чат")
+	}
+	c.rules.MuteChat(key)
+	c.logger.Info("Чат заглушён", zap.String("chat", key))
+	return "Чат заглушён", c.persist(func(st *store.State) {
+		for _, x := range st.MutedChats {
+			if x == key {
+				return
+			}
+		}
+		st.MutedChats = append(st.MutedChats, key)
+	})
+}
+
+func (c *botControl) askStopword(ctx context.Context, chatID int64, ruleSet string) (string, error) {
+	if ruleSet == "" {
+		ruleSet = defaultRuleSet
+	}
+	if ruleSetWordsFile(c.cfg, ruleSet, true) == "" {
+		return "", fmt.Errorf("у набора %q нет файла стоп-слов", ruleSet)
+	}
+	msg, err := c.bot.SendText(ctx, chatID,
+		fmt.Sprintf("Ответьте на это сообщение стоп-словом для набора «%s» (можно скопировать фрагмент из алерта).", ruleSet),
+		notifier.ForceReply())
+	if err != nil {
+		return "", err
+	}
+	c.mu.Lock()
+	c.prompts[msg.MessageID] = ruleSet
+	c.mu.Unlock()
+	return "Пришлите стоп-слово ответом", nil
+}
+
+func (c *botControl) takePrompt(messageID int) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ruleSet, ok := c.prompts[messageID]
+	delete(c.prompts, messageID)
+	return ruleSet, ok
+}
+
+func (c *botControl) addStopword(ruleSet, word string) string {
+	word = strings.TrimSpace(word)
+	if word == "" {
+		return "Пустое стоп-слово, ничего не добавлено"
+	}
+	if err := appendWord(ruleSetWordsFile(c.cfg, ruleSet, true), word); err != nil {
+		return "Ошибка: " + err.Error()
+	}
+	c.reloadRules()
+	c.logger.Info("Добавлено стоп-слово", zap.String("rule_set", ruleSet), zap.String("word", word))
+	return fmt.Sprintf("Стоп-слово «%s» добавлено в набор «%s»", word, ruleSet)
+}
+
+func (c *botControl) reloadRules() {
+	c.rules.SetRuleSets(loadRuleSets(c.cfg, c.logger))
+}
+
+func (c *botControl) reply(ctx context.Context, chatID int64, text string) {
+	if _, err := c.bot.SendText(ctx, chatID, text, nil); err != nil {
+		c.logger.Warn("Bot reply failed", zap.Error(err))
+	}
+}
+
+func (c *botControl) persist(fn func(st *store.State)) error {
+	if c.cfg.StatePath == "" {
+		return nil
+	}
+	return store.Update(c.cfg.StatePath, fn)
+}
@@ -0,0 +1,144 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"getclient/internal/config"
+	"getclient/internal/monitor"
+	"getclient/internal/notifier"
+
+	"go.uber.org/zap"
+)
+
+const (
+	controlChat = int64(-1001)
+	foreignChat = int64(-2002)
+)
+
+type botCall struct {
+	method string
+	form   url.Values
+}
+
+// fakeBot — локальный Bot API, который на всё отвечает ok и запоминает запросы.
+type fakeBot struct {
+	mu    sync.Mutex
+	calls []botCall
+}
+
+func (f *fakeBot) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	_ = r.ParseForm()
+	f.mu.Lock()
+	f.calls = append(f.calls, botCall{method: r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:], form: r.PostForm})
+	f.mu.Unlock()
+	_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "result": map[string]any{"message_id": 100}})
+}
+
+func (f *fakeBot) callsTo(method string) []botCall {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []botCall
+	for _, c := range f.calls {
+		if c.method == method {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+func newTestControl(t *testing.T) (*botControl, *fakeBot) {
+	fake := &fakeBot{}
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+	cfg := config.Config{BotChatID: controlChat}
+	bot := notifier.NewTelegramBot("test-"+t.Name(), controlChat).WithAPIURL(srv.URL)
+	c := &botControl{
+		cfg:      cfg,
+		bot:      bot,
+		rules:    monitor.NewRules(nil, nil, nil),
+		accounts: &accountRegistry{},
+		logger:   zap.NewNop(),
+		prompts:  make(map[int]string),
+	}
+	return c, fake
+}
+
+func callback(chatID int64, data string) notifier.Update {
+	return notifier.Update{UpdateID: 1, CallbackQuery: &notifier.CallbackQuery{
+		ID:      "q1",
+		Data:    data,
+		Message: &notifier.Message{MessageID: 42, Chat: notifier.Chat{ID: chatID}},
+	}}
+}
+
+func TestBotControlCallbacks(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		chat   int64
+		data   string
+		answer string
+		muted  bool
+		edited bool
+	}{
+		{"mute sender", controlChat, "ms:777", "Автор заглушён", true, false},
+		{"done", controlChat, "done", "Отмечено как обработанное", false, true},
+		{"foreign chat", foreignChat, "ms:777", "Нет доступа", false, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c, fake := newTestControl(t)
+			c.handle(context.Background(), callback(tc.chat, tc.data))
+
+			answers := fake.callsTo("answerCallbackQuery")
+			if len(answers) != 1 {
+				t.Fatalf("answerCallbackQuery calls = %d, want 1", len(answers))
+			}
+			if got := answers[0].form.Get("text"); got != tc.answer {
+				t.Errorf("answer = %q, want %q", got, tc.answer)
+			}
+			if got := answers[0].form.Get("callback_query_id"); got != "q1" {
+				t.Errorf("callback_query_id = %q, want q1", got)
+			}
+			if got := c.rules.SenderMuted(777); got != tc.muted {
+				t.Errorf("sender muted = %v, want %v", got, tc.muted)
+			}
+			edits := fake.callsTo("editMessageReplyMarkup")
+			if (len(edits) == 1) != tc.edited {
+				t.Errorf("editMessageReplyMarkup calls = %d, want edited = %v", len(edits), tc.edited)
+			}
+			if tc.edited && edits[0].form.Get("message_id") != "42" {
+				t.Errorf("edited message_id = %q, want 42", edits[0].form.Get("message_id"))
+			}
+		})
+	}
+}
+
+func TestBotControlCommandWhitelist(t *testing.T) {
+	c, fake := newTestControl(t)
+	msg := func(chatID int64) notifier.Update {
+		return notifier.Update{Message: &notifier.Message{MessageID: 1, Chat: notifier.Chat{ID: chatID}, Text: "/pause"}}
+	}
+
+	c.handle(context.Background(), msg(foreignChat))
+	if n := len(fake.callsTo("sendMessage")); n != 0 {
+		t.Fatalf("reply to foreign chat: %d messages", n)
+	}
+	if c.rules.Paused() {
+		t.Fatal("command from foreign chat was executed")
+	}
+
+	c.handle(context.Background(), msg(controlChat))
+	sent := fake.callsTo("sendMessage")
+	if len(sent) != 1 || sent[0].form.Get("chat_id") != "-1001" {
+		t.Fatalf("sendMessage calls = %v, want one reply to control chat", sent)
+	}
+	if !c.rules.Paused() {
+		t.Error("/pause from control chat was not executed")
+	}
+}
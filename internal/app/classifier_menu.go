@@ -0,0 +1,96 @@
+package app
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"getclient/internal/classifier"
+	"getclient/internal/notifier"
+	"getclient/internal/store"
+	"getclient/internal/ui"
+)
+
+const (
+	classifierPath             = "data/classifier.json"
+	defaultClassifierThreshold = 0.5
+)
+
+func classifierThreshold(v float64) float64 {
+	if v > 0 {
+		return v
+	}
+	return defaultClassifierThreshold
+}
+
+func menuClassifier(m *ui.Menu, st *store.State) error {
+	cls, err := classifier.Open(classifierPath)
+	if err != nil {
+		return err
+	}
+	rel, irr := cls.Stats()
+	m.Title("Классификатор релевантности")
+	m.Linef("Включён: %v, порог: %g", st.Classifier, classifierThreshold(st.ClassifierThreshold))
+	m.Linef("Размечено: в тему %d, мимо %d, ждут разметки %d", rel, irr, len(cls.Unlabeled()))
+	m.Linef("")
+	m.Linef("1) Разметить алерты")
+	m.Linef("2) Настройки")
+	m.Linef("0) Назад")
+	s, err := m.Prompt("Выберите пункт")
+	if err != nil {
+		return err
+	}
+	switch s {
+	case "1":
+		return menuLabelHits(m, cls)
+	case "2":
+		return menuClassifierSettings(m, st)
+	}
+	return nil
+}
+
+func menuLabelHits(m *ui.Menu, cls *classifier.Classifier) error {
+	hits := cls.Unlabeled()
+	if len(hits) == 0 {
+		m.Linef("Нет неразмеченных алертов.")
+		return nil
+	}
+	for i, h := range hits {
+		m.Title(fmt.Sprintf("%d/%d · %s · %s · %s", i+1, len(hits), h.Chat, h.RuleSet, h.Time.Local().Format("02.01 15:04")))
+		m.Linef("%s", h.Text)
+		if p, ok := cls.Score(h.Text); ok {
+			m.Linef("%s", notifier.Notification{Relevance: p}.RelevanceLine())
+		}
+		s, err := m.Prompt("1 = в тему, 0 = мимо, пусто = пропустить, q = выход")
+		if err != nil {
+			return err
+		}
+		switch strings.TrimSpace(s) {
+		case "1", "0":
+			if err := cls.Label(h.Key, s == "1"); err != nil {
+				return err
+			}
+		case "q", "Q":
+			return nil
+		}
+	}
+	return nil
+}
+
+func menuClassifierSettings(m *ui.Menu, st *store.State) error {
+	if err := promptToggle(m, "Оценивать алерты классификатором?", &st.Classifier); err != nil {
+		return err
+	}
+	th, err := m.Prompt(fmt.Sprintf("Порог релевантности 0..1 — ниже него алерт уходит получателям с приоритетом low (сейчас %g; пусто = оставить)", classifierThreshold(st.ClassifierThreshold)))
+	if err != nil {
+		return err
+	}
+	if th = strings.TrimSpace(th); th != "" {
+		v, err := strconv.ParseFloat(strings.Replace(th, ",", ".", 1), 64)
+		if err != nil || v <= 0 || v >= 1 {
+			return fmt.Errorf("порог должен быть между 0 и 1")
+		}
+		st.ClassifierThreshold = v
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"getclient/internal/config"
+	"getclient/internal/telegramutil"
+
+	"github.com/gotd/td/session"
+	"github.com/gotd/td/telegram"
+	"go.uber.org/zap"
+)
+
+// clientOptions — параметры подключения аккаунта: сессия, прокси и устройство.
+func clientOptions(acc config.Account) (telegram.Options, error) {
+	resolver, err := telegramutil.ProxyResolver(acc.Proxy)
+	if err != nil {
+		return telegram.Options{}, fmt.Errorf("аккаунт %s: %w", acc.Name, err)
+	}
+	return telegram.Options{
+		Logger: zap.NewNop(),
+		SessionStorage: &session.FileStorage{
+			Path: acc.SessionPath,
+		},
+		Resolver: resolver,
+		// Пустые поля gotd заполняет своими значениями (SetDefaults).
+		Device: telegram.DeviceConfig{
+			DeviceModel:    acc.Device.Model,
+			SystemVersion:  acc.Device.SystemVersion,
+			AppVersion:     acc.Device.AppVersion,
+			LangCode:       acc.Device.LangCode,
+			SystemLangCode: acc.Device.LangCode,
+		},
+	}, nil
+}
+
+const (
+	// Мониторинг обновляет отметку о занятой сессии раз в sessionMarkInterval;
+	// отметка старше sessionMarkTTL осталась от упавшего процесса.
+	sessionMarkInterval = time.Minute
+	sessionMarkTTL      = 3 * time.Minute
+)
+
+func sessionMarkPath(acc config.Account) string {
+	return acc.SessionPath + ".active"
+}
+
+// markSessionActive отмечает, что сессией аккаунта пользуется мониторинг, и
+// обновляет отметку, пока не отменён ctx. Возвращает функцию, снимающую отметку.
+func markSessionActive(ctx context.Context, acc config.Account) func() {
+	if acc.SessionPath == "" {
+		return func() {}
+	}
+	path := sessionMarkPath(acc)
+	touch := func() {
+		_ = os.WriteFile(path, []byte(strconv.Itoa(os.Getpid())), 0o600)
+	}
+	touch()
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		t := time.NewTicker(sessionMarkInterval)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+				touch()
+			}
+		}
+	}()
+	return func() {
+		cancel()
+		<-done
+		_ = os.Remove(path)
+	}
+}
+
+// sessionActive сообщает, работает ли с сессией аккаунта мониторинг — в этом
+// или другом процессе.
+func sessionActive(acc config.Account) bool {
+	if acc.SessionPath == "" {
+		return false
+	}
+	fi, err := os.Stat(sessionMarkPath(acc))
+	return err == nil && time.Since(fi.ModTime()) < sessionMarkTTL
+}
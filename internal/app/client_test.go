@@ -0,0 +1,96 @@
+package app
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"getclient/internal/config"
+
+	"github.com/gotd/td/session"
+	"github.com/gotd/td/telegram"
+	"github.com/gotd/td/tg"
+)
+
+func TestClientOptions(t *testing.T) {
+	opts, err := clientOptions(config.Account{Name: "main", SessionPath: "data/main.json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fs, ok := opts.SessionStorage.(*session.FileStorage); !ok || fs.Path != "data/main.json" {
+		t.Errorf("session storage = %#v", opts.SessionStorage)
+	}
+	if opts.Resolver != nil {
+		t.Errorf("resolver without proxy = %v, want direct", opts.Resolver)
+	}
+	// Без настроек устройства остаются значения gotd, а не пустые строки.
+	if opts.Device != (telegram.DeviceConfig{}) {
+		t.Errorf("device = %+v, want zero", opts.Device)
+	}
+	opts.Device.SetDefaults()
+	if opts.Device.DeviceModel != runtime.Version() || opts.Device.SystemVersion != runtime.GOOS || opts.Device.AppVersion == "" || opts.Device.LangCode != "en" {
+		t.Errorf("device defaults = %+v", opts.Device)
+	}
+
+	opts, err = clientOptions(config.Account{Name: "main", Device: config.Device{Model: "Pixel 8", LangCode: "ru"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts.Device.SetDefaults()
+	if d := opts.Device; d.DeviceModel != "Pixel 8" || d.SystemVersion != runtime.GOOS || d.LangCode != "ru" || d.SystemLangCode != "ru" {
+		t.Errorf("partial device = %+v", d)
+	}
+
+	opts, err = clientOptions(config.Account{Name: "main", Proxy: "socks5://127.0.0.1:1080"})
+	if err != nil || opts.Resolver == nil {
+		t.Errorf("proxy resolver = %v, %v", opts.Resolver, err)
+	}
+	if _, err := clientOptions(config.Account{Name: "main", Proxy: "ftp://proxy:21"}); err == nil || !strings.Contains(err.Error(), "main") {
+		t.Errorf("bad proxy error = %v, want it to name the account", err)
+	}
+}
+
+func TestSessionActive(t *testing.T) {
+	acc := config.Account{Name: "main", SessionPath: filepath.Join(t.TempDir(), "main.json")}
+	if sessionActive(acc) {
+		t.Fatal("fresh session reported active")
+	}
+	release := markSessionActive(context.Background(), acc)
+	if !sessionActive(acc) {
+		t.Fatal("session not active while marked")
+	}
+
+	called := false
+	err := withAccountAPI(context.Background(), config.Config{}, acc, func(ctx context.Context, api *tg.Client) error {
+		called = true
+		return nil
+	})
+	if err == nil || called || !strings.Contains(err.Error(), "мониторинг") {
+		t.Errorf("withAccountAPI on an active session = %v, called = %v", err, called)
+	}
+
+	release()
+	if sessionActive(acc) {
+		t.Error("session still active after release")
+	}
+
+	// Отметку упавшего процесса никто не обновляет, она устаревает.
+	path := sessionMarkPath(acc)
+	if err := os.WriteFile(path, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	stale := time.Now().Add(-sessionMarkTTL - time.Minute)
+	if err := os.Chtimes(path, stale, stale); err != nil {
+		t.Fatal(err)
+	}
+	if sessionActive(acc) {
+		t.Error("stale mark reported active")
+	}
+	if sessionActive(config.Account{Name: "memory"}) {
+		t.Error("account without session file reported active")
+	}
+}
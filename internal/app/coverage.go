@@ -0,0 +1,50 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"getclient/internal/monitor"
+
+	"go.uber.org/zap"
+)
+
+const (
+	coveragePath     = "data/coverage.json"
+	coverageInterval = time.Minute
+)
+
+// writeCoverage раз в минуту сохраняет отчёт о покрытии чатов и пишет в лог,
+// когда меняется число чатов без наблюдения.
+func writeCoverage(ctx context.Context, p *monitor.Planner, logger *zap.Logger) {
+	t := time.NewTicker(coverageInterval)
+	defer t.Stop()
+	last := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		r := p.Report()
+		if n := len(r.Unmonitored); n != last {
+			last = n
+			if n > 0 {
+				logger.Warn("Есть чаты без наблюдения", zap.Int("chats", n), zap.String("report", coveragePath))
+			} else {
+				logger.Info("Все чаты под наблюдением", zap.Int("chats", r.Chats))
+			}
+		}
+		data, err := json.MarshalIndent(r, "", "  ")
+		if err != nil {
+			continue
+		}
+		_ = os.MkdirAll(filepath.Dir(coveragePath), 0o700)
+		if err := os.WriteFile(coveragePath, data, 0o600); err != nil {
+			logger.Warn("Отчёт о покрытии не сохранён", zap.Error(err))
+		}
+	}
+}
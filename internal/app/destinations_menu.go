@@ -0,0 +1,144 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"getclient/internal/notifier"
+	"getclient/internal/store"
+	"getclient/internal/ui"
+)
+
+func menuDestinations(m *ui.Menu, st *store.State) error {
+	m.Title("Получатели уведомлений")
+	if len(st.Destinations) == 0 {
+		m.Linef("Дополнительных получателей нет (используется только основной бот).")
+	}
+	for i, d := range st.Destinations {
+		priority := ""
+		if d.Priority != "" {
+			priority = " (" + d.Priority + ")"
+		}
+		m.Linef("%d) %s [%s] %s%s", i+1, d.Name, d.Type, destinationTarget(d), priority)
+	}
+	m.Linef("")
+	m.Linef("1) Добавить получателя")
+	m.Linef("2) Удалить получателя")
+	m.Linef("0) Назад")
+	s, err := m.Prompt("Выберите пункт")
+	if err != nil {
+		return err
+	}
+	switch s {
+	case "1":
+		return menuAddDestination(m, st)
+	case "2":
+		return menuRemoveDestination(m, st)
+	}
+	return nil
+}
+
+func menuAddDestination(m *ui.Menu, st *store.State) error {
+	typ, err := m.Prompt("Тип получателя: bot / webhook / file")
+	if err != nil {
+		return err
+	}
+	d := store.Destination{Type: strings.ToLower(strings.TrimSpace(typ))}
+
+	switch d.Type {
+	case destBot:
+		tok, err := m.Prompt("BOT_TOKEN (пусто = токен основного бота)")
+		if err != nil {
+			return err
+		}
+		d.BotToken = strings.TrimSpace(tok)
+		if d.ChatID, err = m.PromptInt64("CHAT_ID"); err != nil {
+			return err
+		}
+		if d.ChatID == 0 {
+			return fmt.Errorf("пустой chat_id")
+		}
+		topic, err := m.PromptInt64("ID темы форума (пусто = без темы)")
+		if err != nil {
+			return err
+		}
+		d.TopicID = int(topic)
+	case destWebhook:
+		u, err := m.Prompt("URL вебхука (POST JSON)")
+		if err != nil {
+			return err
+		}
+		d.URL = strings.TrimSpace(u)
+		if d.URL == "" {
+			return fmt.Errorf("пустой URL")
+		}
+	case destFile:
+		p, err := m.Prompt("Путь к файлу (JSON Lines), например data/alerts.jsonl")
+		if err != nil {
+			return err
+		}
+		d.Path = strings.TrimSpace(p)
+		if d.Path == "" {
+			return fmt.Errorf("пустой путь")
+		}
+	default:
+		return fmt.Errorf("неизвестный тип %q", typ)
+	}
+
+	low := false
+	if err := promptToggle(m, "Только для алертов с низкой релевантностью (классификатор)?", &low); err != nil {
+		return err
+	}
+	if low {
+		d.Priority = notifier.PriorityLow
+	}
+
+	name, err := m.Prompt(fmt.Sprintf("Название (пусто = %s-%d)", d.Type, len(st.Destinations)+1))
+	if err != nil {
+		return err
+	}
+	d.Name = safeName.ReplaceAllString(strings.TrimSpace(name), "_")
+	if d.Name == "" {
+		d.Name = fmt.Sprintf("%s-%d", d.Type, len(st.Destinations)+1)
+	}
+	for _, x := range st.Destinations {
+		if x.Name == d.Name {
+			return fmt.Errorf("получатель %q уже существует", d.Name)
+		}
+	}
+
+	st.Destinations = append(st.Destinations, d)
+	return nil
+}
+
+func menuRemoveDestination(m *ui.Menu, st *store.State) error {
+	if len(st.Destinations) == 0 {
+		return nil
+	}
+	idx64, err := m.PromptInt64("Введите номер получателя для удаления")
+	if err != nil {
+		return err
+	}
+	idx := int(idx64)
+	if idx < 1 || idx > len(st.Destinations) {
+		return fmt.Errorf("неверный выбор")
+	}
+	ok, err := m.Confirm(fmt.Sprintf("Удалить получателя %q?", st.Destinations[idx-1].Name))
+	if err != nil || !ok {
+		return err
+	}
+	st.Destinations = append(st.Destinations[:idx-1], st.Destinations[idx:]...)
+	return nil
+}
+
+func destinationTarget(d store.Destination) string {
+	switch d.Type {
+	case destBot:
+		return fmt.Sprintf("chat_id=%d", d.ChatID)
+	case destWebhook:
+		return d.URL
+	case destFile:
+		return d.Path
+	}
+	return ""
+}
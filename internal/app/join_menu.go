@@ -0,0 +1,344 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"getclient/internal/config"
+	"getclient/internal/join"
+	"getclient/internal/store"
+	"getclient/internal/ui"
+
+	"github.com/gotd/td/telegram"
+	"github.com/gotd/td/tg"
+)
+
+const (
+	joinLogPath    = "data/join.log"
+	discoveredPath = "data/discovered.json"
+)
+
+func menuJoin(ctx context.Context, m *ui.Menu, st *store.State) error {
+	m.Title("Вступление в чаты")
+	cfg, err := configFromEnvAndState(*st)
+	if err != nil {
+		return err
+	}
+	acc, err := promptAccount(m, cfg.Accounts)
+	if err != nil {
+		return err
+	}
+	m.Linef("1) Вступить по списку ссылок")
+	m.Linef("2) Найти группы по ключевым словам")
+	m.Linef("3) Вступить в найденные группы")
+	m.Linef("0) Назад")
+	s, err := m.Prompt("Выберите пункт")
+	if err != nil {
+		return err
+	}
+	switch s {
+	case "1":
+		src, err := m.Prompt("Файл со ссылками (по одной в строке) или ссылки через пробел")
+		if err != nil {
+			return err
+		}
+		targets, err := readJoinTargets(src, m.Out)
+		if err != nil {
+			return err
+		}
+		return promptAndJoin(ctx, m, cfg, acc, targets)
+	case "2":
+		q, err := m.Prompt("Ключевые слова для поиска через запятую")
+		if err != nil {
+			return err
+		}
+		_, err = discoverChats(ctx, cfg, acc, splitQueries(q), m.Out)
+		return err
+	case "3":
+		return menuJoinFound(ctx, m, cfg, acc)
+	}
+	return nil
+}
+
+func menuJoinFound(ctx context.Context, m *ui.Menu, cfg config.Config, acc config.Account) error {
+	found, err := join.LoadFound(discoveredPath)
+	if err != nil {
+		return err
+	}
+	log, err := join.OpenLog(joinLogPath)
+	if err != nil {
+		return err
+	}
+	var candidates []join.Found
+	for _, f := range found {
+		t, _ := join.ParseTarget(f.Username)
+		if f.Kind == join.KindGroup && !f.Member && !log.Done(acc.Name, t) {
+			candidates = append(candidates, f)
+		}
+	}
+	if len(candidates) == 0 {
+		m.Linef("Новых групп нет. Сначала выполните поиск (пункт 2).")
+		return nil
+	}
+	for i, f := range candidates {
+		m.Linef("%d) %s — @%s, участников: %d (%s)", i+1, f.Title, f.Username, f.Members, strings.Join(f.Queries, ", "))
+	}
+	s, err := m.Prompt("Номера групп через запятую (пусто = все)")
+	if err != nil {
+		return err
+	}
+	var targets []join.Target
+	for i, f := range candidates {
+		if s != "" && !containsNumber(s, i+1) {
+			continue
+		}
+		t, err := join.ParseTarget(f.Link())
+		if err != nil {
+			continue
+		}
+		targets = append(targets, t)
+	}
+	return promptAndJoin(ctx, m, cfg, acc, targets)
+}
+
+func promptAndJoin(ctx context.Context, m *ui.Menu, cfg config.Config, acc config.Account, targets []join.Target) error {
+	if len(targets) == 0 {
+		return fmt.Errorf("нет ссылок для вступления")
+	}
+	minSec, maxSec := int(join.DefaultMinDelay/time.Second), int(join.DefaultMaxDelay/time.Second)
+	if err := promptInt(m, "Пауза между вступлениями от, сек", &minSec); err != nil {
+		return err
+	}
+	if err := promptInt(m, "Пауза между вступлениями до, сек", &maxSec); err != nil {
+		return err
+	}
+	m.Linef("Прервать — Ctrl+C. При повторном запуске чаты, в которые аккаунт уже вступил, пропускаются.")
+	return joinChats(ctx, cfg, acc, targets, time.Duration(minSec)*time.Second, time.Duration(maxSec)*time.Second, m.Out)
+}
+
+func promptAccount(m *ui.Menu, accounts []config.Account) (config.Account, error) {
+	switch len(accounts) {
+	case 0:
+		return config.Account{}, fmt.Errorf("аккаунтов нет")
+	case 1:
+		return accounts[0], nil
+	}
+	for i, a := range accounts {
+		m.Linef("%d) %s", i+1, a.Name)
+	}
+	s, err := m.Prompt("Номер аккаунта")
+	if err != nil {
+		return config.Account{}, err
+	}
+	idx, err := strconv.Atoi(s)
+	if err != nil || idx < 1 || idx > len(accounts) {
+		return config.Account{}, fmt.Errorf("неверный выбор")
+	}
+	return accounts[idx-1], nil
+}
+
+func findAccount(accounts []config.Account, name string) (config.Account, error) {
+	if name == "" && len(accounts) == 1 {
+		return accounts[0], nil
+	}
+	for _, a := range accounts {
+		if a.Name == name {
+			return a, nil
+		}
+	}
+	if name == "" {
+		return config.Account{}, fmt.Errorf("укажите аккаунт: --account=<название>")
+	}
+	return config.Account{}, fmt.Errorf("аккаунт %q не найден", name)
+}
+
+// readJoinTargets принимает путь к файлу или сами ссылки через пробел.
+func readJoinTargets(src string, out io.Writer) ([]join.Target, error) {
+	src = strings.TrimSpace(src)
+	var targets []join.Target
+	var bad []error
+	if _, err := os.Stat(src); err == nil {
+		if targets, bad, err = join.ReadTargets(src); err != nil {
+			return nil, err
+		}
+	} else {
+		targets, bad = join.ParseTargets(strings.Fields(src))
+	}
+	for _, err := range bad {
+		fmt.Fprintf(out, "Пропущено: %v\n", err)
+	}
+	return targets, nil
+}
+
+func joinChats(ctx context.Context, cfg config.Config, acc config.Account, targets []join.Target, minDelay, maxDelay time.Duration, out io.Writer) error {
+	log, err := join.OpenLog(joinLogPath)
+	if err != nil {
+		return err
+	}
+	var pending []join.Target
+	for _, t := range targets {
+		if !log.Done(acc.Name, t) {
+			pending = append(pending, t)
+		}
+	}
+	fmt.Fprintf(out, "Аккаунт %s: вступить в %d чатов (уже вступил в %d из списка)\n", acc.Name, len(pending), len(targets)-len(pending))
+	if len(pending) == 0 {
+		return nil
+	}
+	counts := make(map[string]int)
+	err = withAccountAPI(ctx, cfg, acc, func(ctx context.Context, api *tg.Client) error {
+		i := 0
+		return join.NewJoiner(api, acc.Name).WithDelay(minDelay, maxDelay).Run(ctx, pending, func(r join.Result) {
+			i++
+			counts[r.Status]++
+			if err := log.Append(r); err != nil {
+				fmt.Fprintf(out, "Журнал не записан: %v\n", err)
+			}
+			fmt.Fprintf(out, "[%d/%d] %s — %s\n", i, len(pending), r.Target, joinStatusLabel(r))
+		})
+	})
+	fmt.Fprintf(out, "Вступил: %d, уже был: %d, заявок: %d, ошибок: %d. Журнал: %s\n",
+		counts[join.StatusJoined], counts[join.StatusAlready], counts[join.StatusRequested], counts[join.StatusFailed], joinLogPath)
+	return err
+}
+
+func joinStatusLabel(r join.Result) string {
+	title := ""
+	if r.Title != "" {
+		title = " «" + r.Title + "»"
+	}
+	switch r.Status {
+	case join.StatusJoined:
+		return ui.Green("вступил") + title
+	case join.StatusAlready:
+		return "уже состоит" + title
+	case join.StatusRequested:
+		return "заявка отправлена" + title
+	}
+	return ui.Red("ошибка: " + r.Error)
+}
+
+// discoverChats ищет публичные чаты и добавляет их в data/discovered.json; даже при
+// FLOOD_WAIT найденное до него сохраняется.
+func discoverChats(ctx context.Context, cfg config.Config, acc config.Account, queries []string, out io.Writer) ([]join.Found, error) {
+	if len(queries) == 0 {
+		return nil, fmt.Errorf("пустой запрос")
+	}
+	var found []join.Found
+	err := withAccountAPI(ctx, cfg, acc, func(ctx context.Context, api *tg.Client) error {
+		var err error
+		found, err = join.Discover(ctx, api, queries)
+		return err
+	})
+	var flood *join.FloodError
+	if err != nil && !errors.As(err, &flood) {
+		return nil, err
+	}
+	fresh, mergeErr := join.MergeFound(discoveredPath, found)
+	if mergeErr != nil {
+		return nil, mergeErr
+	}
+	for _, f := range fresh {
+		fmt.Fprintf(out, "+ %s — @%s (%s, участников: %d)\n", f.Title, f.Username, kindLabel(f.Kind), f.Members)
+	}
+	fmt.Fprintf(out, "Найдено чатов: %d, новых: %d. Сохранено в %s\n", len(found), len(fresh), discoveredPath)
+	if flood != nil {
+		fmt.Fprintf(out, "%s\n", ui.Red(fmt.Sprintf("Поиск прерван: Telegram ограничил запросы на %s", flood.Wait.Round(time.Second))))
+	}
+	return fresh, nil
+}
+
+func kindLabel(kind string) string {
+	if kind == join.KindChannel {
+		return "канал"
+	}
+	return "группа"
+}
+
+// withAccountAPI подключается к Telegram сессией аккаунта без приёма апдейтов.
+// Аккаунт, который сейчас ведёт мониторинг, не подключается: второй клиент на том
+// же файле сессии перезаписывает её состояние.
+func withAccountAPI(ctx context.Context, cfg config.Config, acc config.Account, fn func(ctx context.Context, api *tg.Client) error) error {
+	if sessionActive(acc) {
+		return fmt.Errorf("аккаунт %s сейчас ведёт мониторинг: остановите его или выберите другой аккаунт", acc.Name)
+	}
+	opts, err := clientOptions(acc)
+	if err != nil {
+		return err
+	}
+	opts.NoUpdates = true
+	client := telegram.NewClient(cfg.AppID, cfg.AppHash, opts)
+	return client.Run(ctx, func(ctx context.Context) error {
+		status, err := client.Auth().Status(ctx)
+		if err != nil {
+			return err
+		}
+		if !status.Authorized {
+			return fmt.Errorf("аккаунт %s не авторизован: войдите через «Добавить аккаунт»", acc.Name)
+		}
+		return fn(ctx, client.API())
+	})
+}
+
+func splitQueries(s string) []string {
+	var out []string
+	for _, q := range strings.Split(s, ",") {
+		if q = strings.TrimSpace(q); q != "" {
+			out = append(out, q)
+		}
+	}
+	return out
+}
+
+func containsNumber(list string, n int) bool {
+	for _, f := range strings.FieldsFunc(list, func(r rune) bool { return r == ',' || r == ' ' }) {
+		if v, err := strconv.Atoi(f); err == nil && v == n {
+			return true
+		}
+	}
+	return false
+}
+
+// runJoinCommand — вступление и поиск из командной строки: --join=<файл>,
+// --discover=<слова через запятую>, --account=<название>.
+func runJoinCommand(ctx context.Context, joinFile, discover, account string) int {
+	st, err := store.Load(statePath)
+	if err != nil {
+		fmt.Fprintln(os.Stdout, err.Error())
+		return 2
+	}
+	cfg, err := configFromEnvAndState(st)
+	if err != nil {
+		fmt.Fprintln(os.Stdout, err.Error())
+		return 2
+	}
+	acc, err := findAccount(cfg.Accounts, account)
+	if err != nil {
+		fmt.Fprintln(os.Stdout, err.Error())
+		return 2
+	}
+	if discover != "" {
+		if _, err := discoverChats(ctx, cfg, acc, splitQueries(discover), os.Stdout); err != nil {
+			fmt.Fprintln(os.Stdout, ui.Red(err.Error()))
+			return 1
+		}
+	}
+	if joinFile != "" {
+		targets, err := readJoinTargets(joinFile, os.Stdout)
+		if err != nil {
+			fmt.Fprintln(os.Stdout, ui.Red(err.Error()))
+			return 2
+		}
+		if err := joinChats(ctx, cfg, acc, targets, join.DefaultMinDelay, join.DefaultMaxDelay, os.Stdout); err != nil {
+			fmt.Fprintln(os.Stdout, ui.Red(err.Error()))
+			return 1
+		}
+	}
+	return 0
+}
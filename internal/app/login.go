@@ -11,32 +11,29 @@ import (
 	authutil "getclient/internal/auth"
 	"getclient/internal/store"
 
-	"github.com/gotd/td/session"
 	"github.com/gotd/td/telegram"
 	"github.com/gotd/td/telegram/auth"
-	"go.uber.org/zap"
 )
 
-func loginAndSaveSession(ctx context.Context, accountName string, sessionPath string) error {
+func loginAndSaveSession(ctx context.Context, acc store.Account) error {
 	st, _ := store.Load(statePath)
 	appID := st.AppID
 	appHash := strings.TrimSpace(st.AppHash)
 	if appID == 0 || appHash == "" {
 		return fmt.Errorf("не заданы API_ID/API_HASH. Откройте меню → «Настройки приложения»")
 	}
-	if err := os.MkdirAll(filepath.Dir(sessionPath), 0o700); err != nil {
+	if err := os.MkdirAll(filepath.Dir(acc.SessionPath), 0o700); err != nil {
 		return err
 	}
 
-	client := telegram.NewClient(appID, appHash, telegram.Options{
-		Logger: zap.NewNop(),
-		SessionStorage: &session.FileStorage{
-			Path: sessionPath,
-		},
-		NoUpdates: true,
-	})
+	opts, err := clientOptions(toCfgAccounts([]store.Account{acc})[0])
+	if err != nil {
+		return err
+	}
+	opts.NoUpdates = true
+	client := telegram.NewClient(appID, appHash, opts)
 
-	pa := authutil.PromptAuth{In: bufio.NewReader(os.Stdin), Out: os.Stdout, Tag: accountName}
+	pa := authutil.PromptAuth{In: bufio.NewReader(os.Stdin), Out: os.Stdout, Tag: acc.Name}
 	flow := auth.NewFlow(pa, auth.SendCodeOptions{})
 
 	return client.Run(ctx, func(ctx context.Context) error {
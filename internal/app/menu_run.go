@@ -9,6 +9,7 @@ import (
 	"strings"
 	"time"
 
+	"getclient/internal/notifier"
 	"getclient/internal/store"
 	"getclient/internal/ui"
 )
@@ -16,10 +17,32 @@ import (
 const statePath = "data/config.json"
 
 func Run(ctx context.Context) int {
+	html := false
+	var joinFile, discover, account string
+	for _, a := range os.Args[1:] {
+		if a == "--html" {
+			html = true
+		}
+		if v, ok := strings.CutPrefix(a, "--join="); ok {
+			joinFile = v
+		}
+		if v, ok := strings.CutPrefix(a, "--discover="); ok {
+			discover = v
+		}
+		if v, ok := strings.CutPrefix(a, "--account="); ok {
+			account = v
+		}
+	}
+	if joinFile != "" || discover != "" {
+		return runJoinCommand(ctx, joinFile, discover, account)
+	}
 	for _, a := range os.Args[1:] {
 		if a == "--no-menu" {
 			return RunNonInteractive(ctx)
 		}
+		if path, ok := strings.CutPrefix(a, "--preview-template="); ok {
+			return runPreviewTemplate(path, html)
+		}
 	}
 	return RunMenu(ctx)
 }
@@ -61,12 +84,17 @@ func RunMenu(ctx context.Context) int {
 		if st.BotToken != "" && st.BotChatID != 0 {
 			botStatus = ui.Green("включен")
 		}
+		if st.Paused {
+			botStatus += " | " + ui.Red("мониторинг на паузе (/resume)")
+		}
 		
-		info := fmt.Sprintf("Аккаунтов: %s | Фраз: %s | Стоп-слов: %s | Бот: %s",
+		info := fmt.Sprintf("Аккаунтов: %s | Фраз: %s | Стоп-слов: %s | Бот: %s | Получателей: %s | В очереди: %s",
 			ui.Cyan(fmt.Sprintf("%d", len(st.Accounts))),
 			ui.Cyan(fmt.Sprintf("%d", len(kw))),
 			ui.Cyan(fmt.Sprintf("%d", len(sw))),
 			botStatus,
+			ui.Cyan(fmt.Sprintf("%d", len(st.Destinations))),
+			ui.Cyan(fmt.Sprintf("%d", notifier.PendingCount(stateOutboxes(st)...))),
 		)
 
 		act, err := m.Choose(ctx, info)
@@ -98,8 +126,28 @@ func RunMenu(ctx context.Context) int {
 			if err := menuAddAccount(ctx, m, &st); err != nil {
 				m.Linef("Ошибка: %v", err)
 			}
-		case ui.ActionRemoveAccount:
-			if err := menuRemoveAccount(m, &st); err != nil {
+		case ui.ActionAccounts:
+			if err := menuAccounts(m, &st); err != nil {
+				m.Linef("Ошибка: %v", err)
+			}
+		case ui.ActionDestinations:
+			if err := menuDestinations(m, &st); err != nil {
+				m.Linef("Ошибка: %v", err)
+			}
+		case ui.ActionTemplates:
+			if err := menuTemplates(m, &st); err != nil {
+				m.Linef("Ошибка: %v", err)
+			}
+		case ui.ActionRuleSets:
+			if err := menuRuleSets(m, &st); err != nil {
+				m.Linef("Ошибка: %v", err)
+			}
+		case ui.ActionClassifier:
+			if err := menuClassifier(m, &st); err != nil {
+				m.Linef("Ошибка: %v", err)
+			}
+		case ui.ActionJoin:
+			if err := menuJoin(ctx, m, &st); err != nil {
 				m.Linef("Ошибка: %v", err)
 			}
 		case ui.ActionResetBase:
@@ -109,6 +157,9 @@ func RunMenu(ctx context.Context) int {
 		case ui.ActionStart:
 			_ = store.Save(statePath, st)
 			runMonitoringSession(ctx, m, st)
+			if fresh, err := store.Load(statePath); err == nil {
+				st = fresh
+			}
 			continue
 		}
 		_ = store.Save(statePath, st)
@@ -194,6 +245,41 @@ func menuAppSettings(m *ui.Menu, st *store.State) error {
 	if strings.TrimSpace(hash) != "" {
 		st.AppHash = strings.TrimSpace(hash)
 	}
+	return menuHookSettings(m, st)
+}
+
+func menuHookSettings(m *ui.Menu, st *store.State) error {
+	current := st.Hook
+	if current == "" {
+		current = "нет"
+	}
+	m.Linef("Внешний фильтр получает алерт в JSON и может отклонить его, дополнить или перенаправить (сейчас: %s)", current)
+	hook, err := m.Prompt("Команда или http(s)-адрес фильтра (пусто = оставить как есть, - = отключить)")
+	if err != nil {
+		return err
+	}
+	switch hook = strings.TrimSpace(hook); hook {
+	case "":
+	case "-":
+		st.Hook = ""
+	default:
+		st.Hook = hook
+	}
+	if st.Hook == "" {
+		return nil
+	}
+	timeout, err := m.PromptInt64("Таймаут фильтра, мс (по умолчанию 5000, не больше 30000, пусто = оставить как есть)")
+	if err != nil {
+		return err
+	}
+	if timeout > 0 {
+		st.HookTimeoutMs = timeout
+	}
+	failOpen := !st.HookFailClosed
+	if err := promptToggle(m, "Отправлять алерт, если фильтр недоступен или ответил ошибкой?", &failOpen); err != nil {
+		return err
+	}
+	st.HookFailClosed = !failOpen
 	return nil
 }
 
@@ -220,6 +306,123 @@ func menuBotSettings(m *ui.Menu, st *store.State) error {
 	if pollMs >= 0 {
 		st.PollIntervalMs = pollMs
 	}
+	control, err := m.Prompt("Кнопки под алертами и команды боту: 1 = вкл, 0 = выкл (пусто = оставить как есть)")
+	if err != nil {
+		return err
+	}
+	switch strings.TrimSpace(control) {
+	case "1":
+		st.BotControl = true
+	case "0":
+		st.BotControl = false
+	}
+	if st.BotControl {
+		ids, err := m.Prompt("Дополнительные chat_id, которым разрешены команды (через запятую, пусто = оставить как есть, - = очистить)")
+		if err != nil {
+			return err
+		}
+		if err := parseControlChats(st, ids); err != nil {
+			return err
+		}
+	}
+	replies, err := m.Prompt("Добавлять в алерт сообщение, на которое ответили? 1 = да, 0 = нет (пусто = оставить как есть)")
+	if err != nil {
+		return err
+	}
+	switch strings.TrimSpace(replies) {
+	case "1":
+		st.ContextReplies = true
+	case "0":
+		st.ContextReplies = false
+	}
+	ctxMsgs, err := m.Prompt("Сколько предыдущих сообщений чата добавлять в алерт (0 = нет, пусто = оставить как есть)")
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(ctxMsgs) != "" {
+		var n int
+		if _, err := fmt.Sscanf(ctxMsgs, "%d", &n); err != nil || n < 0 || n > 20 {
+			return fmt.Errorf("нужно число от 0 до 20")
+		}
+		st.ContextMessages = n
+	}
+	topics, err := m.Confirm("Настроить темы форума для алертов?")
+	if err != nil {
+		return err
+	}
+	if topics {
+		return menuForumTopics(m, st)
+	}
+	return nil
+}
+
+func menuForumTopics(m *ui.Menu, st *store.State) error {
+	m.Title("Темы форума")
+	m.Linef("BOT_CHAT_ID должен быть форум-супергруппой. ID темы — число из ссылки на тему (t.me/c/<чат>/<тема>).")
+	auto, err := m.Prompt("Создавать темы автоматически по названию набора правил: 1 = да, 0 = нет (пусто = оставить как есть)")
+	if err != nil {
+		return err
+	}
+	switch strings.TrimSpace(auto) {
+	case "1":
+		st.AutoTopics = true
+	case "0":
+		st.AutoTopics = false
+	}
+
+	for _, name := range ruleSetNames(st) {
+		id, err := m.PromptInt64(fmt.Sprintf("Тема для набора «%s» (сейчас %d; пусто = оставить, -1 = убрать)", name, st.RuleSetTopics[name]))
+		if err != nil {
+			return err
+		}
+		switch {
+		case id < 0:
+			delete(st.RuleSetTopics, name)
+		case id > 0:
+			if st.RuleSetTopics == nil {
+				st.RuleSetTopics = make(map[string]int)
+			}
+			st.RuleSetTopics[name] = int(id)
+		}
+	}
+
+	for i := range st.Accounts {
+		acc := &st.Accounts[i]
+		id, err := m.PromptInt64(fmt.Sprintf("Тема для аккаунта «%s» (сейчас %d; пусто = оставить, -1 = убрать)", acc.Name, acc.TopicID))
+		if err != nil {
+			return err
+		}
+		switch {
+		case id < 0:
+			acc.TopicID = 0
+		case id > 0:
+			acc.TopicID = int(id)
+		}
+	}
+	if len(st.Accounts) > 0 {
+		return saveAccountsJSON(st.AccountsFile, st.Accounts)
+	}
+	return nil
+}
+
+func parseControlChats(st *store.State, s string) error {
+	s = strings.TrimSpace(s)
+	switch s {
+	case "":
+		return nil
+	case "-":
+		st.ControlChats = nil
+		return nil
+	}
+	var ids []int64
+	for _, part := range strings.Split(s, ",") {
+		var id int64
+		if _, err := fmt.Sscanf(strings.TrimSpace(part), "%d", &id); err != nil || id == 0 {
+			return fmt.Errorf("неверный chat_id %q", part)
+		}
+		ids = append(ids, id)
+	}
+	st.ControlChats = ids
 	return nil
 }
 
@@ -228,6 +431,10 @@ func appendLine(m *ui.Menu, filePath, label string) error {
 	if err != nil {
 		return err
 	}
+	return appendWord(filePath, v)
+}
+
+func appendWord(filePath, v string) error {
 	v = strings.TrimSpace(v)
 	if v == "" {
 		return nil
@@ -0,0 +1,250 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"getclient/internal/config"
+	"getclient/internal/notifier"
+	"getclient/internal/store"
+
+	"go.uber.org/zap"
+)
+
+const outboxDir = "data/outbox"
+
+const (
+	destBot     = "bot"
+	destWebhook = "webhook"
+	destFile    = "file"
+	destConsole = "console"
+)
+
+func newMainBot(cfg config.Config, logger *zap.Logger) *notifier.TelegramBot {
+	bot := notifier.NewTelegramBot(cfg.BotToken, cfg.BotChatID).
+		WithAPIURL(cfg.BotAPIURL).
+		WithTemplates(loadTemplates(cfg, destBot, true, logger)).
+		WithActions(cfg.BotControl).
+		WithFeedback(cfg.BotControl && cfg.Classifier).
+		WithTopics(mainBotTopics(cfg, logger))
+	logger.Info("Бот-уведомления",
+		zap.Bool("enabled", bot.Enabled()),
+		zap.Int64("chat_id", cfg.BotChatID),
+		zap.Bool("control", cfg.BotControl),
+	)
+	return bot
+}
+
+func mainBotTopics(cfg config.Config, logger *zap.Logger) notifier.Topics {
+	t := notifier.Topics{
+		RuleSets: make(map[string]int),
+		Accounts: make(map[string]int),
+		Auto:     cfg.AutoTopics,
+	}
+	for name, id := range cfg.RuleSetTopics {
+		t.RuleSets[name] = id
+	}
+	for _, acc := range cfg.Accounts {
+		if acc.TopicID != 0 {
+			t.Accounts[acc.Name] = acc.TopicID
+		}
+	}
+	t.OnCreate = func(ruleSet string, id int) {
+		logger.Info("Создана тема форума", zap.String("rule_set", ruleSet), zap.Int("topic_id", id))
+		if cfg.StatePath == "" {
+			return
+		}
+		if err := store.Update(cfg.StatePath, func(st *store.State) {
+			if st.RuleSetTopics == nil {
+				st.RuleSetTopics = make(map[string]int)
+			}
+			st.RuleSetTopics[ruleSet] = id
+		}); err != nil {
+			logger.Warn("Тема форума не сохранена", zap.Error(err))
+		}
+	}
+	return t
+}
+
+func buildNotifier(cfg config.Config, bot *notifier.TelegramBot, logger *zap.Logger) (notifier.Notifier, []*notifier.Outbox) {
+	console := notifier.NewConsole(os.Stdout).WithTemplates(loadTemplates(cfg, destConsole, false, logger))
+	targets := []notifier.Target{{Name: destConsole, Notifier: console, Priority: notifier.PriorityAll}}
+	var outboxes []*notifier.Outbox
+
+	add := func(name, priority string, n notifier.Notifier) {
+		ob, err := notifier.NewOutbox(outboxPath(name), name, n, logger)
+		if err != nil {
+			logger.Warn("Очередь недоступна, доставка напрямую", zap.String("destination", name), zap.Error(err))
+			targets = append(targets, notifier.Target{Name: name, Notifier: n, Priority: priority})
+			return
+		}
+		outboxes = append(outboxes, ob)
+		targets = append(targets, notifier.Target{Name: name, Notifier: ob, Priority: priority})
+	}
+
+	if bot.Enabled() {
+		add(destBot, "", bot)
+	}
+
+	for i, d := range cfg.Destinations {
+		name := destinationName(d, i)
+		html := strings.EqualFold(strings.TrimSpace(d.Type), destBot)
+		n, err := newDestination(cfg, d, loadTemplates(cfg, name, html, logger))
+		if err != nil {
+			logger.Warn("Получатель пропущен", zap.String("destination", name), zap.Error(err))
+			continue
+		}
+		add(name, d.Priority, n)
+		logger.Info("Получатель уведомлений", zap.String("destination", name), zap.String("type", d.Type), zap.String("priority", d.Priority))
+	}
+
+	dirs := make([]string, 0, len(outboxes))
+	for _, ob := range outboxes {
+		dirs = append(dirs, outboxPath(ob.Name()))
+	}
+	for _, dir := range notifier.Orphaned(outboxDir, dirs) {
+		logger.Warn("Очередь получателя, которого нет в настройках, не отправляется",
+			zap.String("dir", dir),
+			zap.Int("pending", notifier.PendingCount(dir)),
+		)
+	}
+
+	return notifier.NewMulti(logger, targets...), outboxes
+}
+
+// outboxPath — папка очереди получателя.
+func outboxPath(name string) string {
+	return filepath.Join(outboxDir, safeName.ReplaceAllString(name, "_"))
+}
+
+// stateOutboxes — папки очередей получателей из настроек: в шапке меню считаются
+// только они, очереди удалённых получателей никто не разбирает.
+func stateOutboxes(st store.State) []string {
+	var dirs []string
+	if st.BotToken != "" && st.BotChatID != 0 {
+		dirs = append(dirs, outboxPath(destBot))
+	}
+	for i, d := range st.Destinations {
+		dirs = append(dirs, outboxPath(destinationName(config.Destination{Name: d.Name, Type: d.Type}, i)))
+	}
+	return dirs
+}
+
+func runOutboxes(ctx context.Context, outboxes []*notifier.Outbox, logger *zap.Logger) func() {
+	ctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	for _, ob := range outboxes {
+		ob := ob
+		if d := ob.Depth(); d > 0 {
+			logger.Info("Неотправленные уведомления из прошлого запуска", zap.String("destination", ob.Name()), zap.Int("pending", d))
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ob.Run(ctx)
+		}()
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		t := time.NewTicker(time.Minute)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+				for _, ob := range outboxes {
+					if d := ob.Depth(); d > 0 {
+						logger.Info("Очередь уведомлений", zap.String("destination", ob.Name()), zap.Int("pending", d))
+					}
+				}
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		wg.Wait()
+	}
+}
+
+func newDestination(cfg config.Config, d config.Destination, templates notifier.Templates) (notifier.Notifier, error) {
+	switch strings.ToLower(strings.TrimSpace(d.Type)) {
+	case destBot:
+		token := d.BotToken
+		if strings.TrimSpace(token) == "" {
+			token = cfg.BotToken
+		}
+		bot := notifier.NewTelegramBot(token, d.ChatID).
+			WithAPIURL(cfg.BotAPIURL).
+			WithTemplates(templates).
+			WithTopics(notifier.Topics{Default: d.TopicID})
+		if !bot.Enabled() {
+			return nil, fmt.Errorf("не заданы токен или chat_id")
+		}
+		return bot, nil
+	case destWebhook:
+		if strings.TrimSpace(d.URL) == "" {
+			return nil, fmt.Errorf("не задан URL")
+		}
+		return notifier.NewWebhook(d.URL).WithTemplates(templates), nil
+	case destFile:
+		if strings.TrimSpace(d.Path) == "" {
+			return nil, fmt.Errorf("не задан путь к файлу")
+		}
+		return notifier.NewFile(d.Path).WithTemplates(templates), nil
+	default:
+		return nil, fmt.Errorf("неизвестный тип %q", d.Type)
+	}
+}
+
+func destinationName(d config.Destination, i int) string {
+	if name := strings.TrimSpace(d.Name); name != "" {
+		return name
+	}
+	return fmt.Sprintf("%s-%d", strings.ToLower(strings.TrimSpace(d.Type)), i+1)
+}
+
+func loadTemplates(cfg config.Config, dest string, html bool, logger *zap.Logger) notifier.Templates {
+	load := func(path string) notifier.Template {
+		tmpl, err := notifier.ParseTemplateFile(path, html)
+		if err != nil {
+			logger.Warn("Шаблон не загружен, используется стандартный",
+				zap.String("destination", dest),
+				zap.String("file", path),
+				zap.Error(err),
+			)
+			return nil
+		}
+		return tmpl
+	}
+
+	t := notifier.Templates{
+		RuleSets: make(map[string]notifier.Template),
+		OnError: func(ruleSet string, err error) {
+			logger.Warn("Шаблон не сработал, используется стандартный формат",
+				zap.String("destination", dest),
+				zap.String("rule_set", ruleSet),
+				zap.Error(err),
+			)
+		},
+	}
+	if path := strings.TrimSpace(cfg.Templates[dest]); path != "" {
+		t.Default = load(path)
+	}
+	for _, rs := range cfgRuleSets(cfg) {
+		if path := strings.TrimSpace(rs.Templates[dest]); path != "" {
+			if tmpl := load(path); tmpl != nil {
+				t.RuleSets[rs.Name] = tmpl
+			}
+		}
+	}
+	return t
+}
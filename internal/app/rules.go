@@ -0,0 +1,129 @@
+package app
+
+import (
+	"time"
+
+	"getclient/internal/config"
+	"getclient/internal/monitor"
+	"getclient/internal/notifier"
+
+	"go.uber.org/zap"
+)
+
+const defaultRuleSet = "default"
+
+const (
+	scriptsDir    = "data/scripts"
+	scriptsReload = 5 * time.Second
+)
+
+const (
+	autoReplyLogPath = "data/autoreply.log"
+	autoReplyDBPath  = "data/replied.json"
+	// Повторный автоответ тому же отправителю — не раньше чем через полгода.
+	autoReplyRepeat = 180 * 24 * time.Hour
+)
+
+func cfgRuleSets(cfg config.Config) []config.RuleSet {
+	out := []config.RuleSet{{
+		Name:          defaultRuleSet,
+		KeywordsFile:  cfg.KeywordsFile,
+		StopwordsFile: cfg.StopwordsFile,
+		UseRegex:      cfg.UseRegex,
+	}}
+	for _, rs := range cfg.RuleSets {
+		if rs.Name == defaultRuleSet {
+			if rs.KeywordsFile == "" {
+				rs.KeywordsFile = cfg.KeywordsFile
+				rs.UseRegex = cfg.UseRegex
+			}
+			if rs.StopwordsFile == "" {
+				rs.StopwordsFile = cfg.StopwordsFile
+			}
+			out[0] = rs
+			continue
+		}
+		out = append(out, rs)
+	}
+	return out
+}
+
+func loadRuleSets(cfg config.Config, logger *zap.Logger) []monitor.RuleSet {
+	var out []monitor.RuleSet
+	for _, rs := range cfgRuleSets(cfg) {
+		keywords, stopwords := mustReadWords(rs.KeywordsFile, rs.StopwordsFile)
+		logger.Info("Загружены слова",
+			zap.Int("keywords", len(keywords)),
+			zap.Int("stopwords", len(stopwords)),
+			zap.String("rule_set", rs.Name),
+		)
+		matcher := monitor.NewMatcher(keywords, stopwords, rs.UseRegex)
+		if rs.Normalize {
+			matcher.WithNormalize()
+		}
+		if rs.Threshold != 0 {
+			matcher.WithThreshold(rs.Threshold)
+		}
+		out = append(out, monitor.RuleSet{
+			Name:        rs.Name,
+			Matcher:     matcher,
+			Senders:     senderFilter(rs.Senders),
+			Chats:       chatFilter(rs.Chats),
+			Languages:   rs.Languages,
+			ForwardTo:   rs.ForwardTo,
+			ForwardCopy: rs.ForwardCopy,
+			AutoReply:   autoReplyRule(rs, logger),
+		})
+	}
+	return out
+}
+
+func autoReplyRule(rs config.RuleSet, logger *zap.Logger) monitor.ReplyRule {
+	if rs.AutoReply.Template == "" {
+		return monitor.ReplyRule{}
+	}
+	tmpl, err := notifier.ParseTemplateFile(rs.AutoReply.Template, false)
+	if err != nil {
+		logger.Warn("Шаблон автоответа не загружен, автоответ выключен", zap.String("rule_set", rs.Name), zap.Error(err))
+		return monitor.ReplyRule{}
+	}
+	mode := rs.AutoReply.Mode
+	if mode != monitor.ReplyInChat {
+		mode = monitor.ReplyDM
+	}
+	logger.Info("Автоответ", zap.String("rule_set", rs.Name), zap.String("template", rs.AutoReply.Template), zap.String("mode", mode))
+	return monitor.ReplyRule{Template: tmpl, Mode: mode}
+}
+
+func senderFilter(f config.SenderFilter) monitor.SenderFilter {
+	return monitor.SenderFilter{
+		Block:            monitor.NewSenderList(f.Block),
+		Watch:            monitor.NewSenderList(f.Watch),
+		IgnoreBots:       f.IgnoreBots,
+		IgnoreNoUsername: f.IgnoreNoUsername,
+		IgnoreChannels:   f.IgnoreChannels,
+	}
+}
+
+func chatFilter(f config.ChatFilter) monitor.ChatFilter {
+	return monitor.ChatFilter{
+		MinMembers: f.MinMembers,
+		PublicOnly: f.PublicOnly,
+		Forum:      f.Forum,
+		MinAge:     time.Duration(f.MinAgeDays) * 24 * time.Hour,
+		Languages:  f.Languages,
+	}
+}
+
+func ruleSetWordsFile(cfg config.Config, name string, stop bool) string {
+	for _, rs := range cfgRuleSets(cfg) {
+		if rs.Name != name {
+			continue
+		}
+		if stop {
+			return rs.StopwordsFile
+		}
+		return rs.KeywordsFile
+	}
+	return ""
+}
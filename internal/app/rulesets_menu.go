@@ -0,0 +1,453 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"getclient/internal/monitor"
+	"getclient/internal/store"
+	"getclient/internal/ui"
+)
+
+func menuRuleSets(m *ui.Menu, st *store.State) error {
+	m.Title("Наборы правил")
+	for i, name := range ruleSetNames(st) {
+		rs := findRuleSet(st, name)
+		kw := st.KeywordsFile
+		var senders store.SenderFilter
+		var chats store.ChatFilter
+		var langs []string
+		var reply store.AutoReply
+		if rs != nil {
+			if rs.KeywordsFile != "" {
+				kw = rs.KeywordsFile
+			}
+			senders, chats, langs, reply = rs.Senders, rs.Chats, rs.Languages, rs.AutoReply
+		}
+		line := fmt.Sprintf("%d) %s — %s; %s", i+1, name, kw, senderFilterSummary(senders))
+		if len(langs) > 0 {
+			line += "; языки: " + strings.Join(langs, ", ")
+		}
+		if s := chatFilterSummary(chats); s != "" {
+			line += "; " + s
+		}
+		if reply.Template != "" {
+			line += "; автоответ: " + replyModeLabel(reply.Mode)
+		}
+		m.Linef("%s", line)
+	}
+	m.Linef("")
+	m.Linef("1) Добавить набор правил")
+	m.Linef("2) Фильтры отправителей")
+	m.Linef("3) Удалить набор правил")
+	m.Linef("4) Параметры поиска")
+	m.Linef("5) Фильтры чатов")
+	m.Linef("6) Автоответ")
+	m.Linef("0) Назад")
+	s, err := m.Prompt("Выберите пункт")
+	if err != nil {
+		return err
+	}
+	switch s {
+	case "1":
+		return menuAddRuleSet(m, st)
+	case "2":
+		return menuSenderFilter(m, st)
+	case "3":
+		return menuRemoveRuleSet(m, st)
+	case "4":
+		return menuRuleSetSearch(m, st)
+	case "5":
+		return menuChatFilter(m, st)
+	case "6":
+		return menuAutoReply(m, st)
+	}
+	return nil
+}
+
+func menuAddRuleSet(m *ui.Menu, st *store.State) error {
+	name, err := m.Prompt("Название набора")
+	if err != nil {
+		return err
+	}
+	name = safeName.ReplaceAllString(strings.TrimSpace(name), "_")
+	if name == "" || name == defaultRuleSet {
+		return fmt.Errorf("недопустимое название")
+	}
+	if findRuleSet(st, name) != nil {
+		return fmt.Errorf("набор %q уже существует", name)
+	}
+	rs := store.RuleSet{Name: name}
+	kw, err := m.Prompt(fmt.Sprintf("Файл ключевых фраз (пусто = data/keywords_%s.txt)", name))
+	if err != nil {
+		return err
+	}
+	if rs.KeywordsFile = strings.TrimSpace(kw); rs.KeywordsFile == "" {
+		rs.KeywordsFile = fmt.Sprintf("data/keywords_%s.txt", name)
+	}
+	sw, err := m.Prompt("Файл стоп-слов (пусто = без стоп-слов)")
+	if err != nil {
+		return err
+	}
+	rs.StopwordsFile = strings.TrimSpace(sw)
+	if err := promptToggle(m, "Фразы — регулярные выражения?", &rs.UseRegex); err != nil {
+		return err
+	}
+	if err := promptToggle(m, "Нормализовать текст (двойники букв, «и щ у», эмодзи)?", &rs.Normalize); err != nil {
+		return err
+	}
+	for _, path := range []string{rs.KeywordsFile, rs.StopwordsFile} {
+		if err := touchFile(path); err != nil {
+			return err
+		}
+	}
+	st.RuleSets = append(st.RuleSets, rs)
+	return nil
+}
+
+func menuRemoveRuleSet(m *ui.Menu, st *store.State) error {
+	name, err := m.Prompt("Название набора для удаления")
+	if err != nil {
+		return err
+	}
+	name = strings.TrimSpace(name)
+	for i := range st.RuleSets {
+		if st.RuleSets[i].Name != name {
+			continue
+		}
+		ok, err := m.Confirm(fmt.Sprintf("Удалить набор %q? Файлы слов останутся на диске", name))
+		if err != nil || !ok {
+			return err
+		}
+		st.RuleSets = append(st.RuleSets[:i], st.RuleSets[i+1:]...)
+		delete(st.RuleSetTopics, name)
+		return nil
+	}
+	return fmt.Errorf("набор %q не найден", name)
+}
+
+func menuRuleSetSearch(m *ui.Menu, st *store.State) error {
+	rs, err := promptRuleSet(m, st)
+	if err != nil {
+		return err
+	}
+	if rs.Name != defaultRuleSet || rs.KeywordsFile != "" {
+		if err := promptToggle(m, "Фразы — регулярные выражения?", &rs.UseRegex); err != nil {
+			return err
+		}
+	}
+	if err := promptToggle(m, "Нормализовать текст (NFKC, латинские двойники, ё → е, невидимые символы, эмодзи, «и щ у»)?", &rs.Normalize); err != nil {
+		return err
+	}
+	th, err := m.Prompt(fmt.Sprintf("Порог оценки — алерт, если сумма весов сработавших фраз не меньше порога (сейчас %g; 0 = без оценки, пусто = оставить)", rs.Threshold))
+	if err != nil {
+		return err
+	}
+	if th = strings.TrimSpace(th); th != "" {
+		v, err := strconv.ParseFloat(strings.Replace(th, ",", ".", 1), 64)
+		if err != nil {
+			return fmt.Errorf("неверное число")
+		}
+		rs.Threshold = v
+	}
+	return promptLanguages(m, "Языки сообщений, на которые срабатывает набор", &rs.Languages)
+}
+
+func menuSenderFilter(m *ui.Menu, st *store.State) error {
+	rs, err := promptRuleSet(m, st)
+	if err != nil {
+		return err
+	}
+	f := &rs.Senders
+
+	m.Linef("Отправители указываются как ID или @username через запятую.")
+	if err := promptSenderList(m, "Блок-лист — сообщения этих отправителей не проверяются", &f.Block); err != nil {
+		return err
+	}
+	if err := promptSenderList(m, "Наблюдение — любое сообщение этих отправителей даёт алерт", &f.Watch); err != nil {
+		return err
+	}
+	if err := promptToggle(m, "Игнорировать ботов?", &f.IgnoreBots); err != nil {
+		return err
+	}
+	if err := promptToggle(m, "Игнорировать отправителей без username?", &f.IgnoreNoUsername); err != nil {
+		return err
+	}
+	return promptToggle(m, "Игнорировать сообщения от имени каналов?", &f.IgnoreChannels)
+}
+
+func menuChatFilter(m *ui.Menu, st *store.State) error {
+	rs, err := promptRuleSet(m, st)
+	if err != nil {
+		return err
+	}
+	f := &rs.Chats
+
+	m.Linef("Неизвестные значения (Telegram не отдал число участников, язык ещё не определён) условие не нарушают.")
+	if err := promptInt(m, "Минимум участников (0 = без ограничения)", &f.MinMembers); err != nil {
+		return err
+	}
+	if err := promptToggle(m, "Только публичные чаты (с @username)?", &f.PublicOnly); err != nil {
+		return err
+	}
+	forum, err := m.Prompt(fmt.Sprintf("Форумы: 1 = только форумы, 2 = без форумов, 0 = все (сейчас: %s; пусто = оставить)", forumLabel(f.Forum)))
+	if err != nil {
+		return err
+	}
+	switch strings.TrimSpace(forum) {
+	case "0":
+		f.Forum = ""
+	case "1":
+		f.Forum = monitor.ForumOnly
+	case "2":
+		f.Forum = monitor.ForumExclude
+	}
+	if err := promptInt(m, "Минимальный возраст чата, дней (0 = без ограничения)", &f.MinAgeDays); err != nil {
+		return err
+	}
+	return promptLanguages(m, "Языки чата", &f.Languages)
+}
+
+const sampleAutoReply = `Здравствуйте{{with .SenderName}}, {{.}}{{end}}! Вижу ваше сообщение в «{{.ChatTitle}}» — могу помочь.
+`
+
+func menuAutoReply(m *ui.Menu, st *store.State) error {
+	rs, err := promptRuleSet(m, st)
+	if err != nil {
+		return err
+	}
+	r := &rs.AutoReply
+
+	current := r.Template
+	if current == "" {
+		current = "выключен"
+	}
+	m.Linef("Автоответ отправляется автору совпавшего сообщения от аккаунта, который его нашёл (сейчас: %s).", current)
+	m.Linef("Шаблон — text/template с теми же полями, что у шаблонов алертов.")
+	path, err := m.Prompt(fmt.Sprintf("Файл шаблона (пусто = оставить, - = выключить, new = data/templates/reply_%s.txt)", rs.Name))
+	if err != nil {
+		return err
+	}
+	switch path = strings.TrimSpace(path); path {
+	case "":
+	case "-":
+		r.Template, r.Mode = "", ""
+	case "new":
+		path = fmt.Sprintf("data/templates/reply_%s.txt", rs.Name)
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+				return err
+			}
+			if err := os.WriteFile(path, []byte(sampleAutoReply), 0o600); err != nil {
+				return err
+			}
+		}
+		r.Template = path
+	default:
+		if _, err := os.Stat(path); err != nil {
+			return fmt.Errorf("файл шаблона: %w", err)
+		}
+		r.Template = path
+	}
+	if r.Template == "" {
+		return nil
+	}
+	mode, err := m.Prompt(fmt.Sprintf("Куда отвечать: 1 = в личные, 2 = ответом в чате (сейчас: %s; пусто = оставить)", replyModeLabel(r.Mode)))
+	if err != nil {
+		return err
+	}
+	switch strings.TrimSpace(mode) {
+	case "1":
+		r.Mode = monitor.ReplyDM
+	case "2":
+		r.Mode = monitor.ReplyInChat
+	}
+
+	m.Linef("Ограничения общие для всех наборов; каждому отправителю автоответ уходит один раз.")
+	if err := promptInt(m, "Автоответов в сутки на аккаунт (0 = 20)", &st.AutoReplyDailyLimit); err != nil {
+		return err
+	}
+	if err := promptInt(m, "Задержка перед ответом от, сек (0 и 0 = 30–120)", &st.AutoReplyMinDelaySec); err != nil {
+		return err
+	}
+	if err := promptInt(m, "Задержка перед ответом до, сек", &st.AutoReplyMaxDelaySec); err != nil {
+		return err
+	}
+	if st.AutoReplyMaxDelaySec < st.AutoReplyMinDelaySec {
+		st.AutoReplyMaxDelaySec = st.AutoReplyMinDelaySec
+	}
+	return promptToggle(m, "Пробный режим (ничего не отправлять, только писать в data/autoreply.log)?", &st.AutoReplyDryRun)
+}
+
+func replyModeLabel(mode string) string {
+	if mode == monitor.ReplyInChat {
+		return "ответом в чате"
+	}
+	return "в личные"
+}
+
+// promptLanguages запрашивает коды языков: ru, uk, kk, en.
+func promptLanguages(m *ui.Menu, label string, langs *[]string) error {
+	if err := promptSenderList(m, label+" (ru, uk, kk, en)", langs); err != nil {
+		return err
+	}
+	for i, l := range *langs {
+		(*langs)[i] = strings.ToLower(l)
+	}
+	return nil
+}
+
+func promptInt(m *ui.Menu, label string, v *int) error {
+	s, err := m.Prompt(fmt.Sprintf("%s сейчас: %d (пусто = оставить)", label, *v))
+	if err != nil {
+		return err
+	}
+	if s = strings.TrimSpace(s); s == "" {
+		return nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 {
+		return fmt.Errorf("неверное число")
+	}
+	*v = n
+	return nil
+}
+
+func forumLabel(forum string) string {
+	switch forum {
+	case monitor.ForumOnly:
+		return "только форумы"
+	case monitor.ForumExclude:
+		return "без форумов"
+	}
+	return "все"
+}
+
+func chatFilterSummary(f store.ChatFilter) string {
+	var parts []string
+	if f.MinMembers > 0 {
+		parts = append(parts, fmt.Sprintf("от %d участников", f.MinMembers))
+	}
+	if f.PublicOnly {
+		parts = append(parts, "только публичные")
+	}
+	if f.Forum != "" {
+		parts = append(parts, forumLabel(f.Forum))
+	}
+	if f.MinAgeDays > 0 {
+		parts = append(parts, fmt.Sprintf("старше %d дн.", f.MinAgeDays))
+	}
+	if len(f.Languages) > 0 {
+		parts = append(parts, "языки чата: "+strings.Join(f.Languages, ", "))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func promptSenderList(m *ui.Menu, label string, list *[]string) error {
+	current := "пусто"
+	if len(*list) > 0 {
+		current = strings.Join(*list, ", ")
+	}
+	s, err := m.Prompt(fmt.Sprintf("%s (сейчас: %s; пусто = оставить, - = очистить)", label, current))
+	if err != nil {
+		return err
+	}
+	switch s = strings.TrimSpace(s); s {
+	case "":
+	case "-":
+		*list = nil
+	default:
+		*list = nil
+		for _, v := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' }) {
+			*list = append(*list, v)
+		}
+	}
+	return nil
+}
+
+func promptToggle(m *ui.Menu, label string, v *bool) error {
+	current := "нет"
+	if *v {
+		current = "да"
+	}
+	s, err := m.Prompt(fmt.Sprintf("%s сейчас: %s. 1 = да, 0 = нет (пусто = оставить как есть)", label, current))
+	if err != nil {
+		return err
+	}
+	switch strings.TrimSpace(s) {
+	case "1":
+		*v = true
+	case "0":
+		*v = false
+	}
+	return nil
+}
+
+func senderFilterSummary(f store.SenderFilter) string {
+	parts := []string{fmt.Sprintf("блок: %d", len(f.Block)), fmt.Sprintf("наблюдение: %d", len(f.Watch))}
+	if f.IgnoreBots {
+		parts = append(parts, "без ботов")
+	}
+	if f.IgnoreNoUsername {
+		parts = append(parts, "только с username")
+	}
+	if f.IgnoreChannels {
+		parts = append(parts, "без каналов")
+	}
+	return strings.Join(parts, ", ")
+}
+
+func promptRuleSet(m *ui.Menu, st *store.State) (*store.RuleSet, error) {
+	name, err := m.Prompt(fmt.Sprintf("Набор правил (пусто = %s)", defaultRuleSet))
+	if err != nil {
+		return nil, err
+	}
+	if name = strings.TrimSpace(name); name == "" {
+		name = defaultRuleSet
+	}
+	if rs := findRuleSet(st, name); rs != nil {
+		return rs, nil
+	}
+	if name != defaultRuleSet {
+		return nil, fmt.Errorf("набор %q не найден", name)
+	}
+	// Основной набор без своих файлов берёт слова из общих настроек.
+	st.RuleSets = append(st.RuleSets, store.RuleSet{Name: defaultRuleSet})
+	return &st.RuleSets[len(st.RuleSets)-1], nil
+}
+
+func ruleSetNames(st *store.State) []string {
+	names := []string{defaultRuleSet}
+	for _, rs := range st.RuleSets {
+		if rs.Name != defaultRuleSet {
+			names = append(names, rs.Name)
+		}
+	}
+	return names
+}
+
+func findRuleSet(st *store.State, name string) *store.RuleSet {
+	for i := range st.RuleSets {
+		if st.RuleSets[i].Name == name {
+			return &st.RuleSets[i]
+		}
+	}
+	return nil
+}
+
+func touchFile(path string) error {
+	if path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
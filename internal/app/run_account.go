@@ -6,38 +6,65 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sync"
 
 	authutil "getclient/internal/auth"
+	"getclient/internal/classifier"
 	"getclient/internal/config"
 	"getclient/internal/monitor"
 	"getclient/internal/notifier"
 	"getclient/internal/store"
 	"getclient/internal/telegramutil"
 
-	"github.com/gotd/td/session"
 	"github.com/gotd/td/telegram"
 	"github.com/gotd/td/telegram/auth"
 	"github.com/gotd/td/telegram/updates"
 	"github.com/gotd/td/tg"
 	"go.uber.org/zap"
-	"sync"
 )
 
-func runAccount(ctx context.Context, cfg config.Config, acc config.Account, n notifier.Notifier, limiter store.SenderLimiter, globalSeen *sync.Map, logger *zap.Logger) error {
+type runner struct {
+	cfg        config.Config
+	rules      *monitor.Rules
+	notify     notifier.Notifier
+	limiter    store.SenderLimiter
+	globalSeen *sync.Map
+	accounts   *accountRegistry
+	classifier *classifier.Classifier
+	hook       *monitor.Hook
+	replyLog   *monitor.ReplyLog
+	replied    store.SenderLimiter
+	planner    *monitor.Planner
+	logger     *zap.Logger
+}
+
+func (r *runner) runAccount(ctx context.Context, acc config.Account) error {
+	cfg, logger := r.cfg, r.logger
+	state := r.accounts.add(acc.Name)
+	defer state.setStatus(statusStopped)
+
 	if acc.SessionPath != "" {
 		if err := os.MkdirAll(filepath.Dir(acc.SessionPath), 0o700); err != nil {
 			return fmt.Errorf("failed to create session dir (%s): %w", acc.Name, err)
 		}
 	}
-
-	keywords, stopwords := mustReadWords(cfg.KeywordsFile, cfg.StopwordsFile)
-	logger.Info("Загружены слова", 
-		zap.Int("keywords", len(keywords)), 
-		zap.Int("stopwords", len(stopwords)),
-		zap.String("account", acc.Name),
-	)
-	matcher := monitor.NewMatcher(keywords, stopwords, cfg.UseRegex)
-	mon := monitor.New(matcher, logger, n, acc.Name, limiter, globalSeen)
+	defer markSessionActive(ctx, acc)()
+
+	mon := monitor.New(r.rules, logger, r.notify, acc.Name, r.limiter, r.globalSeen)
+	state.setMonitor(mon)
+	cache := telegramutil.NewEntityCache()
+	mon.SetEntityCache(cache)
+	if r.classifier != nil {
+		mon.SetClassifier(r.classifier, classifierThreshold(cfg.ClassifierThreshold))
+	}
+	if r.hook != nil {
+		mon.SetHook(r.hook)
+	}
+	if len(acc.RuleSets) > 0 {
+		mon.SetRuleSets(acc.RuleSets)
+	} else if r.planner != nil {
+		mon.SetPlanner(r.planner)
+	}
 
 	dispatcher := tg.NewUpdateDispatcher()
 
@@ -51,11 +78,11 @@ func runAccount(ctx context.Context, cfg config.Config, acc config.Account, n no
 			switch v := u.(type) {
 			case *tg.UpdateShortMessage:
 				peer := &tg.PeerUser{UserID: v.UserID}
-				mon.ProcessShort(ctx, tg.Entities{}, peer, peer, v.ID, v.Message)
+				mon.ProcessShort(ctx, tg.Entities{}, peer, peer, v.ID, v.Message, v.Entities)
 			case *tg.UpdateShortChatMessage:
 				peer := &tg.PeerChat{ChatID: v.ChatID}
 				from := &tg.PeerUser{UserID: v.FromID}
-				mon.ProcessShort(ctx, tg.Entities{}, peer, from, v.ID, v.Message)
+				mon.ProcessShort(ctx, tg.Entities{}, peer, from, v.ID, v.Message, v.Entities)
 			case *tg.Updates:
 				entities := telegramutil.BuildEntities(v.Users, v.Chats)
 				for _, sub := range v.Updates {
@@ -76,13 +103,21 @@ func runAccount(ctx context.Context, cfg config.Config, acc config.Account, n no
 		return updatesMgr.Handle(ctx, u)
 	})
 
-	client := telegram.NewClient(cfg.AppID, cfg.AppHash, telegram.Options{
-		Logger: zap.NewNop(),
-		SessionStorage: &session.FileStorage{
-			Path: acc.SessionPath,
-		},
-		UpdateHandler: rawHandler,
-	})
+	opts, err := clientOptions(acc)
+	if err != nil {
+		return err
+	}
+	opts.UpdateHandler = rawHandler
+	client := telegram.NewClient(cfg.AppID, cfg.AppHash, opts)
+	mon.SetContextFetcher(monitor.NewContextFetcher(client.API(), cache, cfg.ContextReplies, cfg.ContextMessages))
+	mon.SetForwarder(monitor.NewForwarder(client.API(), cache))
+	mon.SetChatInfo(monitor.NewChatInfoFetcher(client.API(), cache))
+	mon.SetAutoReplier(monitor.NewAutoReplier(client.API(), cache, acc.Name, monitor.ReplyLimits{
+		Daily:    cfg.AutoReplyDailyLimit,
+		MinDelay: cfg.AutoReplyMinDelay,
+		MaxDelay: cfg.AutoReplyMaxDelay,
+		DryRun:   cfg.AutoReplyDryRun,
+	}, r.replied, r.replyLog, logger))
 
 	dispatcher.OnNewMessage(func(ctx context.Context, e tg.Entities, u *tg.UpdateNewMessage) error {
 		mon.ProcessMessage(ctx, e, u.Message)
@@ -105,6 +140,9 @@ func runAccount(ctx context.Context, cfg config.Config, acc config.Account, n no
 	flow := auth.NewFlow(pa, auth.SendCodeOptions{})
 
 	logger.Info("Подключение к Telegram...", zap.String("account", acc.Name))
+	state.setStatus(statusConnecting)
+	// Перед выходом дожидаемся алертов, которые уже уходят получателям.
+	defer mon.Wait()
 	return client.Run(ctx, func(ctx context.Context) error {
 		for {
 			if err := client.Auth().IfNecessary(ctx, flow); err != nil {
@@ -122,19 +160,27 @@ func runAccount(ctx context.Context, cfg config.Config, acc config.Account, n no
 		}
 
 		logger.Info("Успешно подключено", zap.String("account", acc.Name))
+		state.setStatus(statusOnline)
 		api := client.API()
 		self, err := client.Self(ctx)
 		if err != nil {
 			return err
 		}
 
+		if r.planner != nil && len(acc.RuleSets) == 0 {
+			go r.planner.Run(ctx, api, acc.Name, logger)
+		}
+
 		if cfg.PollInterval > 0 {
 			go monitor.NewDialogPoller(api, mon, cfg.PollInterval, cfg.PollLimit).Run(ctx)
 		}
 
 		logger.Info("Мониторинг запущен. Нажмите Ctrl+C для остановки.", zap.String("account", acc.Name))
-		return updatesMgr.Run(ctx, api, self.ID, updates.AuthOptions{})
+		err = updatesMgr.Run(ctx, api, self.ID, updates.AuthOptions{})
+		// Апдейты больше не приходят: чаты аккаунта сразу передаются другим.
+		if r.planner != nil {
+			r.planner.Down(acc.Name)
+		}
+		return err
 	})
 }
-
-
@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"getclient/internal/config"
 	"getclient/internal/store"
@@ -37,16 +38,39 @@ func configFromEnvAndState(st store.State) (config.Config, error) {
 	}
 
 	return config.Config{
-		AppID:        appID,
-		AppHash:      appHash,
-		Accounts:     toCfgAccounts(accounts),
-		KeywordsFile:  st.KeywordsFile,
-		StopwordsFile: st.StopwordsFile,
-		UseRegex:     st.UseRegex,
-		PollInterval: pollDuration(st),
-		PollLimit:    st.PollLimit,
-		BotToken:     st.BotToken,
-		BotChatID:    st.BotChatID,
+		AppID:               appID,
+		AppHash:             appHash,
+		Accounts:            toCfgAccounts(accounts),
+		KeywordsFile:        st.KeywordsFile,
+		StopwordsFile:       st.StopwordsFile,
+		UseRegex:            st.UseRegex,
+		PollInterval:        pollDuration(st),
+		PollLimit:           st.PollLimit,
+		StatePath:           statePath,
+		MutedSenders:        st.MutedSenders,
+		MutedChats:          st.MutedChats,
+		BotControl:          st.BotControl,
+		ControlChatIDs:      st.ControlChats,
+		Paused:              st.Paused,
+		ContextReplies:      st.ContextReplies,
+		ContextMessages:     st.ContextMessages,
+		Classifier:          st.Classifier,
+		ClassifierThreshold: st.ClassifierThreshold,
+		Hook:                st.Hook,
+		HookTimeout:         time.Duration(st.HookTimeoutMs) * time.Millisecond,
+		HookFailClosed:      st.HookFailClosed,
+		AutoReplyDailyLimit: st.AutoReplyDailyLimit,
+		AutoReplyMinDelay:   time.Duration(st.AutoReplyMinDelaySec) * time.Second,
+		AutoReplyMaxDelay:   time.Duration(st.AutoReplyMaxDelaySec) * time.Second,
+		AutoReplyDryRun:     st.AutoReplyDryRun,
+		BotToken:            st.BotToken,
+		BotChatID:           st.BotChatID,
+		BotAPIURL:           st.BotAPIURL,
+		RuleSetTopics:       st.RuleSetTopics,
+		AutoTopics:          st.AutoTopics,
+		Destinations:        toCfgDestinations(st.Destinations),
+		Templates:           st.Templates,
+		RuleSets:            toCfgRuleSets(st.RuleSets),
 	}, nil
 }
 
@@ -83,9 +107,58 @@ func toCfgAccounts(a []store.Account) []config.Account {
 		if name == "" {
 			name = fmt.Sprintf("account-%d", i+1)
 		}
-		out = append(out, config.Account{Name: name, SessionPath: strings.TrimSpace(x.SessionPath)})
+		out = append(out, config.Account{
+			Name:        name,
+			SessionPath: strings.TrimSpace(x.SessionPath),
+			TopicID:     x.TopicID,
+			Proxy:       strings.TrimSpace(x.Proxy),
+			Device:      config.Device(x.Device),
+			RuleSets:    x.RuleSets,
+			Disabled:    x.Disabled,
+		})
 	}
 	return out
 }
 
+func toCfgDestinations(d []store.Destination) []config.Destination {
+	out := make([]config.Destination, 0, len(d))
+	for _, x := range d {
+		out = append(out, config.Destination{
+			Name:     strings.TrimSpace(x.Name),
+			Type:     strings.TrimSpace(x.Type),
+			BotToken: strings.TrimSpace(x.BotToken),
+			ChatID:   x.ChatID,
+			URL:      strings.TrimSpace(x.URL),
+			Path:     strings.TrimSpace(x.Path),
+			TopicID:  x.TopicID,
+			Priority: strings.TrimSpace(x.Priority),
+		})
+	}
+	return out
+}
 
+func toCfgRuleSets(r []store.RuleSet) []config.RuleSet {
+	out := make([]config.RuleSet, 0, len(r))
+	for i, x := range r {
+		name := strings.TrimSpace(x.Name)
+		if name == "" {
+			name = fmt.Sprintf("rules-%d", i+1)
+		}
+		out = append(out, config.RuleSet{
+			Name:          name,
+			KeywordsFile:  strings.TrimSpace(x.KeywordsFile),
+			StopwordsFile: strings.TrimSpace(x.StopwordsFile),
+			UseRegex:      x.UseRegex,
+			Normalize:     x.Normalize,
+			Threshold:     x.Threshold,
+			Templates:     x.Templates,
+			ForwardTo:     strings.TrimSpace(x.ForwardTo),
+			ForwardCopy:   x.ForwardCopy,
+			Senders:       config.SenderFilter(x.Senders),
+			Chats:         config.ChatFilter(x.Chats),
+			Languages:     x.Languages,
+			AutoReply:     config.AutoReply{Template: strings.TrimSpace(x.AutoReply.Template), Mode: x.AutoReply.Mode},
+		})
+	}
+	return out
+}
@@ -0,0 +1,162 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"getclient/internal/config"
+	"getclient/internal/notifier"
+	"getclient/internal/store"
+	"getclient/internal/ui"
+)
+
+func menuTemplates(m *ui.Menu, st *store.State) error {
+	m.Title("Шаблоны уведомлений")
+	m.Linef("Получатели: %s, %s и названия из «Получатели уведомлений».", destConsole, destBot)
+	m.Linef("Шаблоны для ботов — html/template (HTML Telegram), для консоли, вебхуков и файлов — text/template.")
+	m.Linef("Вебхук и файл получают текст по шаблону в поле formatted.")
+	printTemplates(m, "", st.Templates)
+	for _, rs := range st.RuleSets {
+		printTemplates(m, rs.Name, rs.Templates)
+	}
+	m.Linef("")
+	m.Linef("1) Предпросмотр шаблона")
+	m.Linef("2) Назначить шаблон")
+	m.Linef("0) Назад")
+	s, err := m.Prompt("Выберите пункт")
+	if err != nil {
+		return err
+	}
+	switch s {
+	case "1":
+		return menuPreviewTemplate(m)
+	case "2":
+		return menuAssignTemplate(m, st)
+	}
+	return nil
+}
+
+func printTemplates(m *ui.Menu, ruleSet string, t map[string]string) {
+	keys := make([]string, 0, len(t))
+	for k := range t {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if ruleSet == "" {
+			m.Linef("  %s: %s", k, t[k])
+		} else {
+			m.Linef("  %s [%s]: %s", k, ruleSet, t[k])
+		}
+	}
+}
+
+func menuPreviewTemplate(m *ui.Menu) error {
+	path, err := m.Prompt("Путь к файлу шаблона")
+	if err != nil {
+		return err
+	}
+	html, err := m.Confirm("Шаблон для бота (HTML)?")
+	if err != nil {
+		return err
+	}
+	out, err := previewTemplate(path, html)
+	if err != nil {
+		return err
+	}
+	m.Linef("--------------------------------------------------")
+	m.Linef("%s", out)
+	m.Linef("--------------------------------------------------")
+	ui.WaitEnter()
+	return nil
+}
+
+func previewTemplate(path string, html bool) (string, error) {
+	tmpl, err := notifier.ParseTemplateFile(strings.TrimSpace(path), html)
+	if err != nil {
+		return "", err
+	}
+	return notifier.Render(tmpl, notifier.SampleNotification())
+}
+
+func menuAssignTemplate(m *ui.Menu, st *store.State) error {
+	dest, err := m.Prompt(fmt.Sprintf("Получатель (%s, %s или название)", destConsole, destBot))
+	if err != nil {
+		return err
+	}
+	dest = strings.TrimSpace(dest)
+	if dest == "" {
+		return fmt.Errorf("пустой получатель")
+	}
+	html, err := templateHTML(st, dest)
+	if err != nil {
+		return err
+	}
+	ruleSet, err := m.Prompt("Набор правил (пусто = для всех)")
+	if err != nil {
+		return err
+	}
+	ruleSet = strings.TrimSpace(ruleSet)
+	path, err := m.Prompt("Путь к файлу шаблона (пусто = стандартный формат)")
+	if err != nil {
+		return err
+	}
+	path = strings.TrimSpace(path)
+	if path != "" {
+		if _, err := previewTemplate(path, html); err != nil {
+			return fmt.Errorf("шаблон не прошёл проверку: %w", err)
+		}
+	}
+
+	target := &st.Templates
+	if ruleSet != "" {
+		idx := -1
+		for i := range st.RuleSets {
+			if st.RuleSets[i].Name == ruleSet {
+				idx = i
+			}
+		}
+		if idx < 0 {
+			return fmt.Errorf("набор правил %q не найден", ruleSet)
+		}
+		target = &st.RuleSets[idx].Templates
+	}
+	if path == "" {
+		delete(*target, dest)
+		return nil
+	}
+	if *target == nil {
+		*target = make(map[string]string)
+	}
+	(*target)[dest] = path
+	return nil
+}
+
+// templateHTML сообщает, нужен ли получателю шаблон html/template (боты), и
+// проверяет, что такой получатель есть.
+func templateHTML(st *store.State, dest string) (bool, error) {
+	switch dest {
+	case destConsole:
+		return false, nil
+	case destBot:
+		return true, nil
+	}
+	for i, d := range st.Destinations {
+		if destinationName(config.Destination{Name: d.Name, Type: d.Type}, i) == dest {
+			return strings.EqualFold(strings.TrimSpace(d.Type), destBot), nil
+		}
+	}
+	return false, fmt.Errorf("получатель %q не найден", dest)
+}
+
+func runPreviewTemplate(path string, html bool) int {
+	out, err := previewTemplate(path, html)
+	if err != nil {
+		fmt.Fprintln(os.Stdout, ui.Red(err.Error()))
+		return 2
+	}
+	fmt.Fprintln(os.Stdout, out)
+	return 0
+}
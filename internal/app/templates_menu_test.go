@@ -0,0 +1,32 @@
+package app
+
+import (
+	"testing"
+
+	"getclient/internal/store"
+)
+
+func TestTemplateHTML(t *testing.T) {
+	st := &store.State{Destinations: []store.Destination{
+		{Name: "team", Type: "bot"},
+		{Name: "crm", Type: "webhook"},
+		{Type: "file"},
+	}}
+	for _, tc := range []struct {
+		dest string
+		html bool
+		ok   bool
+	}{
+		{destConsole, false, true},
+		{destBot, true, true},
+		{"team", true, true},
+		{"crm", false, true},
+		{"file-3", false, true},
+		{"removed", false, false},
+	} {
+		html, err := templateHTML(st, tc.dest)
+		if (err == nil) != tc.ok || html != tc.html {
+			t.Errorf("templateHTML(%q) = %v, %v, want %v, ok = %v", tc.dest, html, err, tc.html, tc.ok)
+		}
+	}
+}
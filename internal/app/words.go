@@ -31,5 +31,24 @@ func readLines(path string) []string {
 	return out
 }
 
-
-
+func removeWord(path, word string) (bool, error) {
+	word = strings.TrimSpace(word)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+	lines := strings.Split(string(data), "\n")
+	out := lines[:0]
+	found := false
+	for _, line := range lines {
+		if strings.EqualFold(strings.TrimSpace(line), word) {
+			found = true
+			continue
+		}
+		out = append(out, line)
+	}
+	if !found {
+		return false, nil
+	}
+	return true, os.WriteFile(path, []byte(strings.Join(out, "\n")), 0o600)
+}
@@ -0,0 +1,206 @@
+package classifier
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+const (
+	Relevant   = "relevant"
+	Irrelevant = "irrelevant"
+
+	// Модель начинает оценивать после стольких размеченных примеров каждого класса.
+	minDocs = 5
+	maxHits = 500
+	// Слова обрезаются до префикса — грубая замена стемминга для русских окончаний.
+	prefixLen = 6
+)
+
+// Hit — алерт, который можно разметить через меню или кнопки бота.
+type Hit struct {
+	Key     string    `json:"key"`
+	Text    string    `json:"text"`
+	RuleSet string    `json:"rule_set,omitempty"`
+	Chat    string    `json:"chat,omitempty"`
+	Time    time.Time `json:"time"`
+	Label   string    `json:"label,omitempty"`
+}
+
+type model struct {
+	Docs   [2]int            `json:"docs"`
+	Words  [2]int            `json:"words"`
+	Counts map[string][2]int `json:"counts"`
+}
+
+// Classifier — мультиномиальный наивный байесовский классификатор «в тему / мимо».
+type Classifier struct {
+	path string
+	mu   sync.Mutex
+
+	Model model `json:"model"`
+	Hits  []Hit `json:"hits"`
+}
+
+func Open(path string) (*Classifier, error) {
+	c := &Classifier{path: path, Model: model{Counts: make(map[string][2]int)}}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return c, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("classifier %s: %w", path, err)
+	}
+	if c.Model.Counts == nil {
+		c.Model.Counts = make(map[string][2]int)
+	}
+	return c, nil
+}
+
+func (c *Classifier) save() error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o700); err != nil {
+		return err
+	}
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, c.path)
+}
+
+// Stats возвращает число размеченных примеров «в тему» и «мимо».
+func (c *Classifier) Stats() (relevant, irrelevant int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.Model.Docs[0], c.Model.Docs[1]
+}
+
+// Score возвращает вероятность того, что текст «в тему». ok == false, пока примеров мало.
+func (c *Classifier) Score(text string) (float64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	m := &c.Model
+	if m.Docs[0] < minDocs || m.Docs[1] < minDocs {
+		return 0, false
+	}
+	vocab := float64(len(m.Counts))
+	total := float64(m.Docs[0] + m.Docs[1])
+	var logp [2]float64
+	for k := 0; k < 2; k++ {
+		logp[k] = math.Log((float64(m.Docs[k]) + 1) / (total + 2))
+	}
+	for _, w := range Tokenize(text) {
+		counts := m.Counts[w]
+		for k := 0; k < 2; k++ {
+			logp[k] += math.Log((float64(counts[k]) + 1) / (float64(m.Words[k]) + vocab))
+		}
+	}
+	return 1 / (1 + math.Exp(logp[1]-logp[0])), true
+}
+
+// Remember сохраняет алерт для последующей разметки.
+func (c *Classifier) Remember(h Hit) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, x := range c.Hits {
+		if x.Key == h.Key {
+			return nil
+		}
+	}
+	if h.Time.IsZero() {
+		h.Time = time.Now()
+	}
+	c.Hits = append(c.Hits, h)
+	if len(c.Hits) > maxHits {
+		c.Hits = c.Hits[len(c.Hits)-maxHits:]
+	}
+	return c.save()
+}
+
+// Unlabeled возвращает неразмеченные алерты, новые первыми.
+func (c *Classifier) Unlabeled() []Hit {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var out []Hit
+	for i := len(c.Hits) - 1; i >= 0; i-- {
+		if c.Hits[i].Label == "" {
+			out = append(out, c.Hits[i])
+		}
+	}
+	return out
+}
+
+// Label размечает алерт и дообучает модель. Повторная разметка заменяет прежнюю.
+func (c *Classifier) Label(key string, relevant bool) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	label := Irrelevant
+	if relevant {
+		label = Relevant
+	}
+	for i := range c.Hits {
+		h := &c.Hits[i]
+		if h.Key != key {
+			continue
+		}
+		if h.Label == label {
+			return nil
+		}
+		if h.Label != "" {
+			c.train(h.Text, h.Label == Relevant, -1)
+		}
+		h.Label = label
+		c.train(h.Text, relevant, 1)
+		return c.save()
+	}
+	return fmt.Errorf("алерт %s не найден (хранятся последние %d)", key, maxHits)
+}
+
+func (c *Classifier) train(text string, relevant bool, delta int) {
+	k := 1
+	if relevant {
+		k = 0
+	}
+	m := &c.Model
+	m.Docs[k] += delta
+	for _, w := range Tokenize(text) {
+		counts := m.Counts[w]
+		counts[k] += delta
+		m.Words[k] += delta
+		if counts[0] <= 0 && counts[1] <= 0 {
+			delete(m.Counts, w)
+			continue
+		}
+		m.Counts[w] = counts
+	}
+}
+
+func Tokenize(text string) []string {
+	var out []string
+	for _, w := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		r := []rune(strings.ReplaceAll(w, "ё", "е"))
+		if len(r) < 2 {
+			continue
+		}
+		if len(r) > prefixLen {
+			r = r[:prefixLen]
+		}
+		out = append(out, string(r))
+	}
+	return out
+}
@@ -0,0 +1,186 @@
+package classifier
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+var (
+	relevantTexts = []string{
+		"Ищу разработчика на Go для телеграм-бота, бюджет обсуждаем",
+		"Нужен программист, сделать бота для заказов",
+		"Ищем backend разработчика, удалённо, оплата сдельная",
+		"Кто может написать парсер на Python? Заплачу",
+		"Требуется разработчик чат-бота, пишите в личку",
+	}
+	irrelevantTexts = []string{
+		"Продам велосипед, почти новый, самовывоз",
+		"Кто знает хорошую шаурму рядом с метро?",
+		"Сдаю квартиру на длительный срок без посредников",
+		"Поздравляю всех с праздником, друзья!",
+		"Потерялся кот, рыжий, отзывается на Барсик",
+	}
+)
+
+func remember(t *testing.T, c *Classifier, key, text string, relevant bool) {
+	t.Helper()
+	if err := c.Remember(Hit{Key: key, Text: text}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Label(key, relevant); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func train(t *testing.T, c *Classifier, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		remember(t, c, fmt.Sprintf("r:%d", i), relevantTexts[i], true)
+		remember(t, c, fmt.Sprintf("i:%d", i), irrelevantTexts[i], false)
+	}
+}
+
+func TestScoreNeedsData(t *testing.T) {
+	c, err := Open(filepath.Join(t.TempDir(), "classifier.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := c.Score("ищу разработчика"); ok {
+		t.Fatal("empty model scored")
+	}
+	train(t, c, minDocs-1)
+	if _, ok := c.Score("ищу разработчика"); ok {
+		t.Fatalf("model with %d examples per class scored", minDocs-1)
+	}
+	// Много примеров одного класса не заменяют другой.
+	for i := 0; i < minDocs; i++ {
+		remember(t, c, fmt.Sprintf("extra:%d", i), relevantTexts[i], true)
+	}
+	if _, ok := c.Score("ищу разработчика"); ok {
+		t.Fatal("model without enough irrelevant examples scored")
+	}
+}
+
+func TestScore(t *testing.T) {
+	c, err := Open(filepath.Join(t.TempDir(), "classifier.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	train(t, c, minDocs)
+	if rel, irr := c.Stats(); rel != minDocs || irr != minDocs {
+		t.Fatalf("Stats = %d, %d, want %d, %d", rel, irr, minDocs, minDocs)
+	}
+	for _, tc := range []struct {
+		text     string
+		relevant bool
+	}{
+		{"Ищу разработчика бота, оплата сразу", true},
+		{"Нужен программист на Go", true},
+		{"Продам квартиру рядом с метро", false},
+		{"Потерялся рыжий кот", false},
+	} {
+		p, ok := c.Score(tc.text)
+		if !ok {
+			t.Fatalf("Score(%q) not ready", tc.text)
+		}
+		if (p > 0.5) != tc.relevant {
+			t.Errorf("Score(%q) = %.2f, want relevant = %v", tc.text, p, tc.relevant)
+		}
+	}
+}
+
+func TestLabel(t *testing.T) {
+	c, err := Open(filepath.Join(t.TempDir(), "classifier.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	remember(t, c, "k", "Ищу разработчика", true)
+	before := len(c.Model.Counts)
+
+	// Повторная разметка заменяет прежнюю, а не добавляет пример.
+	if err := c.Label("k", false); err != nil {
+		t.Fatal(err)
+	}
+	if rel, irr := c.Stats(); rel != 0 || irr != 1 {
+		t.Errorf("Stats after relabel = %d, %d, want 0, 1", rel, irr)
+	}
+	if err := c.Label("k", false); err != nil {
+		t.Fatal(err)
+	}
+	if rel, irr := c.Stats(); rel != 0 || irr != 1 {
+		t.Errorf("Stats after same label = %d, %d, want 0, 1", rel, irr)
+	}
+	if len(c.Model.Counts) != before {
+		t.Errorf("vocabulary = %d, want %d", len(c.Model.Counts), before)
+	}
+	if len(c.Unlabeled()) != 0 {
+		t.Errorf("Unlabeled = %v, want none", c.Unlabeled())
+	}
+	if err := c.Label("missing", true); err == nil {
+		t.Error("Label of unknown key succeeded")
+	}
+}
+
+func TestRememberPersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", "classifier.json")
+	c, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	train(t, c, minDocs)
+	for _, h := range []Hit{
+		{Key: "new:1", Text: "Ищу дизайнера", RuleSet: "design", Chat: "Фриланс"},
+		{Key: "new:1", Text: "дубль"},
+		{Key: "new:2", Text: "Нужен копирайтер"},
+	} {
+		if err := c.Remember(h); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(reopened.Model, c.Model) {
+		t.Error("model changed after reopen")
+	}
+	unlabeled := reopened.Unlabeled()
+	if len(unlabeled) != 2 || unlabeled[0].Key != "new:2" || unlabeled[1].Text != "Ищу дизайнера" {
+		t.Errorf("Unlabeled after reopen = %+v", unlabeled)
+	}
+	if unlabeled[1].Time.IsZero() || unlabeled[1].RuleSet != "design" {
+		t.Errorf("hit fields lost: %+v", unlabeled[1])
+	}
+	p1, _ := c.Score("Ищу программиста")
+	p2, ok := reopened.Score("Ищу программиста")
+	if !ok || p1 != p2 {
+		t.Errorf("Score after reopen = %v (%v), want %v", p2, ok, p1)
+	}
+}
+
+func TestRememberLimit(t *testing.T) {
+	c, err := Open(filepath.Join(t.TempDir(), "classifier.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < maxHits+10; i++ {
+		c.Hits = append(c.Hits, Hit{Key: fmt.Sprint(i)})
+	}
+	if err := c.Remember(Hit{Key: "last"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Hits) != maxHits || c.Hits[len(c.Hits)-1].Key != "last" || c.Hits[0].Key != "11" {
+		t.Errorf("hits = %d, first %q, last %q", len(c.Hits), c.Hits[0].Key, c.Hits[len(c.Hits)-1].Key)
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	got := Tokenize("Ищу ПРОГРАММИСТА, ёлки-палки! Go и 1С; я")
+	want := []string{"ищу", "програ", "елки", "палки", "go", "1с"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Tokenize = %q, want %q", got, want)
+	}
+}
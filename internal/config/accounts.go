@@ -8,8 +8,20 @@ import (
 )
 
 type accountJSON struct {
-	Name    string `json:"name"`
-	Session string `json:"session"`
+	Name     string     `json:"name"`
+	Session  string     `json:"session"`
+	TopicID  int        `json:"topic_id"`
+	Proxy    string     `json:"proxy"`
+	Device   deviceJSON `json:"device"`
+	RuleSets []string   `json:"rule_sets"`
+	Disabled bool       `json:"disabled"`
+}
+
+type deviceJSON struct {
+	Model         string `json:"device_model"`
+	SystemVersion string `json:"system_version"`
+	AppVersion    string `json:"app_version"`
+	LangCode      string `json:"lang_code"`
 }
 
 func LoadAccounts(accountsFile string, fallbackSessionPath string) ([]Account, error) {
@@ -39,10 +51,15 @@ func LoadAccounts(accountsFile string, fallbackSessionPath string) ([]Account, e
 		if session == "" {
 			return nil, fmt.Errorf("account %q has empty session path", name)
 		}
-		out = append(out, Account{Name: name, SessionPath: session})
+		out = append(out, Account{
+			Name:        name,
+			SessionPath: session,
+			TopicID:     a.TopicID,
+			Proxy:       strings.TrimSpace(a.Proxy),
+			Device:      Device(a.Device),
+			RuleSets:    a.RuleSets,
+			Disabled:    a.Disabled,
+		})
 	}
 	return out, nil
 }
-
-
-
@@ -21,8 +21,17 @@ func Parse() (Config, error) {
 	pollInterval := flag.Duration("poll-interval", 0, "Dialogs polling fallback interval (0 = disabled)")
 	pollLimit := flag.Int("poll-limit", 100, "Dialogs limit per poll")
 
+	contextReplies := flag.Bool("context-replies", false, "Include the replied-to message in alerts")
+	contextMessages := flag.Int("context-messages", 0, "Number of previous chat messages to include in alerts")
+
 	botToken := flag.String("bot-token", "", "Bot token")
 	botChatID := flag.Int64("bot-chat-id", 0, "Bot chat id")
+	botAPIURL := flag.String("bot-api-url", "", "Bot API server URL (default https://api.telegram.org)")
+	botControl := flag.Bool("bot-control", false, "Handle alert buttons and commands sent to the bot")
+
+	hook := flag.String("hook", "", "External alert filter: command (JSON via stdin/stdout) or http(s) URL")
+	hookTimeout := flag.Duration("hook-timeout", 5*time.Second, "External alert filter timeout")
+	hookFailClosed := flag.Bool("hook-fail-closed", false, "Drop alerts when the external filter fails")
 
 	flag.Parse()
 
@@ -46,19 +55,22 @@ func Parse() (Config, error) {
 	}
 
 	return Config{
-		AppID:         appID,
-		AppHash:       appHash,
-		Accounts:      accounts,
-		KeywordsFile:  strings.TrimSpace(*keywordsFile),
-		StopwordsFile: strings.TrimSpace(*stopFile),
-		UseRegex:      *useRegex,
-		PollInterval:  *pollInterval,
-		PollLimit:     *pollLimit,
-		BotToken:      tok,
-		BotChatID:     chatID,
+		AppID:           appID,
+		AppHash:         appHash,
+		Accounts:        accounts,
+		KeywordsFile:    strings.TrimSpace(*keywordsFile),
+		StopwordsFile:   strings.TrimSpace(*stopFile),
+		UseRegex:        *useRegex,
+		PollInterval:    *pollInterval,
+		PollLimit:       *pollLimit,
+		ContextReplies:  *contextReplies,
+		ContextMessages: *contextMessages,
+		BotToken:        tok,
+		BotChatID:       chatID,
+		BotAPIURL:       strings.TrimSpace(*botAPIURL),
+		BotControl:      *botControl,
+		Hook:            strings.TrimSpace(*hook),
+		HookTimeout:     *hookTimeout,
+		HookFailClosed:  *hookFailClosed,
 	}, nil
 }
-
-var _ time.Duration
-
-
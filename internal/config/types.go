@@ -5,6 +5,66 @@ import "time"
 type Account struct {
 	Name        string
 	SessionPath string
+	TopicID     int
+	Proxy       string
+	Device      Device
+	RuleSets    []string
+	Disabled    bool
+}
+
+type Device struct {
+	Model         string
+	SystemVersion string
+	AppVersion    string
+	LangCode      string
+}
+
+type RuleSet struct {
+	Name          string
+	KeywordsFile  string
+	StopwordsFile string
+	UseRegex      bool
+	Normalize     bool
+	Threshold     float64
+	Templates     map[string]string
+	ForwardTo     string
+	ForwardCopy   bool
+	Senders       SenderFilter
+	Chats         ChatFilter
+	Languages     []string
+	AutoReply     AutoReply
+}
+
+type AutoReply struct {
+	Template string
+	Mode     string
+}
+
+type ChatFilter struct {
+	MinMembers int
+	PublicOnly bool
+	Forum      string
+	MinAgeDays int
+	Languages  []string
+}
+
+type SenderFilter struct {
+	Block            []string
+	Watch            []string
+	IgnoreBots       bool
+	IgnoreNoUsername bool
+	IgnoreChannels   bool
+}
+
+type Destination struct {
+	Name     string
+	Type     string
+	BotToken string
+	ChatID   int64
+	URL      string
+	Path     string
+	TopicID  int
+	Priority string
 }
 
 type Config struct {
@@ -20,8 +80,39 @@ type Config struct {
 	PollInterval time.Duration
 	PollLimit    int
 
+	StatePath      string
+	MutedSenders   []int64
+	MutedChats     []string
+	BotControl     bool
+	ControlChatIDs []int64
+	Paused         bool
+
+	ContextReplies  bool
+	ContextMessages int
+
+	Classifier          bool
+	ClassifierThreshold float64
+
+	// Hook — внешний фильтр алертов: команда или http(s)-адрес.
+	Hook           string
+	HookTimeout    time.Duration
+	HookFailClosed bool
+
+	// AutoReply* — общие ограничения автоответов: лимит в сутки на аккаунт,
+	// случайная задержка перед отправкой и пробный режим без отправки.
+	AutoReplyDailyLimit int
+	AutoReplyMinDelay   time.Duration
+	AutoReplyMaxDelay   time.Duration
+	AutoReplyDryRun     bool
+
 	BotToken  string
 	BotChatID int64
-}
+	BotAPIURL string
 
+	RuleSetTopics map[string]int
+	AutoTopics    bool
 
+	Destinations []Destination
+	Templates    map[string]string
+	RuleSets     []RuleSet
+}
@@ -0,0 +1,360 @@
+package extract
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Fields — контакты, ссылки и суммы, найденные в тексте сообщения.
+type Fields struct {
+	Phones    []string `json:"phones,omitempty"`
+	Usernames []string `json:"usernames,omitempty"`
+	Emails    []string `json:"emails,omitempty"`
+	URLs      []string `json:"urls,omitempty"`
+	Prices    []Price  `json:"prices,omitempty"`
+}
+
+// Price — сумма из текста. Max != 0 для диапазона «50–70к». Currency — код ISO
+// (RUB, USD, EUR, KZT, UAH) или USDT; пустой, если валюта не указана.
+type Price struct {
+	Text     string  `json:"text"`
+	Amount   float64 `json:"amount"`
+	Max      float64 `json:"max,omitempty"`
+	Currency string  `json:"currency,omitempty"`
+}
+
+func (f Fields) Empty() bool {
+	return len(f.Phones) == 0 && len(f.Usernames) == 0 && len(f.Emails) == 0 && len(f.URLs) == 0 && len(f.Prices) == 0
+}
+
+func (p Price) String() string {
+	s := formatAmount(p.Amount)
+	if p.Max != 0 {
+		s += "–" + formatAmount(p.Max)
+	}
+	if p.Currency != "" {
+		s += " " + p.Currency
+	}
+	return s
+}
+
+// formatAmount печатает сумму с пробелами между разрядами: 50 000, 1 500.5.
+func formatAmount(v float64) string {
+	s := strconv.FormatFloat(v, 'f', -1, 64)
+	whole, frac, _ := strings.Cut(s, ".")
+	var sb strings.Builder
+	for i, r := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			sb.WriteByte(' ')
+		}
+		sb.WriteRune(r)
+	}
+	if frac != "" {
+		sb.WriteString("." + frac)
+	}
+	return sb.String()
+}
+
+var (
+	emailRe    = regexp.MustCompile(`(?i)[a-z0-9._%+\-]+@[a-z0-9.\-]+\.[a-z]{2,}`)
+	usernameRe = regexp.MustCompile(`(?i)(?:^|[^\w@.])@([a-z][a-z0-9_]{3,31})`)
+	tmeRe      = regexp.MustCompile(`(?i)(?:https?://)?(?:t\.me|telegram\.me)/([a-z][a-z0-9_]{3,31})\b`)
+	urlRe      = regexp.MustCompile(`(?i)\bhttps?://[^\s<>"']+`)
+	phoneRe    = regexp.MustCompile(`\+?\d(?:[\s\-\x{00a0}]?\(?\d\)?){9,14}`)
+)
+
+// Пути t.me, которые не являются юзернеймами.
+var tmeReserved = map[string]bool{"joinchat": true, "addstickers": true, "addlist": true, "share": true, "proxy": true, "socks": true}
+
+// Сумма: «50 000», «1 500,50», «1.5», «50000».
+const number = `\d{1,3}(?:[ \x{00a0}\x{202f}]\d{3})+(?:[.,]\d+)?|\d+(?:[.,]\d+)?`
+
+// Множитель: «50к», «50 тыс.», «1,5 млн», «100 т.р.» (тысяч рублей).
+const multiplier = `т\.?\s?р\.?|тыс(?:яч[аи]?|\.)?|млн\.?|кк|к|kk|k|mln`
+
+const currency = `₽|руб(?:лей|ля|ль)?\.?|р\.?|rub|\$|usdt|usd|долл(?:аров|ара|ар)?\.?|€|eur|евро|₸|тг|тенге|kzt|₴|грн\.?|uah`
+
+const amount = `(` + number + `)(?:\s?(` + multiplier + `))?(?:\s*(?:-|–|—|до|to)\s*(` + number + `)(?:\s?(` + multiplier + `))?)?`
+
+var (
+	// $500, € 1 200
+	pricePrefix = regexp.MustCompile(`(?i)([$€₽₸₴])\s?` + amount)
+	// 500$, 50 000 руб, 50к ₽, 100-150 тыс. руб, 100 т.р., 1500 р/час
+	priceSuffix = regexp.MustCompile(`(?i)` + amount + `(?:\s?(` + currency + `))?`)
+	// бюджет 50к, оплата: от 30 000, зп 100-150 тыс.
+	priceKeyword = regexp.MustCompile(`(?i)(?:бюджет|оплата|оплату|цена|стоимость|зп|з/п|зарплата|ставка|оклад|доход|гонорар|budget|price|salary|rate|pay)\s*[:\-–—]?\s*(?:от|до|около|from|up to)?\s*` + amount + `(?:\s?(` + currency + `))?`)
+)
+
+// Parse извлекает из текста телефоны, @username, email, ссылки и суммы.
+func Parse(text string) Fields {
+	var f Fields
+	var taken []span
+
+	for _, loc := range emailRe.FindAllStringIndex(text, -1) {
+		f.Emails = appendUnique(f.Emails, strings.ToLower(text[loc[0]:loc[1]]))
+		taken = append(taken, span{loc[0], loc[1]})
+	}
+	for _, loc := range urlRe.FindAllStringIndex(text, -1) {
+		u := strings.TrimRight(text[loc[0]:loc[1]], ".,;:!?)»")
+		f.AddURL(u)
+		taken = append(taken, span{loc[0], loc[0] + len(u)})
+	}
+	for _, m := range tmeRe.FindAllStringSubmatch(text, -1) {
+		if !tmeReserved[strings.ToLower(m[1])] {
+			f.AddUsername(m[1])
+		}
+	}
+	for _, m := range usernameRe.FindAllStringSubmatchIndex(text, -1) {
+		if !overlaps(taken, m[2], m[3]) {
+			f.AddUsername(text[m[2]:m[3]])
+		}
+	}
+	for _, loc := range phoneRe.FindAllStringIndex(text, -1) {
+		if overlaps(taken, loc[0], loc[1]) || !boundary(text, loc[0], loc[1]) {
+			continue
+		}
+		if phone, ok := normalizePhone(text[loc[0]:loc[1]]); ok {
+			f.Phones = appendUnique(f.Phones, phone)
+			taken = append(taken, span{loc[0], loc[1]})
+		}
+	}
+	f.Prices = parsePrices(text, taken)
+	return f
+}
+
+// AddURL добавляет ссылку, если её ещё нет.
+func (f *Fields) AddURL(u string) {
+	if u = strings.TrimSpace(u); u != "" {
+		f.URLs = appendUnique(f.URLs, u)
+	}
+}
+
+// AddUsername добавляет @username в нижнем регистре, если его ещё нет.
+func (f *Fields) AddUsername(u string) {
+	if u = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(u), "@")); u != "" {
+		f.Usernames = appendUnique(f.Usernames, "@"+u)
+	}
+}
+
+// normalizePhone приводит номер к виду +79991234567; 8 в начале российского номера
+// заменяется на +7. Номер без кода страны из 10 цифр считается российским.
+func normalizePhone(s string) (string, bool) {
+	var digits strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			digits.WriteRune(r)
+		}
+	}
+	d := digits.String()
+	plus := strings.HasPrefix(strings.TrimSpace(s), "+")
+	switch {
+	case plus && len(d) >= 10 && len(d) <= 15:
+		return "+" + d, true
+	case len(d) == 11 && (d[0] == '8' || d[0] == '7'):
+		return "+7" + d[1:], true
+	case len(d) == 10 && d[0] == '9':
+		return "+7" + d, true
+	}
+	return "", false
+}
+
+type span struct{ start, end int }
+
+func overlaps(spans []span, start, end int) bool {
+	for _, s := range spans {
+		if start < s.end && s.start < end {
+			return true
+		}
+	}
+	return false
+}
+
+// boundary — совпадение не продолжает слово или число.
+func boundary(text string, start, end int) bool {
+	if start > 0 {
+		r, _ := utf8.DecodeLastRuneInString(text[:start])
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return false
+		}
+	}
+	if end < len(text) {
+		r, _ := utf8.DecodeRuneInString(text[end:])
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+type priceMatch struct {
+	span
+	price Price
+}
+
+func parsePrices(text string, taken []span) []Price {
+	var found []priceMatch
+	// add разбирает совпадение: groups — число, множитель, второе число и его
+	// множитель, cur — позиция валюты. needCurrency — сумма без валюты не нужна.
+	add := func(start, end int, cur, groups []int, needCurrency bool) {
+		end = start + len(strings.TrimRightFunc(text[start:end], unicode.IsSpace))
+		// Совпадение, которое продолжает слово («2 раза», «50 кг»), укорачивается до
+		// конца предыдущей группы: сначала отбрасывается валюта, затем множитель.
+		for !boundary(text, start, end) {
+			cut := -1
+			for i := 1; i < len(groups); i += 2 {
+				if groups[i] < end && groups[i] > cut {
+					cut = groups[i]
+				}
+			}
+			if cut < 0 {
+				return
+			}
+			end = cut
+		}
+		if overlaps(taken, start, end) {
+			return
+		}
+		for _, p := range found {
+			if start < p.end && p.start < end {
+				return
+			}
+		}
+		group := func(i int) string {
+			if groups[2*i] < 0 || groups[2*i] >= end {
+				return ""
+			}
+			return text[groups[2*i]:groups[2*i+1]]
+		}
+		c := ""
+		if cur[0] >= 0 && cur[0] < end {
+			c = text[cur[0]:cur[1]]
+		}
+		p, ok := makePrice(group(0), group(1), group(2), group(3), c)
+		if !ok || needCurrency && p.Currency == "" {
+			return
+		}
+		p.Text = text[start:end]
+		found = append(found, priceMatch{span{start, end}, p})
+	}
+
+	for _, m := range pricePrefix.FindAllStringSubmatchIndex(text, -1) {
+		add(m[0], m[1], m[2:4], m[4:], true)
+	}
+	for _, m := range priceSuffix.FindAllStringSubmatchIndex(text, -1) {
+		// Без валюты число — сумма, только если это «т.р.».
+		add(m[0], m[1], m[10:12], m[2:10], true)
+	}
+	for _, m := range priceKeyword.FindAllStringSubmatchIndex(text, -1) {
+		add(m[0], m[1], m[10:12], m[2:10], false)
+	}
+
+	sort.Slice(found, func(i, j int) bool { return found[i].start < found[j].start })
+	out := make([]Price, 0, len(found))
+	for _, p := range found {
+		out = append(out, p.price)
+	}
+	return out
+}
+
+func makePrice(num, mult, maxNum, maxMult, cur string) (Price, bool) {
+	v, ok := parseNumber(num)
+	if !ok {
+		return Price{}, false
+	}
+	p := Price{Currency: currencyCode(cur)}
+	if maxNum != "" {
+		max, ok := parseNumber(maxNum)
+		if !ok {
+			return Price{}, false
+		}
+		// «50-70к»: множитель второго числа относится к обоим.
+		if mult == "" {
+			mult = maxMult
+		}
+		p.Max = max * multiplierValue(maxMult)
+	}
+	p.Amount = v * multiplierValue(mult)
+	if p.Currency == "" {
+		p.Currency = multiplierCurrency(mult)
+	}
+	if p.Max != 0 && p.Max < p.Amount {
+		return Price{}, false
+	}
+	// «оплата 2 раза в неделю» — не сумма.
+	if p.Currency == "" && p.Amount < 100 {
+		return Price{}, false
+	}
+	return p, p.Amount > 0
+}
+
+// parseNumber разбирает «50 000», «1 500,50», «1,5», «1.500»: разделитель перед
+// ровно тремя последними цифрами считается разделителем разрядов.
+func parseNumber(s string) (float64, bool) {
+	s = strings.NewReplacer(" ", "", " ", "", " ", "").Replace(s)
+	if i := strings.LastIndexAny(s, ".,"); i >= 0 {
+		if len(s)-i-1 == 3 {
+			s = s[:i] + s[i+1:]
+		} else {
+			s = s[:i] + "." + s[i+1:]
+		}
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsInf(v, 0) {
+		return 0, false
+	}
+	return v, true
+}
+
+func multiplierValue(m string) float64 {
+	m = strings.ToLower(strings.ReplaceAll(m, " ", ""))
+	switch {
+	case m == "":
+		return 1
+	case strings.HasPrefix(m, "млн"), m == "mln", m == "кк", m == "kk":
+		return 1e6
+	}
+	return 1e3
+}
+
+// multiplierCurrency — «т.р.» означает тысячи рублей.
+func multiplierCurrency(m string) string {
+	if strings.HasPrefix(strings.ToLower(m), "т") && strings.Contains(strings.ToLower(m), "р") {
+		return "RUB"
+	}
+	return ""
+}
+
+func currencyCode(cur string) string {
+	c := strings.ToLower(strings.TrimSpace(cur))
+	switch {
+	case c == "":
+		return ""
+	case c == "₽", strings.HasPrefix(c, "руб"), c == "р", c == "р.", c == "rub":
+		return "RUB"
+	case c == "usdt":
+		return "USDT"
+	case c == "$", c == "usd", strings.HasPrefix(c, "долл"):
+		return "USD"
+	case c == "€", c == "eur", c == "евро":
+		return "EUR"
+	case c == "₸", c == "тг", c == "тенге", c == "kzt":
+		return "KZT"
+	case c == "₴", strings.HasPrefix(c, "грн"), c == "uah":
+		return "UAH"
+	}
+	return strings.ToUpper(c)
+}
+
+func appendUnique(list []string, s string) []string {
+	for _, x := range list {
+		if x == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
@@ -0,0 +1,66 @@
+package extract
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePrices(t *testing.T) {
+	for _, tc := range []struct {
+		text string
+		want []Price
+	}{
+		{"$500 в месяц", []Price{{"$500", 500, 0, "USD"}}},
+		{"бюджет 50000 за проект", []Price{{"бюджет 50000", 50000, 0, ""}}},
+		{"ставка 2000 за час", []Price{{"ставка 2000", 2000, 0, ""}}},
+		{"бюджет 30 000 обсуждается", []Price{{"бюджет 30 000", 30000, 0, ""}}},
+		{"ставка 1500 р/час", []Price{{"1500 р", 1500, 0, "RUB"}}},
+		{"плачу 50к ₽ в месяц", []Price{{"50к ₽", 50000, 0, "RUB"}}},
+		{"зп 100-150 тыс. руб на руки", []Price{{"100-150 тыс. руб", 100000, 150000, "RUB"}}},
+		{"оклад 100 т.р.", []Price{{"100 т.р.", 100000, 0, "RUB"}}},
+		{"€ 1 200 за лендинг", []Price{{"€ 1 200", 1200, 0, "EUR"}}},
+		{"бюджет 1,5 млн тенге", []Price{{"1,5 млн тенге", 1500000, 0, "KZT"}}},
+		{"500$ и 300 usdt", []Price{{"500$", 500, 0, "USD"}, {"300 usdt", 300, 0, "USDT"}}},
+		{"оплата 2 раза в неделю", nil},
+		{"привезу 50 кг за 2 рейса", nil},
+		{"в 2024 году", nil},
+	} {
+		t.Run(tc.text, func(t *testing.T) {
+			got := Parse(tc.text).Prices
+			if len(got) != len(tc.want) || len(got) > 0 && !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Parse(%q).Prices = %#v, want %#v", tc.text, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseContacts(t *testing.T) {
+	text := "Пишите @Ivan_Dev или t.me/joinchat/abc, почта Job@Example.com, " +
+		"тел. 8 (999) 123-45-67, сайт https://example.com/vacancy?id=1."
+	got := Parse(text)
+	want := Fields{
+		Phones:    []string{"+79991234567"},
+		Usernames: []string{"@ivan_dev"},
+		Emails:    []string{"job@example.com"},
+		URLs:      []string{"https://example.com/vacancy?id=1"},
+	}
+	got.Prices = nil
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse = %#v, want %#v", got, want)
+	}
+}
+
+func TestPriceString(t *testing.T) {
+	for _, tc := range []struct {
+		p    Price
+		want string
+	}{
+		{Price{Amount: 50000, Currency: "RUB"}, "50 000 RUB"},
+		{Price{Amount: 100000, Max: 150000}, "100 000–150 000"},
+		{Price{Amount: 1500.5, Currency: "USD"}, "1 500.5 USD"},
+	} {
+		if got := tc.p.String(); got != tc.want {
+			t.Errorf("String() = %q, want %q", got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package join
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gotd/td/tg"
+	"github.com/gotd/td/tgerr"
+)
+
+const (
+	KindGroup   = "group"
+	KindChannel = "channel"
+)
+
+const (
+	searchLimit    = 50
+	searchInterval = 3 * time.Second
+)
+
+// Found — публичный чат, найденный поиском, для просмотра перед вступлением.
+type Found struct {
+	ID       int64     `json:"id"`
+	Username string    `json:"username"`
+	Title    string    `json:"title"`
+	Kind     string    `json:"kind"`
+	Members  int       `json:"members,omitempty"`
+	Member   bool      `json:"member,omitempty"`
+	Queries  []string  `json:"queries"`
+	Found    time.Time `json:"found"`
+}
+
+// Link — ссылка для ReadTargets/ParseTarget.
+func (f Found) Link() string {
+	return "https://t.me/" + f.Username
+}
+
+// Discover ищет публичные группы и каналы по каждому запросу через contacts.search.
+// При FLOOD_WAIT поиск прекращается и возвращается то, что уже найдено.
+func Discover(ctx context.Context, api *tg.Client, queries []string) ([]Found, error) {
+	byID := make(map[int64]*Found)
+	var order []int64
+	for i, q := range queries {
+		q = strings.TrimSpace(q)
+		if q == "" {
+			continue
+		}
+		if i > 0 {
+			if err := sleep(ctx, searchInterval); err != nil {
+				return collect(byID, order), err
+			}
+		}
+		res, err := api.ContactsSearch(ctx, &tg.ContactsSearchRequest{Q: q, Limit: searchLimit})
+		if err != nil {
+			if d, ok := tgerr.AsFloodWait(err); ok {
+				return collect(byID, order), &FloodError{Wait: d}
+			}
+			return collect(byID, order), err
+		}
+		for _, c := range res.Chats {
+			ch, ok := c.(*tg.Channel)
+			if !ok || ch.Username == "" {
+				continue
+			}
+			if f, ok := byID[ch.ID]; ok {
+				if !slices.Contains(f.Queries, q) {
+					f.Queries = append(f.Queries, q)
+				}
+				continue
+			}
+			f := &Found{
+				ID:       ch.ID,
+				Username: ch.Username,
+				Title:    ch.Title,
+				Kind:     KindGroup,
+				Member:   !ch.Left,
+				Queries:  []string{q},
+				Found:    time.Now(),
+			}
+			if ch.Broadcast {
+				f.Kind = KindChannel
+			}
+			if n, ok := ch.GetParticipantsCount(); ok {
+				f.Members = n
+			}
+			byID[ch.ID] = f
+			order = append(order, ch.ID)
+		}
+	}
+	return collect(byID, order), nil
+}
+
+func collect(byID map[int64]*Found, order []int64) []Found {
+	out := make([]Found, 0, len(order))
+	for _, id := range order {
+		out = append(out, *byID[id])
+	}
+	return out
+}
+
+// FloodError — Telegram временно запретил поиск.
+type FloodError struct {
+	Wait time.Duration
+}
+
+func (e *FloodError) Error() string {
+	return "FLOOD_WAIT " + e.Wait.Round(time.Second).String()
+}
+
+func LoadFound(path string) ([]Found, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var out []Found
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// MergeFound добавляет новые результаты к сохранённым: у известных чатов обновляются
+// название, число участников и запросы, дата находки остаётся прежней. Возвращает
+// только чаты, которых раньше не было.
+func MergeFound(path string, found []Found) ([]Found, error) {
+	saved, err := LoadFound(path)
+	if err != nil {
+		return nil, err
+	}
+	index := make(map[int64]int, len(saved))
+	for i, f := range saved {
+		index[f.ID] = i
+	}
+	var fresh []Found
+	for _, f := range found {
+		i, ok := index[f.ID]
+		if !ok {
+			index[f.ID] = len(saved)
+			saved = append(saved, f)
+			fresh = append(fresh, f)
+			continue
+		}
+		old := &saved[i]
+		old.Username, old.Title, old.Member = f.Username, f.Title, f.Member
+		if f.Members > 0 {
+			old.Members = f.Members
+		}
+		for _, q := range f.Queries {
+			if !slices.Contains(old.Queries, q) {
+				old.Queries = append(old.Queries, q)
+			}
+		}
+	}
+	sort.SliceStable(saved, func(i, j int) bool { return saved[i].Members > saved[j].Members })
+	data, err := json.MarshalIndent(saved, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+	return fresh, os.WriteFile(path, data, 0o600)
+}
@@ -0,0 +1,68 @@
+package join
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestMergeFound(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", "discovered.json")
+	first := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	fresh, err := MergeFound(path, []Found{
+		{ID: 1, Username: "go_jobs", Title: "Go Jobs", Members: 100, Queries: []string{"go"}, Found: first},
+		{ID: 2, Username: "py_jobs", Title: "Py Jobs", Members: 500, Queries: []string{"python"}, Found: first},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fresh) != 2 {
+		t.Fatalf("fresh = %d, want 2", len(fresh))
+	}
+
+	fresh, err = MergeFound(path, []Found{
+		{ID: 1, Username: "go_jobs", Title: "Go Jobs RU", Member: true, Queries: []string{"golang", "go"}, Found: time.Now()},
+		{ID: 3, Username: "rust_jobs", Title: "Rust Jobs", Members: 300, Queries: []string{"rust"}, Found: time.Now()},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fresh) != 1 || fresh[0].ID != 3 {
+		t.Fatalf("fresh = %+v, want only id 3", fresh)
+	}
+
+	saved, err := LoadFound(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []int64
+	for _, f := range saved {
+		ids = append(ids, f.ID)
+	}
+	// Сортировка по числу участников.
+	if !slices.Equal(ids, []int64{2, 3, 1}) {
+		t.Fatalf("saved ids = %v, want [2 3 1]", ids)
+	}
+	goJobs := saved[2]
+	if goJobs.Title != "Go Jobs RU" || !goJobs.Member {
+		t.Errorf("title/member not updated: %+v", goJobs)
+	}
+	if goJobs.Members != 100 {
+		t.Errorf("members = %d, want 100 kept when unknown", goJobs.Members)
+	}
+	if !slices.Equal(goJobs.Queries, []string{"go", "golang"}) {
+		t.Errorf("queries = %v, want [go golang]", goJobs.Queries)
+	}
+	if !goJobs.Found.Equal(first) {
+		t.Errorf("found date = %v, want %v", goJobs.Found, first)
+	}
+}
+
+func TestLoadFoundMissing(t *testing.T) {
+	found, err := LoadFound(filepath.Join(t.TempDir(), "none.json"))
+	if err != nil || found != nil {
+		t.Errorf("LoadFound = %v, %v; want nil, nil", found, err)
+	}
+}
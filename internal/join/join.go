@@ -0,0 +1,258 @@
+// Package join вступает в чаты по списку ссылок и ищет новые чаты через contacts.search.
+package join
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gotd/td/tg"
+	"github.com/gotd/td/tgerr"
+)
+
+const (
+	StatusJoined    = "joined"
+	StatusAlready   = "already"
+	StatusRequested = "requested"
+	StatusFailed    = "failed"
+)
+
+// Паузы по умолчанию: Telegram ограничивает вступления примерно парой десятков в час.
+const (
+	DefaultMinDelay = 60 * time.Second
+	DefaultMaxDelay = 180 * time.Second
+	// Более долгий FLOOD_WAIT не пережидается: вступление останавливается.
+	DefaultMaxWait = 15 * time.Minute
+)
+
+// ErrChannelsTooMuch — аккаунт состоит в максимуме чатов, дальше вступать бессмысленно.
+var ErrChannelsTooMuch = errors.New("аккаунт состоит в максимальном числе чатов")
+
+// Target — чат для вступления: публичный username или хэш приглашения.
+type Target struct {
+	Raw      string
+	Username string
+	Invite   string
+}
+
+var usernameRe = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_]{3,31}$`)
+
+// ParseTarget понимает @name, name, t.me/name, t.me/+hash, t.me/joinchat/hash
+// и tg://resolve?domain=name, tg://join?invite=hash.
+func ParseTarget(s string) (Target, error) {
+	t := Target{Raw: strings.TrimSpace(s)}
+	v := t.Raw
+	if strings.HasPrefix(v, "tg://") {
+		u, err := url.Parse(v)
+		if err != nil {
+			return t, err
+		}
+		if inv := u.Query().Get("invite"); inv != "" {
+			t.Invite = inv
+			return t, nil
+		}
+		v = u.Query().Get("domain")
+	}
+	v = strings.TrimPrefix(strings.TrimPrefix(v, "https://"), "http://")
+	for _, host := range []string{"t.me/", "telegram.me/", "telegram.dog/"} {
+		v = strings.TrimPrefix(v, host)
+	}
+	v = strings.TrimPrefix(v, "@")
+	if i := strings.IndexAny(v, "?/"); i >= 0 && !strings.HasPrefix(v, "joinchat/") {
+		v = v[:i]
+	}
+	switch {
+	case strings.HasPrefix(v, "+"):
+		t.Invite = strings.TrimPrefix(v, "+")
+	case strings.HasPrefix(v, "joinchat/"):
+		t.Invite = strings.Trim(strings.TrimPrefix(v, "joinchat/"), "/")
+	case usernameRe.MatchString(v):
+		t.Username = v
+	default:
+		return t, fmt.Errorf("не похоже на ссылку или username: %q", t.Raw)
+	}
+	if t.Invite == "" && t.Username == "" {
+		return t, fmt.Errorf("пустая ссылка: %q", t.Raw)
+	}
+	return t, nil
+}
+
+// Key — ключ для сравнения целей из разных записей одной ссылки.
+func (t Target) Key() string {
+	if t.Invite != "" {
+		return "+" + t.Invite
+	}
+	return strings.ToLower(t.Username)
+}
+
+// Result — итог вступления в один чат.
+type Result struct {
+	Time    time.Time `json:"time"`
+	Account string    `json:"account"`
+	Target  string    `json:"target"`
+	Key     string    `json:"key"`
+	Status  string    `json:"status"`
+	Title   string    `json:"title,omitempty"`
+	Error   string    `json:"error,omitempty"`
+}
+
+// Joiner вступает в чаты по одному со случайной паузой между вступлениями.
+// FLOOD_WAIT до MaxWait пережидается, более долгий останавливает работу.
+type Joiner struct {
+	api      *tg.Client
+	account  string
+	minDelay time.Duration
+	maxDelay time.Duration
+	maxWait  time.Duration
+}
+
+func NewJoiner(api *tg.Client, account string) *Joiner {
+	return &Joiner{
+		api:      api,
+		account:  account,
+		minDelay: DefaultMinDelay,
+		maxDelay: DefaultMaxDelay,
+		maxWait:  DefaultMaxWait,
+	}
+}
+
+func (j *Joiner) WithDelay(min, max time.Duration) *Joiner {
+	if max < min {
+		max = min
+	}
+	j.minDelay, j.maxDelay = min, max
+	return j
+}
+
+func (j *Joiner) WithMaxWait(d time.Duration) *Joiner {
+	j.maxWait = d
+	return j
+}
+
+// Run вступает во все цели по порядку и вызывает report после каждой. Ошибка
+// возвращается, если продолжать нельзя: отмена, долгий FLOOD_WAIT, лимит чатов.
+// Пауза делается только после запроса на вступление: цели, где аккаунт уже
+// состоит или которые не нашлись, лимит вступлений не расходуют.
+func (j *Joiner) Run(ctx context.Context, targets []Target, report func(Result)) error {
+	attempted := false
+	for _, t := range targets {
+		if attempted {
+			if err := sleep(ctx, j.delay()); err != nil {
+				return err
+			}
+		}
+		res, tried, err := j.joinWithRetry(ctx, t)
+		attempted = tried
+		report(res)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// joinWithRetry возвращает итог и attempted, если до запроса на вступление дошло.
+func (j *Joiner) joinWithRetry(ctx context.Context, t Target) (Result, bool, error) {
+	attempted := false
+	for {
+		res := Result{Account: j.account, Target: t.Raw, Key: t.Key()}
+		title, tried, err := j.join(ctx, t)
+		attempted = attempted || tried
+		res.Time, res.Title = time.Now(), title
+		switch {
+		case !tried && err == nil:
+			res.Status = StatusAlready
+		case err == nil:
+			res.Status = StatusJoined
+		case tgerr.Is(err, "USER_ALREADY_PARTICIPANT"):
+			res.Status = StatusAlready
+		case tgerr.Is(err, "INVITE_REQUEST_SENT"):
+			res.Status = StatusRequested
+		default:
+			res.Status, res.Error = StatusFailed, err.Error()
+			if d, ok := tgerr.AsFloodWait(err); ok {
+				if d > j.maxWait {
+					return res, attempted, fmt.Errorf("FLOOD_WAIT %s: продолжите позже", d.Round(time.Second))
+				}
+				if err := sleep(ctx, d+time.Second); err != nil {
+					return res, attempted, err
+				}
+				continue
+			}
+			if tgerr.Is(err, "CHANNELS_TOO_MUCH") {
+				return res, attempted, ErrChannelsTooMuch
+			}
+		}
+		return res, attempted, nil
+	}
+}
+
+// join возвращает название чата и attempted, если был отправлен запрос на вступление.
+// Без запроса и без ошибки аккаунт уже состоит в чате.
+func (j *Joiner) join(ctx context.Context, t Target) (string, bool, error) {
+	if t.Invite != "" {
+		upd, err := j.api.MessagesImportChatInvite(ctx, t.Invite)
+		if err != nil {
+			return "", true, err
+		}
+		return updatesTitle(upd), true, nil
+	}
+	res, err := j.api.ContactsResolveUsername(ctx, t.Username)
+	if err != nil {
+		return "", false, err
+	}
+	p, ok := res.Peer.(*tg.PeerChannel)
+	if !ok {
+		return "", false, fmt.Errorf("@%s — не группа и не канал", t.Username)
+	}
+	for _, c := range res.Chats {
+		ch, ok := c.(*tg.Channel)
+		if !ok || ch.ID != p.ChannelID {
+			continue
+		}
+		if !ch.Left {
+			return ch.Title, false, nil
+		}
+		_, err := j.api.ChannelsJoinChannel(ctx, ch.AsInput())
+		return ch.Title, true, err
+	}
+	return "", false, fmt.Errorf("@%s: канал не найден в ответе", t.Username)
+}
+
+func (j *Joiner) delay() time.Duration {
+	d := j.minDelay
+	if spread := j.maxDelay - j.minDelay; spread > 0 {
+		d += time.Duration(rand.Int63n(int64(spread)))
+	}
+	return d
+}
+
+func updatesTitle(u tg.UpdatesClass) string {
+	if v, ok := u.(*tg.Updates); ok {
+		for _, c := range v.Chats {
+			switch ch := c.(type) {
+			case *tg.Channel:
+				return ch.Title
+			case *tg.Chat:
+				return ch.Title
+			}
+		}
+	}
+	return ""
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,140 @@
+package join
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gotd/td/bin"
+	"github.com/gotd/td/tg"
+	"github.com/gotd/td/tgerr"
+)
+
+func TestParseTarget(t *testing.T) {
+	for _, tc := range []struct {
+		in       string
+		username string
+		invite   string
+		err      bool
+	}{
+		{in: "@golang_jobs", username: "golang_jobs"},
+		{in: "golang_jobs", username: "golang_jobs"},
+		{in: " https://t.me/golang_jobs ", username: "golang_jobs"},
+		{in: "t.me/golang_jobs/123", username: "golang_jobs"},
+		{in: "https://telegram.me/golang_jobs?start=1", username: "golang_jobs"},
+		{in: "https://t.me/+AbCdEf123", invite: "AbCdEf123"},
+		{in: "t.me/joinchat/AbCdEf123", invite: "AbCdEf123"},
+		{in: "https://t.me/joinchat/AbCdEf123/", invite: "AbCdEf123"},
+		{in: "tg://resolve?domain=golang_jobs", username: "golang_jobs"},
+		{in: "tg://join?invite=AbCdEf123", invite: "AbCdEf123"},
+		{in: "", err: true},
+		{in: "@abc", err: true},
+		{in: "1golang", err: true},
+		{in: "https://t.me/+", err: true},
+		{in: "t.me/joinchat/", err: true},
+		{in: "tg://resolve?phone=123", err: true},
+		{in: "golang jobs", err: true},
+	} {
+		got, err := ParseTarget(tc.in)
+		if (err != nil) != tc.err {
+			t.Errorf("ParseTarget(%q) error = %v, want error = %v", tc.in, err, tc.err)
+			continue
+		}
+		if err == nil && (got.Username != tc.username || got.Invite != tc.invite) {
+			t.Errorf("ParseTarget(%q) = @%q +%q, want @%q +%q", tc.in, got.Username, got.Invite, tc.username, tc.invite)
+		}
+	}
+}
+
+func TestTargetKey(t *testing.T) {
+	a, _ := ParseTarget("@Golang_Jobs")
+	b, _ := ParseTarget("https://t.me/golang_jobs")
+	if a.Key() != b.Key() {
+		t.Errorf("keys differ: %q, %q", a.Key(), b.Key())
+	}
+	c, _ := ParseTarget("t.me/+AbC")
+	if c.Key() != "+AbC" {
+		t.Errorf("invite key = %q", c.Key())
+	}
+}
+
+type invokerFunc func(ctx context.Context, input bin.Encoder, output bin.Decoder) error
+
+func (f invokerFunc) Invoke(ctx context.Context, input bin.Encoder, output bin.Decoder) error {
+	return f(ctx, input, output)
+}
+
+// fakeJoinAPI: username member — аккаунт уже в чате, missing — не существует,
+// остальные — чаты, куда можно вступить.
+func fakeJoinAPI(joins *int) *tg.Client {
+	return tg.NewClient(invokerFunc(func(ctx context.Context, input bin.Encoder, output bin.Decoder) error {
+		switch req := input.(type) {
+		case *tg.ContactsResolveUsernameRequest:
+			if req.Username == "missing" {
+				return tgerr.New(400, "USERNAME_NOT_OCCUPIED")
+			}
+			ch := &tg.Channel{ID: int64(len(req.Username)), AccessHash: 1, Title: req.Username, Left: req.Username != "member"}
+			out := output.(*tg.ContactsResolvedPeer)
+			out.Peer = &tg.PeerChannel{ChannelID: ch.ID}
+			out.Chats = []tg.ChatClass{ch}
+		case *tg.ChannelsJoinChannelRequest:
+			*joins++
+			output.(*tg.UpdatesBox).Updates = &tg.Updates{}
+		}
+		return nil
+	}))
+}
+
+func TestJoinerRunPauses(t *testing.T) {
+	const delay = 300 * time.Millisecond
+	targets := func(names ...string) []Target {
+		var out []Target
+		for _, n := range names {
+			tgt, err := ParseTarget(n)
+			if err != nil {
+				t.Fatal(err)
+			}
+			out = append(out, tgt)
+		}
+		return out
+	}
+	for _, tc := range []struct {
+		name     string
+		targets  []Target
+		statuses []string
+		joins    int
+		pauses   int
+	}{
+		{"nothing to join", targets("member", "missing", "member"), []string{StatusAlready, StatusFailed, StatusAlready}, 0, 0},
+		{"pause after a join only", targets("first_chat", "member", "second_chat"), []string{StatusJoined, StatusAlready, StatusJoined}, 2, 1},
+		{"joins in a row", targets("first_chat", "second_chat", "missing"), []string{StatusJoined, StatusJoined, StatusFailed}, 2, 2},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			joins := 0
+			var statuses []string
+			start := time.Now()
+			err := NewJoiner(fakeJoinAPI(&joins), "acc").WithDelay(delay, delay).Run(context.Background(), tc.targets, func(r Result) {
+				statuses = append(statuses, r.Status)
+			})
+			elapsed := time.Since(start)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(statuses) != len(tc.statuses) {
+				t.Fatalf("statuses = %v, want %v", statuses, tc.statuses)
+			}
+			for i := range statuses {
+				if statuses[i] != tc.statuses[i] {
+					t.Errorf("statuses = %v, want %v", statuses, tc.statuses)
+					break
+				}
+			}
+			if joins != tc.joins {
+				t.Errorf("joinChannel calls = %d, want %d", joins, tc.joins)
+			}
+			if pauses := int(elapsed / delay); pauses != tc.pauses {
+				t.Errorf("elapsed %s = %d pauses, want %d", elapsed, pauses, tc.pauses)
+			}
+		})
+	}
+}
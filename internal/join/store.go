@@ -0,0 +1,105 @@
+package join
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Log — журнал вступлений (JSON Lines). По нему повторный запуск пропускает чаты,
+// в которые аккаунт уже вступил или подал заявку.
+type Log struct {
+	path string
+
+	mu   sync.Mutex
+	done map[string]struct{}
+}
+
+func OpenLog(path string) (*Log, error) {
+	l := &Log{path: path, done: make(map[string]struct{})}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return l, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var r Result
+		if json.Unmarshal(sc.Bytes(), &r) == nil && r.Status != StatusFailed {
+			l.done[r.Account+"|"+r.Key] = struct{}{}
+		}
+	}
+	return l, sc.Err()
+}
+
+// Done — аккаунт уже вступил в чат или ждёт одобрения заявки.
+func (l *Log) Done(account string, t Target) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, ok := l.done[account+"|"+t.Key()]
+	return ok
+}
+
+func (l *Log) Append(r Result) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if r.Status != StatusFailed {
+		l.done[r.Account+"|"+r.Key] = struct{}{}
+	}
+	if err := os.MkdirAll(filepath.Dir(l.path), 0o700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ReadTargets читает ссылки из файла: по одной в строке, пустые строки и # — пропускаются.
+// Повторы убираются; нераспознанные строки возвращаются отдельно.
+func ReadTargets(path string) ([]Target, []error, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	targets, bad := ParseTargets(strings.Split(string(data), "\n"))
+	return targets, bad, nil
+}
+
+func ParseTargets(lines []string) ([]Target, []error) {
+	var targets []Target
+	var bad []error
+	seen := make(map[string]struct{})
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		t, err := ParseTarget(line)
+		if err != nil {
+			bad = append(bad, err)
+			continue
+		}
+		if _, ok := seen[t.Key()]; ok {
+			continue
+		}
+		seen[t.Key()] = struct{}{}
+		targets = append(targets, t)
+	}
+	return targets, bad
+}
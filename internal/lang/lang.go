@@ -0,0 +1,139 @@
+package lang
+
+import (
+	"embed"
+	"math"
+	"strings"
+	"unicode"
+)
+
+const (
+	Russian   = "ru"
+	Ukrainian = "uk"
+	Kazakh    = "kk"
+	English   = "en"
+)
+
+// Меньше букв — язык не определяется.
+const minLetters = 12
+
+const maxGram = 3
+
+//go:embed corpus/*.txt
+var corpus embed.FS
+
+// profile — частоты n-грамм (1–3 буквы, с границами слов) из обучающего текста.
+type profile struct {
+	lang   string
+	counts map[string]int
+	total  int
+}
+
+// alphabet — профили языков одного алфавита и размер их общего словаря n-грамм.
+// Сглаживание у всех профилей алфавита считается по общему словарю, иначе профиль
+// с меньшим словарём получает завышенную оценку неизвестных n-грамм.
+type alphabet struct {
+	profiles []profile
+	vocab    int
+}
+
+// Профили по алфавитам: русский в латинице (транслит) определяется как русский.
+var cyrillic, latin alphabet
+
+func init() {
+	for _, p := range []struct {
+		file, lang string
+		latin      bool
+	}{
+		{"ru.txt", Russian, false},
+		{"uk.txt", Ukrainian, false},
+		{"kk.txt", Kazakh, false},
+		{"en.txt", English, true},
+		{"ru-latn.txt", Russian, true},
+	} {
+		data, err := corpus.ReadFile("corpus/" + p.file)
+		if err != nil {
+			panic(err)
+		}
+		prof := profile{lang: p.lang, counts: make(map[string]int)}
+		grams(string(data), func(g string) {
+			prof.counts[g]++
+			prof.total++
+		})
+		if p.latin {
+			latin.profiles = append(latin.profiles, prof)
+		} else {
+			cyrillic.profiles = append(cyrillic.profiles, prof)
+		}
+	}
+	cyrillic.vocab = vocabulary(cyrillic.profiles)
+	latin.vocab = vocabulary(latin.profiles)
+}
+
+// vocabulary возвращает число разных n-грамм во всех профилях.
+func vocabulary(profiles []profile) int {
+	seen := make(map[string]struct{})
+	for _, p := range profiles {
+		for g := range p.counts {
+			seen[g] = struct{}{}
+		}
+	}
+	return len(seen)
+}
+
+// Detect определяет язык текста (ru, uk, kk, en) наивным байесовским классификатором
+// по n-граммам букв. Сначала выбирается алфавит, затем язык среди профилей этого
+// алфавита, поэтому русский транслит не считается английским. Пустая строка —
+// текст слишком короткий.
+func Detect(text string) string {
+	var cyr, lat int
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Cyrillic, r):
+			cyr++
+		case unicode.Is(unicode.Latin, r):
+			lat++
+		}
+	}
+	if cyr+lat < minLetters {
+		return ""
+	}
+	a := cyrillic
+	if lat > cyr {
+		a = latin
+	}
+	profiles := a.profiles
+
+	scores := make([]float64, len(profiles))
+	grams(text, func(g string) {
+		for i, p := range profiles {
+			scores[i] += math.Log(float64(p.counts[g]+1) / float64(p.total+a.vocab))
+		}
+	})
+	best := 0
+	for i := range scores {
+		if scores[i] > scores[best] {
+			best = i
+		}
+	}
+	return profiles[best].lang
+}
+
+// grams перебирает n-граммы слов текста; слово обрамляется пробелами, чтобы
+// учитывались начала и окончания.
+func grams(text string, fn func(string)) {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	})
+	for _, w := range words {
+		r := []rune(" " + strings.ReplaceAll(w, "ё", "е") + " ")
+		for n := 1; n <= maxGram; n++ {
+			for i := 0; i+n <= len(r); i++ {
+				if n == 1 && r[i] == ' ' {
+					continue
+				}
+				fn(string(r[i : i+n]))
+			}
+		}
+	}
+}
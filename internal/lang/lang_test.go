@@ -0,0 +1,44 @@
+package lang
+
+import "testing"
+
+func TestDetect(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		text string
+		want string
+	}{
+		{"russian", "Ищу разработчика на Go для интернет-магазина, оплата почасовая", Russian},
+		{"russian without yo", "Нужен дизайнер для логотипа, бюджет обсудим в личке", Russian},
+		{"russian short", "Ищу репетитора по химии", Russian},
+		{"ukrainian", "Шукаю розробника на Go для інтернет-магазину, оплата погодинна", Ukrainian},
+		{"ukrainian short", "Потрібен дизайнер логотипу", Ukrainian},
+		{"ukrainian without unique letters", "Шукаю дизайнера, бюджет обговоримо", Ukrainian},
+		{"kazakh", "Интернет-дүкенге Go бағдарламашысын іздеймін, төлем сағаттық", Kazakh},
+		{"kazakh short", "Жұмысқа адам керек, жазыңыз", Kazakh},
+		{"english", "Looking for a Go developer for an online store, hourly payment", English},
+		{"english short", "Need a logo designer", English},
+		{"russian translit", "ishchu razrabotchika na Go dlya internet-magazina, oplata pochasovaya", Russian},
+		{"russian translit short", "nuzhen dizayner logotipa", Russian},
+		{"too short", "Ищу дизайна", ""},
+		{"too short latin", "need a dev", ""},
+		{"no letters", "+7 999 123-45-67 🔥🔥🔥", ""},
+		{"empty", "", ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Detect(tc.text); got != tc.want {
+				t.Errorf("Detect(%q) = %q, want %q", tc.text, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestVocabularyShared(t *testing.T) {
+	for _, a := range []alphabet{cyrillic, latin} {
+		for _, p := range a.profiles {
+			if a.vocab < len(p.counts) {
+				t.Errorf("%s: vocabulary %d smaller than profile's own %d", p.lang, a.vocab, len(p.counts))
+			}
+		}
+	}
+}
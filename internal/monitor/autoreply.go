@@ -0,0 +1,343 @@
+package monitor
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"getclient/internal/notifier"
+	"getclient/internal/store"
+	"getclient/internal/telegramutil"
+
+	"github.com/gotd/td/tg"
+	"github.com/gotd/td/tgerr"
+	"go.uber.org/zap"
+)
+
+const (
+	ReplyDM     = "dm"
+	ReplyInChat = "reply"
+)
+
+const (
+	replySent   = "sent"
+	replyDryRun = "dry_run"
+	replyFailed = "failed"
+	replyDayFmt = "2006-01-02"
+
+	defaultReplyLimit    = 20
+	defaultReplyMinDelay = 30 * time.Second
+	defaultReplyMaxDelay = 2 * time.Minute
+)
+
+// ReplyRule — автоответ набора правил: шаблон текста и способ отправки.
+type ReplyRule struct {
+	Template notifier.Template
+	Mode     string
+}
+
+// ReplyLimits — общие ограничения автоответов. Задержка выбирается случайно
+// из [MinDelay, MaxDelay] и отсчитывается от предыдущей отправки аккаунта.
+type ReplyLimits struct {
+	Daily    int
+	MinDelay time.Duration
+	MaxDelay time.Duration
+	DryRun   bool
+}
+
+// ReplyEntry — запись журнала автоответов.
+type ReplyEntry struct {
+	Time      time.Time `json:"time"`
+	Account   string    `json:"account"`
+	RuleSet   string    `json:"rule_set"`
+	Mode      string    `json:"mode"`
+	Status    string    `json:"status"`
+	Chat      string    `json:"chat"`
+	ChatKey   string    `json:"chat_key"`
+	MessageID int       `json:"message_id"`
+	SenderID  int64     `json:"sender_id"`
+	Sender    string    `json:"sender,omitempty"`
+	Text      string    `json:"text"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// ReplyLog — журнал автоответов (JSON Lines), общий для всех аккаунтов. По нему же
+// считается суточный лимит, поэтому перезапуск лимит не сбрасывает.
+type ReplyLog struct {
+	path string
+
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+func OpenReplyLog(path string) (*ReplyLog, error) {
+	l := &ReplyLog{path: path, counts: make(map[string]int)}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return l, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	today := time.Now().Format(replyDayFmt)
+	for sc.Scan() {
+		var e ReplyEntry
+		if json.Unmarshal(sc.Bytes(), &e) != nil || e.Time.Local().Format(replyDayFmt) != today {
+			continue
+		}
+		l.counts[countKey(today, e.Account, e.Status)]++
+	}
+	return l, sc.Err()
+}
+
+func countKey(day, account, status string) string {
+	return day + "|" + account + "|" + status
+}
+
+// reserve занимает место в суточном лимите аккаунта. Пробные ответы считаются
+// отдельно от настоящих.
+func (l *ReplyLog) reserve(account, status string, limit int) bool {
+	key := countKey(time.Now().Format(replyDayFmt), account, status)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.counts[key] >= limit {
+		return false
+	}
+	l.counts[key]++
+	return true
+}
+
+func (l *ReplyLog) release(account, status string, day string) {
+	key := countKey(day, account, status)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.counts[key] > 0 {
+		l.counts[key]--
+	}
+}
+
+func (l *ReplyLog) write(e ReplyEntry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(l.path), 0o700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// AutoReplier отправляет автоответы от имени аккаунта: не больше Daily в сутки,
+// каждому отправителю один раз (см. store.SenderLimiter), со случайной задержкой.
+// После PEER_FLOOD автоответы аккаунта выключаются до конца суток.
+type AutoReplier struct {
+	api     *tg.Client
+	cache   *telegramutil.EntityCache
+	account string
+	limits  ReplyLimits
+	senders store.SenderLimiter
+	log     *ReplyLog
+	logger  *zap.Logger
+
+	mu           sync.Mutex
+	next         time.Time
+	blockedUntil time.Time
+}
+
+func NewAutoReplier(api *tg.Client, cache *telegramutil.EntityCache, account string, limits ReplyLimits, senders store.SenderLimiter, log *ReplyLog, logger *zap.Logger) *AutoReplier {
+	if limits.Daily <= 0 {
+		limits.Daily = defaultReplyLimit
+	}
+	if limits.MinDelay == 0 && limits.MaxDelay == 0 {
+		limits.MinDelay, limits.MaxDelay = defaultReplyMinDelay, defaultReplyMaxDelay
+	}
+	if limits.MaxDelay < limits.MinDelay {
+		limits.MaxDelay = limits.MinDelay
+	}
+	return &AutoReplier{
+		api:     api,
+		cache:   cache,
+		account: account,
+		limits:  limits,
+		senders: senders,
+		log:     log,
+		logger:  logger,
+	}
+}
+
+// Schedule проверяет лимиты и ставит ответ на отправку; сама отправка идёт в фоне.
+func (a *AutoReplier) Schedule(ctx context.Context, rule ReplyRule, n notifier.Notification, chat tg.PeerClass, sender telegramutil.SenderInfo) {
+	if rule.Template == nil || sender.ID == 0 || sender.Bot || sender.IsChannel {
+		return
+	}
+	text, err := notifier.Render(rule.Template, n)
+	if err != nil {
+		a.logger.Warn("Auto-reply template failed", zap.String("rule_set", n.RuleSet), zap.Error(err))
+		return
+	}
+	if text == "" {
+		return
+	}
+
+	status := replySent
+	if a.limits.DryRun {
+		status = replyDryRun
+	}
+	day := time.Now().Format(replyDayFmt)
+	if a.blocked() || !a.log.reserve(a.account, status, a.limits.Daily) {
+		return
+	}
+	// Один отправитель получает автоответ один раз, от какого бы аккаунта тот ни пришёл.
+	// Пробные ответы учитываются отдельно, чтобы не блокировать настоящие.
+	ok, err := a.senders.Allow(ctx, status, sender.ID)
+	if err != nil || !ok {
+		a.log.release(a.account, status, day)
+		if err != nil {
+			a.logger.Warn("Limiter failed", zap.Error(err))
+		}
+		return
+	}
+
+	entry := ReplyEntry{
+		Account:   a.account,
+		RuleSet:   n.RuleSet,
+		Mode:      rule.Mode,
+		Status:    status,
+		Chat:      n.ChatTitle,
+		ChatKey:   n.ChatKey,
+		MessageID: n.MessageID,
+		SenderID:  sender.ID,
+		Sender:    senderDisplayName(sender),
+		Text:      text,
+	}
+	delay := a.delay()
+	go func() {
+		t := time.NewTimer(delay)
+		defer t.Stop()
+		select {
+		case <-ctx.Done():
+			a.release(ctx, status, day, sender.ID)
+			return
+		case <-t.C:
+		}
+		if !a.limits.DryRun {
+			if err := a.send(ctx, rule.Mode, chat, n.MessageID, sender, text); err != nil {
+				entry.Status, entry.Error = replyFailed, err.Error()
+				a.release(ctx, status, day, sender.ID)
+				a.logger.Warn("Auto-reply failed", zap.String("account", a.account), zap.Int64("sender", sender.ID), zap.Error(err))
+			}
+		}
+		entry.Time = time.Now()
+		if err := a.log.write(entry); err != nil {
+			a.logger.Warn("Auto-reply log failed", zap.Error(err))
+		}
+		if entry.Status != replyFailed {
+			a.logger.Info("Автоответ", zap.String("account", a.account), zap.String("to", entry.Sender), zap.String("mode", rule.Mode), zap.Bool("dry_run", a.limits.DryRun))
+		}
+	}()
+}
+
+// release возвращает место в суточном лимите и снимает отметку об отправителе,
+// чтобы неотправленный ответ не мешал ответить ему позже.
+func (a *AutoReplier) release(ctx context.Context, status, day string, senderID int64) {
+	a.log.release(a.account, status, day)
+	if err := a.senders.Forget(context.WithoutCancel(ctx), status, senderID); err != nil {
+		a.logger.Warn("Limiter failed", zap.Error(err))
+	}
+}
+
+// delay возвращает задержку до отправки так, чтобы между ответами аккаунта
+// проходило не меньше MinDelay.
+func (a *AutoReplier) delay() time.Duration {
+	d := a.limits.MinDelay
+	if spread := a.limits.MaxDelay - a.limits.MinDelay; spread > 0 {
+		d += time.Duration(rand.Int63n(int64(spread)))
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	at := time.Now().Add(d)
+	if earliest := a.next.Add(a.limits.MinDelay); at.Before(earliest) {
+		at = earliest
+	}
+	a.next = at
+	return time.Until(at)
+}
+
+func (a *AutoReplier) blocked() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return time.Now().Before(a.blockedUntil)
+}
+
+func (a *AutoReplier) block(until time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if until.After(a.blockedUntil) {
+		a.blockedUntil = until
+	}
+}
+
+func (a *AutoReplier) send(ctx context.Context, mode string, chat tg.PeerClass, msgID int, sender telegramutil.SenderInfo, text string) error {
+	req := &tg.MessagesSendMessageRequest{Message: text, RandomID: randomID(), NoWebpage: true}
+	if mode == ReplyInChat {
+		peer, ok := a.cache.InputPeer(chat)
+		if !ok {
+			return errNoAccessHash
+		}
+		req.Peer = peer
+		req.SetReplyTo(&tg.InputReplyToMessage{ReplyToMsgID: msgID})
+	} else {
+		peer, err := a.userPeer(ctx, sender)
+		if err != nil {
+			return err
+		}
+		req.Peer = peer
+	}
+	_, err := a.api.MessagesSendMessage(ctx, req)
+	if d, ok := tgerr.AsFloodWait(err); ok {
+		a.block(time.Now().Add(d))
+	} else if tgerr.Is(err, "PEER_FLOOD") {
+		y, m, dd := time.Now().Date()
+		a.block(time.Date(y, m, dd+1, 0, 0, 0, 0, time.Local))
+		a.logger.Warn("Telegram ограничил отправку сообщений, автоответы выключены до конца суток", zap.String("account", a.account))
+	}
+	return err
+}
+
+func (a *AutoReplier) userPeer(ctx context.Context, sender telegramutil.SenderInfo) (tg.InputPeerClass, error) {
+	if peer, ok := a.cache.InputPeer(&tg.PeerUser{UserID: sender.ID}); ok {
+		return peer, nil
+	}
+	if sender.Username == "" {
+		return nil, errNoAccessHash
+	}
+	res, err := a.api.ContactsResolveUsername(ctx, sender.Username)
+	if err != nil {
+		return nil, fmt.Errorf("resolve @%s: %w", sender.Username, err)
+	}
+	a.cache.AddUsersChats(res.Users, res.Chats)
+	peer, ok := a.cache.InputPeer(res.Peer)
+	if !ok {
+		return nil, errNoAccessHash
+	}
+	return peer, nil
+}
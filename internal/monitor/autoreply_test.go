@@ -0,0 +1,124 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"getclient/internal/notifier"
+	"getclient/internal/store"
+	"getclient/internal/telegramutil"
+
+	"github.com/gotd/td/bin"
+	"github.com/gotd/td/tg"
+	"go.uber.org/zap"
+)
+
+type invokerFunc func(ctx context.Context, input bin.Encoder, output bin.Decoder) error
+
+func (f invokerFunc) Invoke(ctx context.Context, input bin.Encoder, output bin.Decoder) error {
+	return f(ctx, input, output)
+}
+
+func newTestReplier(t *testing.T, sendErr error) (*AutoReplier, *store.BaseDB, *ReplyLog) {
+	dir := t.TempDir()
+	senders, err := store.OpenBaseDBTTL(filepath.Join(dir, "replied.json"), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	log, err := OpenReplyLog(filepath.Join(dir, "autoreply.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	api := tg.NewClient(invokerFunc(func(ctx context.Context, input bin.Encoder, output bin.Decoder) error {
+		return sendErr
+	}))
+	cache := telegramutil.NewEntityCache()
+	cache.AddUsersChats([]tg.UserClass{&tg.User{ID: 777, AccessHash: 1}}, nil)
+	limits := ReplyLimits{Daily: 5, MinDelay: time.Millisecond, MaxDelay: time.Millisecond}
+	return NewAutoReplier(api, cache, "acc", limits, senders, log, zap.NewNop()), senders, log
+}
+
+// waitLog ждёт, пока фоновая отправка допишет журнал.
+func waitLog(t *testing.T, l *ReplyLog) {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if st, err := os.Stat(l.path); err == nil && st.Size() > 0 {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("auto-reply log was not written")
+}
+
+func TestAutoReplySenderRecorded(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		sendErr  error
+		recorded bool
+		count    int
+	}{
+		{"sent", nil, true, 1},
+		{"send failed", errors.New("PEER_FLOOD"), false, 0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			a, senders, log := newTestReplier(t, tc.sendErr)
+			tmpl, err := notifier.ParseTemplate("reply", "Здравствуйте!", false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctx := context.Background()
+			a.Schedule(ctx, ReplyRule{Template: tmpl, Mode: ReplyDM}, notifier.Notification{}, &tg.PeerUser{UserID: 1}, telegramutil.SenderInfo{ID: 777})
+			waitLog(t, log)
+
+			ok, err := senders.Allow(ctx, replySent, 777)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok == tc.recorded {
+				t.Errorf("sender recorded = %v, want %v", !ok, tc.recorded)
+			}
+			log.mu.Lock()
+			count := log.counts[countKey(time.Now().Format(replyDayFmt), "acc", replySent)]
+			log.mu.Unlock()
+			if count != tc.count {
+				t.Errorf("daily count = %d, want %d", count, tc.count)
+			}
+		})
+	}
+}
+
+func TestAutoReplyCanceled(t *testing.T) {
+	a, senders, log := newTestReplier(t, nil)
+	a.limits.MinDelay, a.limits.MaxDelay = time.Hour, time.Hour
+	tmpl, err := notifier.ParseTemplate("reply", "Здравствуйте!", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	a.Schedule(ctx, ReplyRule{Template: tmpl, Mode: ReplyDM}, notifier.Notification{}, &tg.PeerUser{UserID: 1}, telegramutil.SenderInfo{ID: 777})
+	cancel()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		ok, err := senders.Allow(context.Background(), replySent, 777)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("sender is still recorded after cancel")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	log.mu.Lock()
+	defer log.mu.Unlock()
+	if count := log.counts[countKey(time.Now().Format(replyDayFmt), "acc", replySent)]; count != 0 {
+		t.Errorf("daily count = %d, want 0", count)
+	}
+}
@@ -0,0 +1,253 @@
+package monitor
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"getclient/internal/telegramutil"
+
+	"github.com/gotd/td/tg"
+	"github.com/gotd/td/tgerr"
+)
+
+const (
+	ForumOnly    = "only"
+	ForumExclude = "exclude"
+)
+
+const (
+	chatInfoTTL         = 24 * time.Hour
+	chatInfoRetry       = 10 * time.Minute
+	chatInfoMinInterval = time.Second
+	// Преобладающий язык определяется после стольких сообщений с известным языком.
+	minLangSamples = 5
+	maxLangSamples = 200
+)
+
+// ChatFilter — условия на чат: число участников, публичность, форум, возраст и язык.
+// Неизвестное значение (Telegram не отдал число участников, язык ещё не определён)
+// условие не нарушает.
+type ChatFilter struct {
+	MinMembers int
+	PublicOnly bool
+	Forum      string
+	MinAge     time.Duration
+	Languages  []string
+}
+
+func (f ChatFilter) Empty() bool {
+	return f.MinMembers == 0 && !f.PublicOnly && f.Forum == "" && f.MinAge == 0 && len(f.Languages) == 0
+}
+
+func (f ChatFilter) Allows(c telegramutil.ChatInfo) bool {
+	if f.MinMembers > 0 && c.Members > 0 && c.Members < f.MinMembers {
+		return false
+	}
+	if f.PublicOnly && c.Username == "" {
+		return false
+	}
+	switch f.Forum {
+	case ForumOnly:
+		if !c.Forum {
+			return false
+		}
+	case ForumExclude:
+		if c.Forum {
+			return false
+		}
+	}
+	if f.MinAge > 0 && !c.Created.IsZero() && time.Since(c.Created) < f.MinAge {
+		return false
+	}
+	if len(f.Languages) > 0 && c.Language != "" && !slices.Contains(f.Languages, c.Language) {
+		return false
+	}
+	return true
+}
+
+type chatFull struct {
+	members int
+	created time.Time
+	expires time.Time
+}
+
+// ChatInfoFetcher дополняет сведения о чате из апдейтов: число участников из
+// channels.getFullChannel, дату создания по первому сообщению (всё кэшируется
+// на сутки) и преобладающий язык сообщений.
+type ChatInfoFetcher struct {
+	api   *tg.Client
+	cache *telegramutil.EntityCache
+
+	mu         sync.Mutex
+	full       map[string]chatFull
+	langs      map[string]map[string]int
+	next       time.Time
+	floodUntil time.Time
+}
+
+func NewChatInfoFetcher(api *tg.Client, cache *telegramutil.EntityCache) *ChatInfoFetcher {
+	return &ChatInfoFetcher{
+		api:   api,
+		cache: cache,
+		full:  make(map[string]chatFull),
+		langs: make(map[string]map[string]int),
+	}
+}
+
+// Observe учитывает язык сообщения (см. lang.Detect) в статистике чата.
+func (f *ChatInfoFetcher) Observe(key, l string) {
+	if l == "" {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	counts := f.langs[key]
+	if counts == nil {
+		counts = make(map[string]int)
+		f.langs[key] = counts
+	}
+	counts[l]++
+	total := 0
+	for _, n := range counts {
+		total += n
+	}
+	// Старые сообщения постепенно теряют вес, чтобы язык чата мог смениться.
+	if total > maxLangSamples {
+		for k, n := range counts {
+			counts[k] = n / 2
+		}
+	}
+}
+
+// Complete заполняет язык и, если fetch, число участников и дату создания. Запрос
+// к API не ждёт очереди: если лимит запросов исчерпан, они останутся неизвестными.
+func (f *ChatInfoFetcher) Complete(ctx context.Context, peer tg.PeerClass, info telegramutil.ChatInfo, fetch bool) telegramutil.ChatInfo {
+	if f == nil {
+		return info
+	}
+	info.Language = f.language(info.Key)
+	if !fetch || info.Members > 0 && !info.Created.IsZero() {
+		return info
+	}
+	p, ok := peer.(*tg.PeerChannel)
+	if !ok {
+		return info
+	}
+
+	f.mu.Lock()
+	cached, ok := f.full[info.Key]
+	f.mu.Unlock()
+	if !ok || !time.Now().Before(cached.expires) {
+		if !f.acquire() {
+			return info
+		}
+		cached = f.fetch(ctx, p.ChannelID)
+		f.mu.Lock()
+		f.full[info.Key] = cached
+		f.mu.Unlock()
+	}
+	if info.Members == 0 {
+		info.Members = cached.members
+	}
+	if info.Created.IsZero() {
+		info.Created = cached.created
+	}
+	return info
+}
+
+func (f *ChatInfoFetcher) fetch(ctx context.Context, channelID int64) chatFull {
+	entry := chatFull{expires: time.Now().Add(chatInfoTTL)}
+	ch, ok := f.cache.InputChannel(channelID)
+	if !ok {
+		entry.expires = time.Now().Add(chatInfoRetry)
+		return entry
+	}
+	var err error
+	if entry.members, err = f.fetchMembers(ctx, ch); err == nil {
+		entry.created, err = f.fetchCreated(ctx, ch)
+	}
+	if err != nil {
+		f.flood(err)
+		entry.expires = time.Now().Add(chatInfoRetry)
+	}
+	return entry
+}
+
+func (f *ChatInfoFetcher) language(key string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	best, total, top := "", 0, 0
+	for l, n := range f.langs[key] {
+		total += n
+		if n > top || n == top && l < best {
+			best, top = l, n
+		}
+	}
+	if total < minLangSamples {
+		return ""
+	}
+	return best
+}
+
+func (f *ChatInfoFetcher) fetchMembers(ctx context.Context, ch *tg.InputChannel) (int, error) {
+	resp, err := f.api.ChannelsGetFullChannel(ctx, ch)
+	if err != nil {
+		return 0, err
+	}
+	f.cache.AddUsersChats(resp.Users, resp.Chats)
+	if full, ok := resp.FullChat.(*tg.ChannelFull); ok {
+		if n, ok := full.GetParticipantsCount(); ok {
+			return n, nil
+		}
+	}
+	return 0, nil
+}
+
+// fetchCreated возвращает дату первого сообщения супергруппы — сервисного «группа
+// создана» или переноса из обычной группы. В апдейтах у супергруппы дата вступления
+// аккаунта, а не создания. Если первое сообщение удалено или история скрыта от новых
+// участников, дата неизвестна.
+func (f *ChatInfoFetcher) fetchCreated(ctx context.Context, ch *tg.InputChannel) (time.Time, error) {
+	resp, err := f.api.ChannelsGetMessages(ctx, &tg.ChannelsGetMessagesRequest{
+		Channel: ch,
+		ID:      []tg.InputMessageClass{&tg.InputMessageID{ID: 1}},
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+	msgs, ok := resp.AsModified()
+	if !ok {
+		return time.Time{}, nil
+	}
+	for _, msg := range msgs.GetMessages() {
+		switch msg := msg.(type) {
+		case *tg.MessageService:
+			return time.Unix(int64(msg.Date), 0), nil
+		case *tg.Message:
+			return time.Unix(int64(msg.Date), 0), nil
+		}
+	}
+	return time.Time{}, nil
+}
+
+func (f *ChatInfoFetcher) flood(err error) {
+	if d, ok := tgerr.AsFloodWait(err); ok {
+		f.mu.Lock()
+		f.floodUntil = time.Now().Add(d)
+		f.mu.Unlock()
+	}
+}
+
+// acquire разрешает не больше одного запроса в chatInfoMinInterval и ни одного во время FLOOD_WAIT.
+func (f *ChatInfoFetcher) acquire() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	now := time.Now()
+	if now.Before(f.floodUntil) || now.Before(f.next) {
+		return false
+	}
+	f.next = now.Add(chatInfoMinInterval)
+	return true
+}
@@ -0,0 +1,145 @@
+package monitor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"getclient/internal/telegramutil"
+
+	"github.com/gotd/td/bin"
+	"github.com/gotd/td/tg"
+	"github.com/gotd/td/tgerr"
+)
+
+func TestChatFilterAllows(t *testing.T) {
+	old := time.Now().Add(-90 * 24 * time.Hour)
+	fresh := time.Now().Add(-2 * 24 * time.Hour)
+	for _, tc := range []struct {
+		name   string
+		filter ChatFilter
+		chat   telegramutil.ChatInfo
+		want   bool
+	}{
+		{"empty filter", ChatFilter{}, telegramutil.ChatInfo{}, true},
+		{"enough members", ChatFilter{MinMembers: 100}, telegramutil.ChatInfo{Members: 150}, true},
+		{"few members", ChatFilter{MinMembers: 100}, telegramutil.ChatInfo{Members: 50}, false},
+		{"members unknown", ChatFilter{MinMembers: 100}, telegramutil.ChatInfo{}, true},
+		{"public", ChatFilter{PublicOnly: true}, telegramutil.ChatInfo{Username: "jobs"}, true},
+		{"private", ChatFilter{PublicOnly: true}, telegramutil.ChatInfo{}, false},
+		{"forum only", ChatFilter{Forum: ForumOnly}, telegramutil.ChatInfo{Forum: true}, true},
+		{"forum only, plain group", ChatFilter{Forum: ForumOnly}, telegramutil.ChatInfo{}, false},
+		{"forum excluded", ChatFilter{Forum: ForumExclude}, telegramutil.ChatInfo{Forum: true}, false},
+		{"old chat", ChatFilter{MinAge: 30 * 24 * time.Hour}, telegramutil.ChatInfo{Created: old}, true},
+		{"new chat", ChatFilter{MinAge: 30 * 24 * time.Hour}, telegramutil.ChatInfo{Created: fresh}, false},
+		{"age unknown", ChatFilter{MinAge: 30 * 24 * time.Hour}, telegramutil.ChatInfo{}, true},
+		{"language", ChatFilter{Languages: []string{"ru", "uk"}}, telegramutil.ChatInfo{Language: "uk"}, true},
+		{"other language", ChatFilter{Languages: []string{"ru"}}, telegramutil.ChatInfo{Language: "en"}, false},
+		{"language unknown", ChatFilter{Languages: []string{"ru"}}, telegramutil.ChatInfo{}, true},
+		{
+			"all conditions",
+			ChatFilter{MinMembers: 100, PublicOnly: true, Forum: ForumExclude, MinAge: time.Hour, Languages: []string{"ru"}},
+			telegramutil.ChatInfo{Members: 500, Username: "jobs", Created: old, Language: "ru"},
+			true,
+		},
+	} {
+		if got := tc.filter.Allows(tc.chat); got != tc.want {
+			t.Errorf("%s: Allows = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+// fakeChannelInfo отвечает на channels.getFullChannel и channels.getMessages.
+type fakeChannelInfo struct {
+	members int
+	created int // дата первого сообщения; 0 — сообщение недоступно
+	err     error
+	full    int
+	first   int
+}
+
+func (f *fakeChannelInfo) Invoke(ctx context.Context, input bin.Encoder, output bin.Decoder) error {
+	if f.err != nil {
+		return f.err
+	}
+	switch req := input.(type) {
+	case *tg.ChannelsGetFullChannelRequest:
+		f.full++
+		full := &tg.ChannelFull{}
+		full.SetParticipantsCount(f.members)
+		output.(*tg.MessagesChatFull).FullChat = full
+	case *tg.ChannelsGetMessagesRequest:
+		f.first++
+		if id := req.ID[0].(*tg.InputMessageID).ID; id != 1 {
+			return tgerr.New(400, "MESSAGE_ID_INVALID")
+		}
+		var msg tg.MessageClass = &tg.MessageEmpty{ID: 1}
+		if f.created != 0 {
+			msg = &tg.MessageService{ID: 1, Date: f.created, Action: &tg.MessageActionChannelCreate{Title: "jobs"}}
+		}
+		output.(*tg.MessagesMessagesBox).Messages = &tg.MessagesChannelMessages{Messages: []tg.MessageClass{msg}}
+	}
+	return nil
+}
+
+func TestChatInfoFetcher(t *testing.T) {
+	created := time.Now().Add(-400 * 24 * time.Hour).Truncate(time.Second)
+	joined := time.Now().Add(-time.Hour)
+	channel := &tg.Channel{ID: 100, AccessHash: 1, Megagroup: true, Date: int(joined.Unix())}
+	e := tg.Entities{Channels: map[int64]*tg.Channel{100: channel}}
+	peer := &tg.PeerChannel{ChannelID: 100}
+
+	for _, tc := range []struct {
+		name    string
+		fake    *fakeChannelInfo
+		fetch   bool
+		members int
+		created time.Time
+		calls   int
+	}{
+		{"fetched", &fakeChannelInfo{members: 1200, created: int(created.Unix())}, true, 1200, created, 2},
+		{"first message hidden", &fakeChannelInfo{members: 1200}, true, 1200, time.Time{}, 2},
+		{"not needed", &fakeChannelInfo{members: 1200}, false, 0, time.Time{}, 0},
+		{"api error", &fakeChannelInfo{err: tgerr.New(400, "CHANNEL_PRIVATE")}, true, 0, time.Time{}, 0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cache := telegramutil.NewEntityCache()
+			cache.Add(e)
+			f := NewChatInfoFetcher(tg.NewClient(tc.fake), cache)
+			for i := 0; i < minLangSamples; i++ {
+				f.Observe(telegramutil.PeerKey(peer), "ru")
+			}
+
+			// Дата вступления из апдейта не выдаётся за дату создания.
+			info := telegramutil.Chat(peer, e)
+			if !info.Created.IsZero() {
+				t.Fatalf("Chat().Created = %v, want unknown for a joined supergroup", info.Created)
+			}
+			for i := 0; i < 2; i++ {
+				got := f.Complete(context.Background(), peer, info, tc.fetch)
+				if got.Members != tc.members || !got.Created.Equal(tc.created) || got.Language != "ru" {
+					t.Errorf("Complete #%d = members %d, created %v, language %q; want %d, %v, ru", i, got.Members, got.Created, got.Language, tc.members, tc.created)
+				}
+			}
+			// Второй вызов берёт данные из кэша.
+			if calls := tc.fake.full + tc.fake.first; calls != tc.calls {
+				t.Errorf("requests = %d, want %d", calls, tc.calls)
+			}
+		})
+	}
+}
+
+func TestChatInfoFetcherBasicGroup(t *testing.T) {
+	created := time.Now().Add(-10 * 24 * time.Hour).Truncate(time.Second)
+	peer := &tg.PeerChat{ChatID: 5}
+	e := tg.Entities{Chats: map[int64]*tg.Chat{5: {ID: 5, ParticipantsCount: 40, Date: int(created.Unix())}}}
+	fake := &fakeChannelInfo{}
+	f := NewChatInfoFetcher(tg.NewClient(fake), telegramutil.NewEntityCache())
+	got := f.Complete(context.Background(), peer, telegramutil.Chat(peer, e), true)
+	if got.Members != 40 || !got.Created.Equal(created) {
+		t.Errorf("Complete = members %d, created %v; want 40, %v", got.Members, got.Created, created)
+	}
+	if fake.full+fake.first != 0 {
+		t.Errorf("basic group made %d requests", fake.full+fake.first)
+	}
+}
@@ -0,0 +1,164 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"getclient/internal/notifier"
+	"getclient/internal/telegramutil"
+
+	"github.com/gotd/td/tg"
+	"github.com/gotd/td/tgerr"
+)
+
+const contextMinInterval = 700 * time.Millisecond
+
+var errNoAccessHash = errors.New("no access hash for peer")
+
+type ContextFetcher struct {
+	api     *tg.Client
+	cache   *telegramutil.EntityCache
+	replies bool
+	history int
+
+	mu         sync.Mutex
+	next       time.Time
+	floodUntil time.Time
+}
+
+func NewContextFetcher(api *tg.Client, cache *telegramutil.EntityCache, replies bool, history int) *ContextFetcher {
+	return &ContextFetcher{api: api, cache: cache, replies: replies, history: history}
+}
+
+func (f *ContextFetcher) Enabled() bool {
+	return f != nil && (f.replies || f.history > 0)
+}
+
+func (f *ContextFetcher) Fetch(ctx context.Context, peer tg.PeerClass, msg *tg.Message) (*notifier.Quote, []notifier.Quote) {
+	if !f.Enabled() || msg == nil {
+		return nil, nil
+	}
+
+	var reply *notifier.Quote
+	replyHere := false
+	if f.replies {
+		if h, ok := msg.ReplyTo.(*tg.MessageReplyHeader); ok {
+			if id, ok := h.GetReplyToMsgID(); ok {
+				// Ответ на сообщение другого чата или комментарий к посту канала:
+				// сообщение берётся оттуда, а без доступа к тому чату цитаты нет.
+				from := peer
+				if p, ok := h.GetReplyToPeerID(); ok {
+					from = p
+				}
+				replyHere = telegramutil.PeerKey(from) == telegramutil.PeerKey(peer)
+				if msgs := f.getMessages(ctx, from, id); len(msgs) > 0 {
+					q := f.quote(msgs[0])
+					reply = &q
+				}
+			}
+		}
+	}
+
+	var history []notifier.Quote
+	if f.history > 0 {
+		msgs := f.getHistory(ctx, peer, msg.ID, f.history)
+		for i := len(msgs) - 1; i >= 0; i-- {
+			if reply != nil && replyHere && msgs[i].ID == reply.MessageID {
+				continue
+			}
+			history = append(history, f.quote(msgs[i]))
+		}
+	}
+	return reply, history
+}
+
+func (f *ContextFetcher) getMessages(ctx context.Context, peer tg.PeerClass, id int) []*tg.Message {
+	ids := []tg.InputMessageClass{&tg.InputMessageID{ID: id}}
+	return f.request(ctx, func() (tg.MessagesMessagesClass, error) {
+		if p, ok := peer.(*tg.PeerChannel); ok {
+			ch, ok := f.cache.InputChannel(p.ChannelID)
+			if !ok {
+				return nil, errNoAccessHash
+			}
+			return f.api.ChannelsGetMessages(ctx, &tg.ChannelsGetMessagesRequest{Channel: ch, ID: ids})
+		}
+		return f.api.MessagesGetMessages(ctx, ids)
+	})
+}
+
+func (f *ContextFetcher) getHistory(ctx context.Context, peer tg.PeerClass, offsetID, limit int) []*tg.Message {
+	return f.request(ctx, func() (tg.MessagesMessagesClass, error) {
+		input, ok := f.cache.InputPeer(peer)
+		if !ok {
+			return nil, errNoAccessHash
+		}
+		return f.api.MessagesGetHistory(ctx, &tg.MessagesGetHistoryRequest{
+			Peer:     input,
+			OffsetID: offsetID,
+			Limit:    limit,
+		})
+	})
+}
+
+func (f *ContextFetcher) request(ctx context.Context, call func() (tg.MessagesMessagesClass, error)) []*tg.Message {
+	if !f.wait(ctx) {
+		return nil
+	}
+	resp, err := call()
+	if err != nil {
+		if d, ok := tgerr.AsFloodWait(err); ok {
+			f.mu.Lock()
+			f.floodUntil = time.Now().Add(d)
+			f.mu.Unlock()
+		}
+		return nil
+	}
+	mod, ok := resp.AsModified()
+	if !ok {
+		return nil
+	}
+	f.cache.AddUsersChats(mod.GetUsers(), mod.GetChats())
+
+	var out []*tg.Message
+	for _, m := range mod.GetMessages() {
+		if msg, ok := m.(*tg.Message); ok {
+			out = append(out, msg)
+		}
+	}
+	return out
+}
+
+// wait выдерживает паузу между запросами и возвращает false, пока аккаунт в
+// FLOOD_WAIT: тогда алерт уходит без контекста, а не задерживается.
+func (f *ContextFetcher) wait(ctx context.Context) bool {
+	f.mu.Lock()
+	now := time.Now()
+	if now.Before(f.floodUntil) {
+		f.mu.Unlock()
+		return false
+	}
+	at := f.next
+	if at.Before(now) {
+		at = now
+	}
+	f.next = at.Add(contextMinInterval)
+	f.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(time.Until(at)):
+		return true
+	}
+}
+
+func (f *ContextFetcher) quote(msg *tg.Message) notifier.Quote {
+	q := notifier.Quote{MessageID: msg.ID, Text: msg.Message}
+	if msg.FromID != nil {
+		e := f.cache.Complete(tg.Entities{}, msg.FromID)
+		q.From = senderDisplayName(telegramutil.Sender(msg.FromID, e))
+	}
+	return q
+}
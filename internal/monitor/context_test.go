@@ -0,0 +1,78 @@
+package monitor
+
+import (
+	"context"
+	"testing"
+
+	"getclient/internal/telegramutil"
+
+	"github.com/gotd/td/bin"
+	"github.com/gotd/td/tg"
+)
+
+// fakeMessages отвечает на запросы сообщений текстом, в котором указан канал.
+type fakeMessages struct {
+	channels []int64
+	plain    int
+}
+
+func (f *fakeMessages) Invoke(ctx context.Context, input bin.Encoder, output bin.Decoder) error {
+	text := "plain"
+	switch req := input.(type) {
+	case *tg.ChannelsGetMessagesRequest:
+		ch := req.Channel.(*tg.InputChannel)
+		f.channels = append(f.channels, ch.ChannelID)
+		text = "channel"
+	case *tg.MessagesGetMessagesRequest:
+		f.plain++
+	}
+	out := output.(*tg.MessagesMessagesBox)
+	out.Messages = &tg.MessagesMessages{Messages: []tg.MessageClass{&tg.Message{ID: 7, Message: text}}}
+	return nil
+}
+
+func TestContextFetchReplyPeer(t *testing.T) {
+	here := &tg.PeerChannel{ChannelID: 100}
+	for _, tc := range []struct {
+		name     string
+		header   *tg.MessageReplyHeader
+		want     string // текст цитаты, пусто — без цитаты
+		channels []int64
+	}{
+		{"same chat", replyHeader(7, nil), "channel", []int64{100}},
+		{"same chat explicit peer", replyHeader(7, here), "channel", []int64{100}},
+		{"other known channel", replyHeader(7, &tg.PeerChannel{ChannelID: 200}), "channel", []int64{200}},
+		{"unknown channel", replyHeader(7, &tg.PeerChannel{ChannelID: 300}), "", nil},
+		{"basic chat", replyHeader(7, &tg.PeerChat{ChatID: 5}), "plain", nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fake := &fakeMessages{}
+			cache := telegramutil.NewEntityCache()
+			cache.AddUsersChats(nil, []tg.ChatClass{
+				&tg.Channel{ID: 100, AccessHash: 1, Megagroup: true},
+				&tg.Channel{ID: 200, AccessHash: 2},
+			})
+			f := NewContextFetcher(tg.NewClient(fake), cache, true, 0)
+			reply, _ := f.Fetch(context.Background(), here, &tg.Message{ID: 10, ReplyTo: tc.header})
+			got := ""
+			if reply != nil {
+				got = reply.Text
+			}
+			if got != tc.want {
+				t.Errorf("reply = %q, want %q", got, tc.want)
+			}
+			if len(fake.channels) != len(tc.channels) || len(tc.channels) > 0 && fake.channels[0] != tc.channels[0] {
+				t.Errorf("channels.getMessages = %v, want %v", fake.channels, tc.channels)
+			}
+		})
+	}
+}
+
+func replyHeader(id int, peer tg.PeerClass) *tg.MessageReplyHeader {
+	h := &tg.MessageReplyHeader{}
+	h.SetReplyToMsgID(id)
+	if peer != nil {
+		h.SetReplyToPeerID(peer)
+	}
+	return h
+}
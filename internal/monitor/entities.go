@@ -0,0 +1,171 @@
+package monitor
+
+import (
+	"net/url"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/gotd/td/tg"
+)
+
+const PartEntities = "entities"
+
+// MessageEntities — ссылки, хэштеги, упоминания и кэштеги сообщения,
+// включая скрытые адреса TextURL, кнопок и превью.
+type MessageEntities struct {
+	URLs     []string
+	Domains  []string
+	Hashtags []string
+	Mentions []string
+	Cashtags []string
+}
+
+func (m MessageEntities) Empty() bool {
+	return len(m.URLs) == 0 && len(m.Hashtags) == 0 && len(m.Mentions) == 0 && len(m.Cashtags) == 0
+}
+
+// ExtractEntities разбирает сущности текста. Смещения в Telegram задаются в UTF-16.
+func ExtractEntities(text string, entities []tg.MessageEntityClass) MessageEntities {
+	var out MessageEntities
+	if len(entities) == 0 {
+		return out
+	}
+	units := utf16.Encode([]rune(text))
+	slice := func(offset, length int) string {
+		if offset < 0 || length <= 0 || offset+length > len(units) {
+			return ""
+		}
+		return string(utf16.Decode(units[offset : offset+length]))
+	}
+	for _, ent := range entities {
+		switch v := ent.(type) {
+		case *tg.MessageEntityURL:
+			out.addURL(slice(v.Offset, v.Length))
+		case *tg.MessageEntityTextURL:
+			out.addURL(v.URL)
+		case *tg.MessageEntityHashtag:
+			out.Hashtags = appendTag(out.Hashtags, slice(v.Offset, v.Length), "#")
+		case *tg.MessageEntityMention:
+			out.Mentions = appendTag(out.Mentions, slice(v.Offset, v.Length), "@")
+		case *tg.MessageEntityCashtag:
+			out.Cashtags = appendTag(out.Cashtags, slice(v.Offset, v.Length), "$")
+		}
+	}
+	return out
+}
+
+func messageEntities(msg *tg.Message) MessageEntities {
+	out := ExtractEntities(msg.Message, msg.Entities)
+	if media, ok := msg.Media.(*tg.MessageMediaWebPage); ok {
+		if page, ok := media.Webpage.(*tg.WebPage); ok {
+			out.addURL(page.URL)
+		}
+	}
+	if markup, ok := msg.ReplyMarkup.(*tg.ReplyInlineMarkup); ok {
+		for _, row := range markup.Rows {
+			for _, b := range row.Buttons {
+				if link, ok := b.(*tg.KeyboardButtonURL); ok {
+					out.addURL(link.URL)
+				}
+			}
+		}
+	}
+	return out
+}
+
+func (m *MessageEntities) addURL(raw string) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return
+	}
+	m.URLs = append(m.URLs, raw)
+	if host := urlHost(raw); host != "" {
+		m.Domains = append(m.Domains, host)
+	}
+}
+
+func urlHost(raw string) string {
+	if !strings.Contains(raw, "://") {
+		raw = "http://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+}
+
+func appendTag(list []string, s, prefix string) []string {
+	s = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(s), prefix))
+	if s == "" {
+		return list
+	}
+	return append(list, s)
+}
+
+type entityRule struct {
+	kind  string
+	value string
+	line  string
+}
+
+// parseEntityRule распознаёт строки вида domain:site.ru, url:/jobs, hashtag:вакансия,
+// mention:@channel и cashtag:$BTC.
+func parseEntityRule(line string) (entityRule, bool) {
+	kind, value, ok := strings.Cut(strings.TrimSpace(line), ":")
+	if !ok {
+		return entityRule{}, false
+	}
+	kind = strings.ToLower(strings.TrimSpace(kind))
+	value = strings.ToLower(strings.TrimSpace(value))
+	switch kind {
+	case "domain":
+		value = strings.TrimPrefix(urlHost(value), "www.")
+	case "hashtag":
+		value = strings.TrimPrefix(value, "#")
+	case "mention":
+		value = strings.TrimPrefix(value, "@")
+	case "cashtag":
+		value = strings.TrimPrefix(value, "$")
+	case "url":
+	default:
+		return entityRule{}, false
+	}
+	if value == "" {
+		return entityRule{}, false
+	}
+	return entityRule{kind: kind, value: value, line: strings.TrimSpace(line)}, true
+}
+
+func (r entityRule) match(ents MessageEntities) bool {
+	switch r.kind {
+	case "domain":
+		for _, d := range ents.Domains {
+			if d == r.value || strings.HasSuffix(d, "."+r.value) {
+				return true
+			}
+		}
+	case "url":
+		for _, u := range ents.URLs {
+			if strings.Contains(strings.ToLower(u), r.value) {
+				return true
+			}
+		}
+	case "hashtag":
+		return containsString(ents.Hashtags, r.value)
+	case "mention":
+		return containsString(ents.Mentions, r.value)
+	case "cashtag":
+		return containsString(ents.Cashtags, r.value)
+	}
+	return false
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,114 @@
+package monitor
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gotd/td/tg"
+)
+
+func TestExtractEntities(t *testing.T) {
+	// Смещения в UTF-16: эмодзи занимает две единицы.
+	text := "🔥 #Вакансия от @HR_Team, $BTC, сайт Jobs.Example.com/go"
+	ents := ExtractEntities(text, []tg.MessageEntityClass{
+		&tg.MessageEntityHashtag{Offset: 3, Length: 9},
+		&tg.MessageEntityMention{Offset: 16, Length: 8},
+		&tg.MessageEntityCashtag{Offset: 26, Length: 4},
+		&tg.MessageEntityURL{Offset: 37, Length: 19},
+		&tg.MessageEntityTextURL{Offset: 0, Length: 2, URL: "https://WWW.Hidden.ru/apply"},
+		&tg.MessageEntityURL{Offset: 100, Length: 5},
+	})
+	want := MessageEntities{
+		URLs:     []string{"Jobs.Example.com/go", "https://WWW.Hidden.ru/apply"},
+		Domains:  []string{"jobs.example.com", "hidden.ru"},
+		Hashtags: []string{"вакансия"},
+		Mentions: []string{"hr_team"},
+		Cashtags: []string{"btc"},
+	}
+	if !reflect.DeepEqual(ents, want) {
+		t.Errorf("ExtractEntities = %+v, want %+v", ents, want)
+	}
+}
+
+func TestParseEntityRule(t *testing.T) {
+	for _, tc := range []struct {
+		line  string
+		kind  string
+		value string
+		ok    bool
+	}{
+		{"domain:Example.com", "domain", "example.com", true},
+		{"domain: https://www.example.com/jobs", "domain", "example.com", true},
+		{"DOMAIN:sub.example.com", "domain", "sub.example.com", true},
+		{"url:/Jobs/", "url", "/jobs/", true},
+		{"hashtag:#Вакансия", "hashtag", "вакансия", true},
+		{"mention:@HR_Team", "mention", "hr_team", true},
+		{"cashtag:$btc", "cashtag", "btc", true},
+		{"hashtag:#", "", "", false},
+		{"phone:123", "", "", false},
+		{"ищу разработчика", "", "", false},
+	} {
+		r, ok := parseEntityRule(tc.line)
+		if ok != tc.ok || r.kind != tc.kind || r.value != tc.value {
+			t.Errorf("parseEntityRule(%q) = %q %q %v, want %q %q %v", tc.line, r.kind, r.value, ok, tc.kind, tc.value, tc.ok)
+		}
+	}
+}
+
+func TestEntityRuleMatch(t *testing.T) {
+	ents := MessageEntities{
+		URLs:     []string{"https://Sub.Example.com/Jobs/123", "https://notexample.org"},
+		Domains:  []string{"sub.example.com", "notexample.org"},
+		Hashtags: []string{"вакансия"},
+		Mentions: []string{"hr_team"},
+		Cashtags: []string{"btc"},
+	}
+	for _, tc := range []struct {
+		line string
+		want bool
+	}{
+		{"domain:example.com", true},
+		{"domain:sub.example.com", true},
+		{"domain:www.sub.example.com", true},
+		{"domain:other.sub.example.com", false},
+		{"domain:ample.com", false},
+		{"domain:example.org", false},
+		{"domain:notexample.org", true},
+		{"url:/jobs/", true},
+		{"url:/vacancy/", false},
+		{"hashtag:#ВАКАНСИЯ", true},
+		{"hashtag:вакансии", false},
+		{"mention:@HR_TEAM", true},
+		{"mention:hr", false},
+		{"cashtag:$BTC", true},
+		{"cashtag:eth", false},
+	} {
+		r, ok := parseEntityRule(tc.line)
+		if !ok {
+			t.Fatalf("parseEntityRule(%q) failed", tc.line)
+		}
+		if got := r.match(ents); got != tc.want {
+			t.Errorf("%s match = %v, want %v", tc.line, got, tc.want)
+		}
+	}
+}
+
+func TestMatcherEntityRules(t *testing.T) {
+	m := NewMatcher([]string{"domain:example.com", "hashtag:вакансия"}, nil, false)
+	for _, tc := range []struct {
+		name string
+		ents MessageEntities
+		want string
+	}{
+		{"subdomain", MessageEntities{URLs: []string{"https://jobs.example.com"}, Domains: []string{"jobs.example.com"}}, "domain:example.com"},
+		{"hashtag", MessageEntities{Hashtags: []string{"вакансия"}}, "hashtag:вакансия"},
+		{"other domain", MessageEntities{URLs: []string{"https://example.net"}, Domains: []string{"example.net"}}, ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, _ := m.FindIn("текст без ключевых слов", tc.ents)
+			if got != tc.want {
+				t.Errorf("FindIn = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
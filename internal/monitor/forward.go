@@ -0,0 +1,149 @@
+package monitor
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"getclient/internal/telegramutil"
+
+	"github.com/gotd/td/tg"
+	"github.com/gotd/td/tgerr"
+)
+
+type Forwarder struct {
+	api   *tg.Client
+	cache *telegramutil.EntityCache
+
+	mu      sync.Mutex
+	peers   map[string]tg.InputPeerClass
+	targets map[string]struct{}
+}
+
+func NewForwarder(api *tg.Client, cache *telegramutil.EntityCache) *Forwarder {
+	return &Forwarder{
+		api:     api,
+		cache:   cache,
+		peers:   make(map[string]tg.InputPeerClass),
+		targets: make(map[string]struct{}),
+	}
+}
+
+// IsTarget — в чат пересылаются алерты; такие копии не проверяются и не
+// пересылаются повторно.
+func (f *Forwarder) IsTarget(peerKey string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_, ok := f.targets[peerKey]
+	return ok
+}
+
+// Forward отправляет исходное сообщение в целевой чат. Если чат-источник
+// запрещает пересылку, отправляется текст алерта.
+func (f *Forwarder) Forward(ctx context.Context, to string, copy bool, from tg.PeerClass, msgID int, noforwards bool, fallback string) error {
+	target, err := f.resolve(ctx, to)
+	if err != nil {
+		return err
+	}
+	if !noforwards {
+		source, ok := f.cache.InputPeer(from)
+		if !ok {
+			return errNoAccessHash
+		}
+		_, err = f.api.MessagesForwardMessages(ctx, &tg.MessagesForwardMessagesRequest{
+			FromPeer:   source,
+			ID:         []int{msgID},
+			RandomID:   []int64{randomID()},
+			ToPeer:     target,
+			DropAuthor: copy,
+		})
+		if err == nil || !tgerr.Is(err, "CHAT_FORWARDS_RESTRICTED") {
+			return err
+		}
+	}
+	_, err = f.api.MessagesSendMessage(ctx, &tg.MessagesSendMessageRequest{
+		Peer:      target,
+		Message:   fallback,
+		RandomID:  randomID(),
+		NoWebpage: true,
+	})
+	return err
+}
+
+func (f *Forwarder) resolve(ctx context.Context, to string) (tg.InputPeerClass, error) {
+	to = strings.TrimSpace(to)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if p, ok := f.peers[to]; ok {
+		return p, nil
+	}
+
+	var peer tg.InputPeerClass
+	switch {
+	case to == "me" || to == "self":
+		peer = &tg.InputPeerSelf{}
+	case isNumericPeer(to):
+		id, _ := strconv.ParseInt(to, 10, 64)
+		p, ok := f.cache.InputPeer(peerFromBotAPIID(id))
+		if !ok {
+			return nil, fmt.Errorf("чат %s не найден среди известных аккаунту", to)
+		}
+		peer = p
+	default:
+		name := strings.TrimPrefix(strings.TrimPrefix(to, "https://t.me/"), "@")
+		res, err := f.api.ContactsResolveUsername(ctx, name)
+		if err != nil {
+			return nil, fmt.Errorf("resolve %s: %w", to, err)
+		}
+		f.cache.AddUsersChats(res.Users, res.Chats)
+		p, ok := f.cache.InputPeer(res.Peer)
+		if !ok {
+			return nil, fmt.Errorf("resolve %s: нет access hash", to)
+		}
+		peer = p
+	}
+	f.peers[to] = peer
+	f.targets[inputPeerKey(peer)] = struct{}{}
+	return peer, nil
+}
+
+func inputPeerKey(p tg.InputPeerClass) string {
+	switch v := p.(type) {
+	case *tg.InputPeerUser:
+		return telegramutil.PeerKey(&tg.PeerUser{UserID: v.UserID})
+	case *tg.InputPeerChat:
+		return telegramutil.PeerKey(&tg.PeerChat{ChatID: v.ChatID})
+	case *tg.InputPeerChannel:
+		return telegramutil.PeerKey(&tg.PeerChannel{ChannelID: v.ChannelID})
+	}
+	return "self"
+}
+
+func isNumericPeer(s string) bool {
+	_, err := strconv.ParseInt(s, 10, 64)
+	return err == nil
+}
+
+// peerFromBotAPIID переводит id в формате Bot API (-100… для каналов,
+// отрицательные для обычных групп) в пиры MTProto.
+func peerFromBotAPIID(id int64) tg.PeerClass {
+	const channelShift = 1000000000000
+	switch {
+	case id <= -channelShift:
+		return &tg.PeerChannel{ChannelID: -id - channelShift}
+	case id < 0:
+		return &tg.PeerChat{ChatID: -id}
+	default:
+		return &tg.PeerUser{UserID: id}
+	}
+}
+
+func randomID() int64 {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	return int64(binary.LittleEndian.Uint64(b[:]))
+}
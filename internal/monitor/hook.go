@@ -0,0 +1,153 @@
+package monitor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"strings"
+	"time"
+
+	"getclient/internal/notifier"
+)
+
+const (
+	defaultHookTimeout = 5 * time.Second
+	// maxHookTimeout ограничивает время, на которое фильтр занимает слот отправки.
+	maxHookTimeout = 30 * time.Second
+)
+
+// HookResult — ответ внешнего фильтра. Пустой ответ означает «пропустить без изменений».
+type HookResult struct {
+	Veto   bool              `json:"veto"`
+	Reason string            `json:"reason,omitempty"`
+	Extra  map[string]string `json:"extra,omitempty"`
+	Route  []string          `json:"route,omitempty"`
+}
+
+// Hook передаёт алерт внешней программе (JSON в stdin, ответ в stdout)
+// или локальному HTTP-адресу (POST JSON) перед отправкой.
+type Hook struct {
+	target   string
+	timeout  time.Duration
+	failOpen bool
+	http     *http.Client
+}
+
+// NewHook создаёт фильтр. target — URL (http:// или https://) либо команда с аргументами.
+// failOpen определяет, отправлять ли алерт, если фильтр не ответил или вернул ошибку.
+// Таймаут не больше maxHookTimeout.
+func NewHook(target string, timeout time.Duration, failOpen bool) *Hook {
+	if timeout <= 0 {
+		timeout = defaultHookTimeout
+	}
+	timeout = min(timeout, maxHookTimeout)
+	return &Hook{
+		target:   strings.TrimSpace(target),
+		timeout:  timeout,
+		failOpen: failOpen,
+		http:     &http.Client{},
+	}
+}
+
+func (h *Hook) isHTTP() bool {
+	return strings.HasPrefix(h.target, "http://") || strings.HasPrefix(h.target, "https://")
+}
+
+// Apply прогоняет алерт через фильтр. ok == false — алерт отклонён.
+func (h *Hook) Apply(ctx context.Context, n notifier.Notification) (notifier.Notification, bool, error) {
+	res, err := h.call(ctx, n)
+	if err != nil {
+		return n, h.failOpen, err
+	}
+	if res.Veto {
+		return n, false, nil
+	}
+	if len(res.Extra) > 0 {
+		extra := make(map[string]string, len(n.Extra)+len(res.Extra))
+		for k, v := range n.Extra {
+			extra[k] = v
+		}
+		for k, v := range res.Extra {
+			extra[k] = v
+		}
+		n.Extra = extra
+	}
+	if len(res.Route) > 0 {
+		n.Route = res.Route
+	}
+	return n, true, nil
+}
+
+func (h *Hook) call(ctx context.Context, n notifier.Notification) (HookResult, error) {
+	payload, err := json.Marshal(n)
+	if err != nil {
+		return HookResult{}, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	var out []byte
+	if h.isHTTP() {
+		out, err = h.post(ctx, payload)
+	} else {
+		out, err = h.exec(ctx, payload)
+	}
+	if err != nil {
+		return HookResult{}, err
+	}
+
+	var res HookResult
+	if len(bytes.TrimSpace(out)) == 0 {
+		return res, nil
+	}
+	if err := json.Unmarshal(out, &res); err != nil {
+		return HookResult{}, fmt.Errorf("hook: неверный ответ: %w", err)
+	}
+	return res, nil
+}
+
+func (h *Hook) exec(ctx context.Context, payload []byte) ([]byte, error) {
+	args := strings.Fields(h.target)
+	if len(args) == 0 {
+		return nil, fmt.Errorf("hook: пустая команда")
+	}
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdin = bytes.NewReader(payload)
+	// Дочерние процессы могут держать stdout открытым и после таймаута.
+	cmd.WaitDelay = time.Second
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("hook: таймаут %s", h.timeout)
+		}
+		return nil, fmt.Errorf("hook: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+func (h *Hook) post(ctx context.Context, payload []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.target, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := h.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("hook: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("hook: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("hook: HTTP %d", resp.StatusCode)
+	}
+	return body, nil
+}
@@ -0,0 +1,211 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"getclient/internal/notifier"
+
+	"github.com/gotd/td/tg"
+	"go.uber.org/zap"
+)
+
+func testAlert() notifier.Notification {
+	return notifier.Notification{RuleSet: "dev", Text: "ищу разработчика", Extra: map[string]string{"Город": "Москва"}}
+}
+
+// scriptHook создаёт фильтр-программу из shell-скрипта.
+func scriptHook(t *testing.T, script string, timeout time.Duration, failOpen bool) *Hook {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "hook.sh")
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return NewHook("sh "+path, timeout, failOpen)
+}
+
+func TestHookExec(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		script   string
+		failOpen bool
+		allowed  bool
+		err      bool
+		route    []string
+		extra    map[string]string
+	}{
+		{name: "empty answer", script: "cat >/dev/null", allowed: true},
+		{name: "allow", script: `echo '{"veto": false}'`, allowed: true},
+		{name: "veto", script: `echo '{"veto": true, "reason": "spam"}'`, failOpen: true},
+		{
+			name:    "route and extra",
+			script:  `echo '{"extra": {"Компания": "Acme", "Город": "Казань"}, "route": ["bot"]}'`,
+			allowed: true,
+			route:   []string{"bot"},
+			extra:   map[string]string{"Компания": "Acme", "Город": "Казань"},
+		},
+		{
+			name:    "reads alert",
+			script:  `grep -q '"rule_set":"dev"' && echo '{"route": ["crm"]}'`,
+			allowed: true,
+			route:   []string{"crm"},
+		},
+		{name: "exit code fail-open", script: "echo boom >&2; exit 1", failOpen: true, allowed: true, err: true},
+		{name: "exit code fail-closed", script: "exit 1", err: true},
+		{name: "bad answer", script: "echo nope", failOpen: true, allowed: true, err: true},
+		{name: "timeout fail-open", script: "exec sleep 5", failOpen: true, allowed: true, err: true},
+		{name: "timeout fail-closed", script: "exec sleep 5", err: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			h := scriptHook(t, tc.script, 200*time.Millisecond, tc.failOpen)
+			n, allowed, err := h.Apply(context.Background(), testAlert())
+			if (err != nil) != tc.err {
+				t.Fatalf("err = %v, want error = %v", err, tc.err)
+			}
+			if allowed != tc.allowed {
+				t.Fatalf("allowed = %v, want %v", allowed, tc.allowed)
+			}
+			if !slices.Equal(n.Route, tc.route) {
+				t.Errorf("route = %v, want %v", n.Route, tc.route)
+			}
+			want := testAlert().Extra
+			if tc.extra != nil {
+				want = tc.extra
+			}
+			if len(n.Extra) != len(want) {
+				t.Errorf("extra = %v, want %v", n.Extra, want)
+			}
+			for k, v := range want {
+				if n.Extra[k] != v {
+					t.Errorf("extra[%s] = %q, want %q", k, n.Extra[k], v)
+				}
+			}
+		})
+	}
+}
+
+func TestHookHTTP(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		status   int
+		body     string
+		delay    time.Duration
+		failOpen bool
+		allowed  bool
+		err      bool
+		route    []string
+	}{
+		{name: "no content", status: http.StatusNoContent, allowed: true},
+		{name: "route", status: http.StatusOK, body: `{"route": ["crm"], "extra": {"Сделка": "42"}}`, allowed: true, route: []string{"crm"}},
+		{name: "veto", status: http.StatusOK, body: `{"veto": true}`, failOpen: true},
+		{name: "server error fail-open", status: http.StatusInternalServerError, failOpen: true, allowed: true, err: true},
+		{name: "server error fail-closed", status: http.StatusBadGateway, err: true},
+		{name: "timeout fail-open", status: http.StatusOK, delay: time.Second, failOpen: true, allowed: true, err: true},
+		{name: "timeout fail-closed", status: http.StatusOK, delay: time.Second, err: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var n notifier.Notification
+				if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+					t.Errorf("request %s %s", r.Method, r.Header.Get("Content-Type"))
+				}
+				if err := json.NewDecoder(r.Body).Decode(&n); err != nil || n.RuleSet != "dev" {
+					t.Errorf("payload = %+v, %v", n, err)
+				}
+				select {
+				case <-time.After(tc.delay):
+				case <-r.Context().Done():
+					return
+				}
+				w.WriteHeader(tc.status)
+				_, _ = w.Write([]byte(tc.body))
+			}))
+			defer srv.Close()
+
+			h := NewHook(srv.URL, 200*time.Millisecond, tc.failOpen)
+			n, allowed, err := h.Apply(context.Background(), testAlert())
+			if (err != nil) != tc.err {
+				t.Fatalf("err = %v, want error = %v", err, tc.err)
+			}
+			if allowed != tc.allowed {
+				t.Fatalf("allowed = %v, want %v", allowed, tc.allowed)
+			}
+			if !slices.Equal(n.Route, tc.route) {
+				t.Errorf("route = %v, want %v", n.Route, tc.route)
+			}
+			if tc.route != nil && (n.Extra["Сделка"] != "42" || n.Extra["Город"] != "Москва") {
+				t.Errorf("extra = %v", n.Extra)
+			}
+		})
+	}
+}
+
+func TestNewHookTimeout(t *testing.T) {
+	for _, tc := range []struct {
+		in, want time.Duration
+	}{
+		{0, defaultHookTimeout},
+		{-time.Second, defaultHookTimeout},
+		{2 * time.Second, 2 * time.Second},
+		{time.Hour, maxHookTimeout},
+	} {
+		if got := NewHook("true", tc.in, true).timeout; got != tc.want {
+			t.Errorf("NewHook(%s).timeout = %s, want %s", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestMonitorHookBounded(t *testing.T) {
+	release := make(chan struct{})
+	var mu sync.Mutex
+	inFlight, peak := 0, 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inFlight++
+		peak = max(peak, inFlight)
+		mu.Unlock()
+		<-release
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	rec := &recordNotifier{}
+	m := New(newTestRules(), zap.NewNop(), rec, "acc", nil, &sync.Map{})
+	m.SetHook(NewHook(srv.URL, 10*time.Second, false))
+
+	// Первые maxDeliveries алертов не задерживают обработку, следующий ждёт слота.
+	for i := 1; i <= maxDeliveries; i++ {
+		m.ProcessMessage(context.Background(), tg.Entities{}, groupMessage(i, "ищу разработчика"))
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		m.ProcessMessage(context.Background(), tg.Entities{}, groupMessage(maxDeliveries+1, "ищу разработчика"))
+	}()
+	select {
+	case <-done:
+		t.Fatal("delivery over the limit did not wait")
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	close(release)
+	<-done
+	m.Wait()
+	if len(rec.sent) != maxDeliveries+1 {
+		t.Errorf("alerts = %d, want %d", len(rec.sent), maxDeliveries+1)
+	}
+	if peak > maxDeliveries {
+		t.Errorf("hook calls in flight = %d, want at most %d", peak, maxDeliveries)
+	}
+}
@@ -1,63 +1,272 @@
 package monitor
 
 import (
+	"fmt"
 	"regexp"
+	"strconv"
 	"strings"
 )
 
+// Match — найденное ключевое слово и его байтовый диапазон в исходном тексте.
+// End == 0, если у совпадения нет позиции в тексте (правила по сущностям).
+// В режиме оценки Score — сумма весов, Terms — сработавшие термы с весами.
+type Match struct {
+	Keyword    string
+	Start, End int
+	Score      float64
+	Terms      []string
+}
+
+// term — строка файла ключевых слов: фраза, регулярное выражение, правило NEAR
+// или правило по сущностям, с весом из суффикса « =2.5» (по умолчанию 1).
+type term struct {
+	line   string
+	weight float64
+	key    string
+	re     *regexp.Regexp
+	near   *nearRule
+	entity *entityRule
+}
+
+var weightSuffix = regexp.MustCompile(`\s+=\s*([+-]?\d+(?:[.,]\d+)?)\s*$`)
+
+func splitWeight(line string) (string, float64) {
+	line = strings.TrimSpace(line)
+	loc := weightSuffix.FindStringSubmatchIndex(line)
+	if loc == nil {
+		return line, 1
+	}
+	w, err := strconv.ParseFloat(strings.Replace(line[loc[2]:loc[3]], ",", ".", 1), 64)
+	if err != nil {
+		return line, 1
+	}
+	return strings.TrimSpace(line[:loc[0]]), w
+}
+
 type Matcher struct {
-	useRegex bool
-	phrases  []string
-	stop     []string
-	regexps  []*regexp.Regexp
+	normalize bool
+	threshold float64
+	terms     []term
+	stopWords []string
+	stop      []string
 }
 
 func NewMatcher(phrases []string, stopWords []string, useRegex bool) *Matcher {
-	m := &Matcher{useRegex: useRegex, phrases: phrases}
+	m := &Matcher{}
+	for _, p := range phrases {
+		line, weight := splitWeight(p)
+		if line == "" || weight == 0 {
+			continue
+		}
+		t := term{line: line, weight: weight}
+		if rule, ok := parseEntityRule(line); ok {
+			t.entity = &rule
+		} else if rule, ok := parseNearRule(line); ok {
+			t.near = &rule
+		} else if useRegex {
+			re, err := regexp.Compile("(?i)" + line)
+			if err != nil {
+				continue
+			}
+			t.re = re
+		}
+		m.terms = append(m.terms, t)
+	}
 	for _, sw := range stopWords {
-		sw = strings.TrimSpace(sw)
-		if sw != "" {
-			m.stop = append(m.stop, strings.ToLower(sw))
+		if sw = strings.TrimSpace(sw); sw != "" {
+			m.stopWords = append(m.stopWords, sw)
 		}
 	}
-	if useRegex {
-		for _, p := range phrases {
-			if re, err := regexp.Compile("(?i)" + p); err == nil {
-				m.regexps = append(m.regexps, re)
+	m.prepare()
+	return m
+}
+
+// WithNormalize включает нормализацию текста, фраз и стоп-слов (см. Normalize).
+func (m *Matcher) WithNormalize() *Matcher {
+	m.normalize = true
+	m.prepare()
+	return m
+}
+
+// WithThreshold включает режим оценки: веса сработавших термов суммируются,
+// совпадение засчитывается, если сумма не меньше порога.
+func (m *Matcher) WithThreshold(threshold float64) *Matcher {
+	m.threshold = threshold
+	return m
+}
+
+func (m *Matcher) prepare() {
+	for i := range m.terms {
+		t := &m.terms[i]
+		switch {
+		case t.near != nil:
+			t.near.keys = t.near.keys[:0]
+			for _, s := range t.near.terms {
+				t.near.keys = append(t.near.keys, tokenTexts(m.fold(s).Text))
 			}
+		case t.entity == nil && t.re == nil:
+			t.key = m.fold(t.line).Text
 		}
 	}
-	return m
+	m.stop = m.stop[:0]
+	for _, sw := range m.stopWords {
+		if s := m.fold(sw).Text; s != "" {
+			m.stop = append(m.stop, s)
+		}
+	}
+}
+
+func (m *Matcher) fold(s string) Normalized {
+	if m.normalize {
+		return Normalize(s)
+	}
+	return lowerMapped(s)
+}
+
+// foldWords — как fold, но без склейки слов по буквам: правила NEAR сами находят
+// термы, написанные по буквам, и не теряют границы слов («и щ у р а б о т а»).
+func (m *Matcher) foldWords(s string) Normalized {
+	if m.normalize {
+		return normalizeWords(s)
+	}
+	return lowerMapped(s)
+}
+
+// Empty — в наборе нет ни одной фразы или правила.
+func (m *Matcher) Empty() bool {
+	return len(m.terms) == 0
 }
 
 func (m *Matcher) Match(text string) bool {
-	if text == "" {
-		return false
+	_, ok := m.Find(text)
+	return ok
+}
+
+func (m *Matcher) Find(text string) (string, bool) {
+	return m.FindIn(text, MessageEntities{})
+}
+
+func (m *Matcher) FindIn(text string, ents MessageEntities) (string, bool) {
+	match, ok := m.Locate(text, ents)
+	return match.Keyword, ok
+}
+
+func (m *Matcher) Locate(text string, ents MessageEntities) (Match, bool) {
+	if text == "" && ents.Empty() {
+		return Match{}, false
 	}
-	tLower := strings.ToLower(text)
-	for _, sw := range m.stop {
-		if sw != "" && strings.Contains(tLower, sw) {
-			return false
+	in := matchInput{text: text, folded: m.fold(text), ents: ents}
+	for _, r := range in.folded.readings() {
+		for _, sw := range m.stop {
+			if strings.Contains(r.Text, sw) {
+				return Match{}, false
+			}
 		}
 	}
-	if m.useRegex {
-		for _, re := range m.regexps {
-			if re.MatchString(text) {
-				return true
+	if m.threshold != 0 {
+		return m.score(&in)
+	}
+
+	// Без порога термы с отрицательным весом работают как стоп-слова.
+	for i := range m.terms {
+		if t := &m.terms[i]; t.weight < 0 {
+			if _, _, ok := m.locateTerm(t, &in); ok {
+				return Match{}, false
 			}
 		}
-		return false
 	}
-	for _, p := range m.phrases {
-		p = strings.TrimSpace(p)
-		if p == "" {
+	for i := range m.terms {
+		t := &m.terms[i]
+		if t.weight < 0 {
 			continue
 		}
-		if strings.Contains(tLower, strings.ToLower(p)) {
-			return true
+		if start, end, ok := m.locateTerm(t, &in); ok {
+			return Match{Keyword: t.keyword(text, start, end), Start: start, End: end}, true
 		}
 	}
-	return false
+	return Match{}, false
 }
 
+func (m *Matcher) score(in *matchInput) (Match, bool) {
+	var res Match
+	found := false
+	for i := range m.terms {
+		t := &m.terms[i]
+		start, end, ok := m.locateTerm(t, in)
+		if !ok {
+			continue
+		}
+		res.Score += t.weight
+		res.Terms = append(res.Terms, fmt.Sprintf("%s %+g", t.line, t.weight))
+		if !found && t.weight > 0 {
+			res.Keyword, res.Start, res.End = t.keyword(in.text, start, end), start, end
+			found = true
+		}
+	}
+	if !found || res.Score < m.threshold {
+		return Match{}, false
+	}
+	return res, true
+}
 
+// keyword — для регулярного выражения найденный текст, для остальных термов сама строка.
+func (t *term) keyword(text string, start, end int) string {
+	if t.re != nil && end > start {
+		return text[start:end]
+	}
+	return t.line
+}
+
+type matchInput struct {
+	text   string
+	folded Normalized
+	ents   MessageEntities
+	// words — слова текста для правил NEAR, по варианту на каждое прочтение.
+	words []tokenized
+}
+
+type tokenized struct {
+	Normalized
+	tokens []token
+}
+
+// locateTerm возвращает байтовый диапазон терма в исходном тексте.
+func (m *Matcher) locateTerm(t *term, in *matchInput) (int, int, bool) {
+	switch {
+	case t.entity != nil:
+		return 0, 0, t.entity.match(in.ents)
+	case t.near != nil:
+		if in.words == nil {
+			for _, r := range m.foldWords(in.text).readings() {
+				in.words = append(in.words, tokenized{Normalized: r, tokens: tokenize(r.Text)})
+			}
+		}
+		for _, w := range in.words {
+			if s, e, ok := t.near.find(w.tokens); ok {
+				start, end := w.Span(s, e)
+				return start, end, true
+			}
+		}
+	case t.re != nil:
+		if loc := t.re.FindStringIndex(in.text); loc != nil {
+			return loc[0], loc[1], true
+		}
+		// Латинские буквы в выражении не совпадут со свёрнутым текстом, поэтому сначала исходный.
+		if m.normalize {
+			for _, r := range in.folded.readings() {
+				if loc := t.re.FindStringIndex(r.Text); loc != nil {
+					start, end := r.Span(loc[0], loc[1])
+					return start, end, true
+				}
+			}
+		}
+	case t.key != "":
+		for _, r := range in.folded.readings() {
+			if idx := strings.Index(r.Text, t.key); idx >= 0 {
+				start, end := r.Span(idx, idx+len(t.key))
+				return start, end, true
+			}
+		}
+	}
+	return 0, 0, false
+}
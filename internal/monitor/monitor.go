@@ -5,7 +5,12 @@ import (
 	"fmt"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
+	"getclient/internal/classifier"
+	"getclient/internal/extract"
+	"getclient/internal/lang"
 	"getclient/internal/notifier"
 	"getclient/internal/store"
 	"getclient/internal/telegramutil"
@@ -14,43 +19,134 @@ import (
 	"go.uber.org/zap"
 )
 
+// maxDeliveries — сколько найденных алертов аккаунта одновременно проходят
+// контекст, внешний фильтр, отправку и пересылку.
+const maxDeliveries = 8
+
 type Monitor struct {
-	matcher    *Matcher
+	rules      *Rules
 	logger     *zap.Logger
 	notify     notifier.Notifier
 	account    string
 	limiter    store.SenderLimiter
 	globalSeen *sync.Map
+
+	cache     *telegramutil.EntityCache
+	context   *ContextFetcher
+	forwarder *Forwarder
+	chatInfo  *ChatInfoFetcher
+	replier   *AutoReplier
+	planner   *Planner
+	ruleSets  []string
+
+	classifier   *classifier.Classifier
+	minRelevance float64
+	hook         *Hook
+
+	deliveries chan struct{}
+	pending    sync.WaitGroup
+
+	processed atomic.Int64
+	matched   atomic.Int64
+	alerted   atomic.Int64
 }
 
-func New(matcher *Matcher, logger *zap.Logger, notify notifier.Notifier, account string, limiter store.SenderLimiter, globalSeen *sync.Map) *Monitor {
+type Stats struct {
+	Processed int64
+	Matched   int64
+	Alerted   int64
+}
+
+func New(rules *Rules, logger *zap.Logger, notify notifier.Notifier, account string, limiter store.SenderLimiter, globalSeen *sync.Map) *Monitor {
 	return &Monitor{
-		matcher:    matcher,
+		rules:      rules,
 		logger:     logger,
 		notify:     notify,
 		account:    account,
 		limiter:    limiter,
 		globalSeen: globalSeen,
+		deliveries: make(chan struct{}, maxDeliveries),
 	}
 }
 
+func (m *Monitor) Stats() Stats {
+	return Stats{
+		Processed: m.processed.Load(),
+		Matched:   m.matched.Load(),
+		Alerted:   m.alerted.Load(),
+	}
+}
+
+func (m *Monitor) SetEntityCache(cache *telegramutil.EntityCache) {
+	m.cache = cache
+}
+
+func (m *Monitor) SetChatInfo(f *ChatInfoFetcher) {
+	m.chatInfo = f
+}
+
+func (m *Monitor) SetAutoReplier(a *AutoReplier) {
+	m.replier = a
+}
+
+// SetPlanner включает распределение чатов: сообщения чатов, которые ведёт другой
+// аккаунт, пропускаются.
+func (m *Monitor) SetPlanner(p *Planner) {
+	m.planner = p
+}
+
+// SetRuleSets ограничивает аккаунт наборами правил с этими именами. Такой аккаунт
+// проверяет сообщения независимо от остальных: одно сообщение, полученное им и
+// другим аккаунтом, проверяется каждым, но алерт по одному набору отправляется
+// один раз.
+func (m *Monitor) SetRuleSets(names []string) {
+	m.ruleSets = names
+}
+
+func (m *Monitor) SetContextFetcher(f *ContextFetcher) {
+	m.context = f
+}
+
+func (m *Monitor) SetForwarder(f *Forwarder) {
+	m.forwarder = f
+}
+
+// SetClassifier включает оценку релевантности: алерты с вероятностью ниже minRelevance
+// помечаются как низкоприоритетные.
+func (m *Monitor) SetClassifier(c *classifier.Classifier, minRelevance float64) {
+	m.classifier = c
+	m.minRelevance = minRelevance
+}
+
+func (m *Monitor) SetHook(h *Hook) {
+	m.hook = h
+}
+
 func (m *Monitor) ProcessMessage(ctx context.Context, e tg.Entities, msg tg.MessageClass) {
 	message, ok := msg.(*tg.Message)
 	if !ok || message == nil {
 		return
 	}
-	m.process(ctx, e, message.PeerID, message.FromID, message.ID, message.Message)
+	m.process(ctx, e, message.PeerID, message.FromID, message.ID, ExtractText(message), messageEntities(message), message)
 }
 
-func (m *Monitor) ProcessShort(ctx context.Context, e tg.Entities, peerID tg.PeerClass, fromID tg.PeerClass, msgID int, text string) {
-	m.process(ctx, e, peerID, fromID, msgID, text)
+func (m *Monitor) ProcessShort(ctx context.Context, e tg.Entities, peerID tg.PeerClass, fromID tg.PeerClass, msgID int, text string, entities []tg.MessageEntityClass) {
+	if strings.TrimSpace(text) == "" {
+		return
+	}
+	m.process(ctx, e, peerID, fromID, msgID, []TextPart{{Source: PartText, Text: text}}, ExtractEntities(text, entities), nil)
 }
 
-func (m *Monitor) process(ctx context.Context, e tg.Entities, peerID tg.PeerClass, fromID tg.PeerClass, msgID int, text string) {
-	if text == "" {
+func (m *Monitor) process(ctx context.Context, e tg.Entities, peerID tg.PeerClass, fromID tg.PeerClass, msgID int, parts []TextPart, ents MessageEntities, msg *tg.Message) {
+	if m.rules.Paused() {
 		return
 	}
 
+	if m.cache != nil {
+		m.cache.Add(e)
+		e = m.cache.Complete(e, peerID, fromID)
+	}
+
 	isGroup := false
 	switch p := peerID.(type) {
 	case *tg.PeerChat:
@@ -70,29 +166,78 @@ func (m *Monitor) process(ctx context.Context, e tg.Entities, peerID tg.PeerClas
 	}
 
 	peerKey := telegramutil.PeerKey(peerID)
-	dedupeKey := fmt.Sprintf("%s:%d", peerKey, msgID)
+	if m.planner != nil {
+		m.planner.Observe(m.account, peerKey, telegramutil.PeerTitle(peerID, e))
+		if !m.planner.Owns(m.account, peerKey) {
+			return
+		}
+	}
+	msgKey := fmt.Sprintf("%s:%d", peerKey, msgID)
+	// Аккаунты со своими наборами проверяют сообщение независимо от остальных,
+	// поэтому повторы отсекаются только внутри аккаунта.
+	dedupeKey := msgKey
+	if len(m.ruleSets) > 0 {
+		dedupeKey = m.account + "|" + msgKey
+	}
 	if _, loaded := m.globalSeen.LoadOrStore(dedupeKey, struct{}{}); loaded {
 		return
 	}
 
-	if !m.matcher.Match(text) {
+	m.processed.Add(1)
+	if m.rules.ChatMuted(peerKey) {
+		return
+	}
+	if m.forwarder != nil && m.forwarder.IsTarget(peerKey) {
 		return
 	}
 
-	chatName := telegramutil.PeerTitle(peerID, e)
 	var fromPeer tg.PeerClass = fromID
 	if fromPeer == nil {
 		fromPeer = &tg.PeerUser{UserID: 0}
 	}
 	sender := telegramutil.Sender(fromPeer, e)
-	senderName := sender.Name
-	if sender.Username != "" {
-		senderName = "@" + sender.Username
-	} else if senderName == "" && sender.ID != 0 {
-		senderName = fmt.Sprintf("id:%d", sender.ID)
+
+	text := JoinText(parts)
+	language := lang.Detect(text)
+	chat := telegramutil.Chat(peerID, e)
+	if m.chatInfo != nil {
+		m.chatInfo.Observe(peerKey, language)
+		chat = m.chatInfo.Complete(ctx, peerID, chat, m.rules.needsChatInfo())
+	}
+	res, ok := m.rules.match(candidate{
+		parts:    parts,
+		text:     text,
+		msgID:    msgID,
+		msg:      msg,
+		lang:     language,
+		ents:     ents,
+		chat:     chat,
+		sender:   sender,
+		ruleSets: m.ruleSets,
+	})
+	if !ok {
+		return
+	}
+	// Сообщение может проверить и аккаунт со своими наборами, и общий: алерт по
+	// набору отправляет тот, кто нашёл совпадение первым.
+	if _, loaded := m.globalSeen.LoadOrStore(res.ruleSet.Name+"|"+msgKey, struct{}{}); loaded {
+		return
+	}
+	rs, keyword, matchedIn := res.ruleSet, res.keyword, res.part
+	m.matched.Add(1)
+	if text == "" {
+		text = "[медиа без подписи]"
 	}
 
-	if m.limiter != nil {
+	chatName := telegramutil.PeerTitle(peerID, e)
+	senderName := senderDisplayName(sender)
+	if m.rules.SenderMuted(sender.ID) {
+		return
+	}
+
+	// Отправитель из watch даёт алерт на каждое сообщение, суточный лимит на
+	// отправителя к нему не применяется.
+	if m.limiter != nil && matchedIn != PartSender {
 		ok, err := m.limiter.Allow(ctx, m.account, sender.ID)
 		if err != nil {
 			m.logger.Warn("Limiter failed", zap.Error(err))
@@ -105,31 +250,136 @@ func (m *Monitor) process(ctx context.Context, e tg.Entities, peerID tg.PeerClas
 
 	link := telegramutil.MessageLink(peerID, msgID, e)
 
+	var relevance float64
+	lowPriority := false
+	if m.classifier != nil {
+		if p, ok := m.classifier.Score(text); ok {
+			relevance = p
+			lowPriority = p < m.minRelevance
+		}
+	}
+
+	fields := extract.Parse(text)
+	for _, u := range ents.URLs {
+		fields.AddURL(u)
+	}
+	for _, u := range ents.Mentions {
+		fields.AddUsername(u)
+	}
+	var extracted *extract.Fields
+	if !fields.Empty() {
+		extracted = &fields
+	}
+
+	n := notifier.Notification{
+		ChatTitle:      chatName,
+		From:           fmt.Sprintf("%s (через %s)", senderName, m.account),
+		Link:           link,
+		Text:           text,
+		Account:        m.account,
+		RuleSet:        rs.Name,
+		Keyword:        keyword,
+		MatchedIn:      matchedIn,
+		MatchStart:     res.start,
+		MatchEnd:       res.end,
+		Score:          res.score,
+		Terms:          res.terms,
+		Relevance:      relevance,
+		LowPriority:    lowPriority,
+		Language:       language,
+		Extracted:      extracted,
+		SenderID:       sender.ID,
+		SenderName:     sender.Name,
+		SenderUsername: sender.Username,
+		ChatKey:        peerKey,
+		MessageID:      msgID,
+		Time:           time.Now(),
+	}
+
+	// Дальше запросы в сеть и внешний фильтр: они идут в фоне, чтобы не задерживать
+	// апдейты, но не больше maxDeliveries сразу — иначе ждём освобождения слота.
+	select {
+	case m.deliveries <- struct{}{}:
+	case <-ctx.Done():
+		return
+	}
+	m.pending.Add(1)
+	go func() {
+		defer func() {
+			<-m.deliveries
+			m.pending.Done()
+		}()
+		m.deliver(ctx, n, rs, e, peerID, msg, sender)
+	}()
+}
+
+// Wait ждёт, пока уйдут алерты, найденные до вызова.
+func (m *Monitor) Wait() {
+	m.pending.Wait()
+}
+
+func (m *Monitor) deliver(ctx context.Context, n notifier.Notification, rs RuleSet, e tg.Entities, peerID tg.PeerClass, msg *tg.Message, sender telegramutil.SenderInfo) {
+	if m.context.Enabled() {
+		n.ReplyTo, n.Context = m.context.Fetch(ctx, peerID, msg)
+	}
+
+	if m.hook != nil {
+		var allowed bool
+		var err error
+		n, allowed, err = m.hook.Apply(ctx, n)
+		if err != nil {
+			m.logger.Warn("Hook failed", zap.Bool("sent", allowed), zap.Error(err))
+		}
+		if !allowed {
+			m.logger.Info("Алерт отклонён фильтром", zap.String("chat", n.ChatTitle), zap.String("rule_set", rs.Name))
+			return
+		}
+	}
+	m.alerted.Add(1)
+
+	senderName := senderDisplayName(sender)
 	m.logger.Info("Keyword found",
-		zap.String("chat", chatName),
+		zap.String("chat", n.ChatTitle),
 		zap.String("from", senderName),
 		zap.String("account", m.account),
-		zap.String("text", text),
+		zap.String("rule_set", rs.Name),
+		zap.String("matched_in", n.MatchedIn),
+		zap.Float64("score", n.Score),
+		zap.String("text", n.Text),
 	)
 
-	fmt.Printf("\n\033[32m[ALERT]\033[0m Найдено аккаунтом: \033[1m%s\033[0m\n", m.account)
-	fmt.Printf("Чат: \033[33m%s\033[0m\n", chatName)
-	fmt.Printf("От: \033[36m%s\033[0m\n", senderName)
-	if link != "" {
-		fmt.Printf("Ссылка: \033[34m%s\033[0m\n", link)
+	if m.classifier != nil {
+		if err := m.classifier.Remember(classifier.Hit{Key: n.HitKey(), Text: n.Text, RuleSet: rs.Name, Chat: n.ChatTitle}); err != nil {
+			m.logger.Warn("Classifier save failed", zap.Error(err))
+		}
 	}
-	fmt.Printf("Текст: %s\n\n", text)
 
 	if m.notify != nil {
-		if err := m.notify.Notify(ctx, notifier.Notification{
-			ChatTitle: chatName,
-			From:      fmt.Sprintf("%s (через %s)", senderName, m.account),
-			Link:      link,
-			Text:      text,
-		}); err != nil {
+		if err := m.notify.Notify(ctx, n); err != nil {
 			m.logger.Warn("Notify failed", zap.Error(err))
 		}
 	}
+
+	if rs.ForwardTo != "" && m.forwarder != nil {
+		fallback := fmt.Sprintf("%s\nОт: %s\n%s\n\n%s", n.ChatTitle, senderName, n.Link, n.Text)
+		if err := m.forwarder.Forward(ctx, rs.ForwardTo, rs.ForwardCopy, peerID, n.MessageID, telegramutil.NoForwards(peerID, e), fallback); err != nil {
+			m.logger.Warn("Forward failed", zap.String("to", rs.ForwardTo), zap.String("account", m.account), zap.Error(err))
+		}
+	}
+
+	if rs.AutoReply.Template != nil && m.replier != nil {
+		m.replier.Schedule(ctx, rs.AutoReply, n, peerID, sender)
+	}
+}
+
+func senderDisplayName(sender telegramutil.SenderInfo) string {
+	if sender.Username != "" {
+		return "@" + sender.Username
+	}
+	if sender.Name == "" && sender.ID != 0 {
+		return fmt.Sprintf("id:%d", sender.ID)
+	}
+	return sender.Name
 }
 
 func truncate(s string, n int) string {
@@ -139,5 +389,3 @@ func truncate(s string, n int) string {
 	}
 	return s[:n] + "..."
 }
-
-
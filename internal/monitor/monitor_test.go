@@ -0,0 +1,171 @@
+package monitor
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"getclient/internal/classifier"
+	"getclient/internal/notifier"
+	"getclient/internal/store"
+
+	"github.com/gotd/td/tg"
+	"go.uber.org/zap"
+)
+
+type recordNotifier struct {
+	mu   sync.Mutex
+	sent []notifier.Notification
+}
+
+func (r *recordNotifier) Notify(ctx context.Context, n notifier.Notification) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sent = append(r.sent, n)
+	return nil
+}
+
+func newTestRules() *Rules {
+	return NewRules([]RuleSet{
+		{Name: "dev", Matcher: NewMatcher([]string{"разработчик"}, nil, false)},
+		{Name: "design", Matcher: NewMatcher([]string{"дизайнер"}, nil, false)},
+	}, nil, nil)
+}
+
+func groupMessage(id int, text string) *tg.Message {
+	return &tg.Message{ID: id, PeerID: &tg.PeerChat{ChatID: 10}, FromID: &tg.PeerUser{UserID: 777}, Message: text}
+}
+
+func TestMonitorDedupeAcrossAccounts(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		sets    [][]string // наборы аккаунтов; nil — все
+		text    string
+		ruleSet []string // наборы отправленных алертов
+	}{
+		{"unrestricted twice", [][]string{nil, nil}, "ищу разработчика", []string{"dev"}},
+		{"restricted and unrestricted", [][]string{{"dev"}, nil}, "ищу разработчика", []string{"dev"}},
+		{"unrestricted and restricted", [][]string{nil, {"dev"}}, "ищу разработчика", []string{"dev"}},
+		{"same restricted set", [][]string{{"dev"}, {"dev"}}, "ищу разработчика", []string{"dev"}},
+		{"different sets", [][]string{{"design"}, nil}, "ищу разработчика и дизайнера", []string{"design", "dev"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rules := newTestRules()
+			rec := &recordNotifier{}
+			seen := &sync.Map{}
+			for i, sets := range tc.sets {
+				m := New(rules, zap.NewNop(), rec, "acc"+string(rune('a'+i)), nil, seen)
+				if sets != nil {
+					m.SetRuleSets(sets)
+				}
+				// Одно и то же сообщение приходит аккаунту дважды: апдейтом и поллером.
+				m.ProcessMessage(context.Background(), tg.Entities{}, groupMessage(5, tc.text))
+				m.Wait()
+				m.ProcessMessage(context.Background(), tg.Entities{}, groupMessage(5, tc.text))
+				m.Wait()
+			}
+			if len(rec.sent) != len(tc.ruleSet) {
+				t.Fatalf("alerts = %d, want %d", len(rec.sent), len(tc.ruleSet))
+			}
+			for i, n := range rec.sent {
+				if n.RuleSet != tc.ruleSet[i] {
+					t.Errorf("alert %d rule set = %q, want %q", i, n.RuleSet, tc.ruleSet[i])
+				}
+			}
+		})
+	}
+}
+
+func TestMonitorClassifierHitKey(t *testing.T) {
+	c, err := classifier.Open(filepath.Join(t.TempDir(), "classifier.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := &recordNotifier{}
+	m := New(newTestRules(), zap.NewNop(), rec, "acc", nil, &sync.Map{})
+	m.SetRuleSets([]string{"dev"})
+	m.SetClassifier(c, 0.5)
+	m.ProcessMessage(context.Background(), tg.Entities{}, groupMessage(5, "ищу разработчика"))
+	m.Wait()
+	if len(rec.sent) != 1 {
+		t.Fatalf("alerts = %d, want 1", len(rec.sent))
+	}
+	if err := c.Label(rec.sent[0].HitKey(), true); err != nil {
+		t.Errorf("Label(%q): %v", rec.sent[0].HitKey(), err)
+	}
+}
+
+func TestMonitorClassifierLowPriority(t *testing.T) {
+	c, err := classifier.Open(filepath.Join(t.TempDir(), "classifier.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	relevant := []string{"ищу разработчика бота", "нужен программист на go", "ищем разработчика, оплата сразу", "кто напишет бота на заказ", "требуется разработчик парсера"}
+	irrelevant := []string{"продам велосипед", "сдаю квартиру у метро", "потерялся рыжий кот", "поздравляю с праздником", "где вкусная шаурма"}
+	for i := range relevant {
+		for _, h := range []struct {
+			key, text string
+			relevant  bool
+		}{{"r" + relevant[i], relevant[i], true}, {"i" + irrelevant[i], irrelevant[i], false}} {
+			if err := c.Remember(classifier.Hit{Key: h.key, Text: h.text}); err != nil {
+				t.Fatal(err)
+			}
+			if err := c.Label(h.key, h.relevant); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	rec := &recordNotifier{}
+	m := New(newTestRules(), zap.NewNop(), rec, "acc", nil, &sync.Map{})
+	m.SetClassifier(c, 0.5)
+	m.ProcessMessage(context.Background(), tg.Entities{}, groupMessage(1, "ищу разработчика для бота"))
+	m.Wait()
+	m.ProcessMessage(context.Background(), tg.Entities{}, groupMessage(2, "продам квартиру, разработчик дома"))
+	m.Wait()
+	if len(rec.sent) != 2 {
+		t.Fatalf("alerts = %d, want 2", len(rec.sent))
+	}
+	if rec.sent[0].LowPriority || rec.sent[0].Relevance < 0.5 {
+		t.Errorf("relevant alert: low = %v, relevance = %.2f", rec.sent[0].LowPriority, rec.sent[0].Relevance)
+	}
+	if !rec.sent[1].LowPriority || rec.sent[1].Relevance >= 0.5 {
+		t.Errorf("irrelevant alert: low = %v, relevance = %.2f", rec.sent[1].LowPriority, rec.sent[1].Relevance)
+	}
+}
+
+func TestMonitorWatchBypassesSenderLimit(t *testing.T) {
+	limiter, err := store.OpenBaseDB(filepath.Join(t.TempDir(), "base.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rules := NewRules([]RuleSet{
+		{Name: "watch", Senders: SenderFilter{Watch: NewSenderList([]string{"777"})}},
+		{Name: "dev", Matcher: NewMatcher([]string{"разработчик"}, nil, false)},
+	}, nil, nil)
+	rec := &recordNotifier{}
+	m := New(rules, zap.NewNop(), rec, "acc", limiter, &sync.Map{})
+
+	m.ProcessMessage(context.Background(), tg.Entities{}, groupMessage(1, "привет"))
+	m.Wait()
+	m.ProcessMessage(context.Background(), tg.Entities{}, groupMessage(2, "ищу разработчика"))
+	m.Wait()
+	if len(rec.sent) != 2 {
+		t.Fatalf("watched alerts = %d, want 2", len(rec.sent))
+	}
+
+	// Остальные отправители по-прежнему дают не больше одного алерта в сутки.
+	other := func(id int) *tg.Message {
+		msg := groupMessage(id, "ищу разработчика")
+		msg.FromID = &tg.PeerUser{UserID: 888}
+		return msg
+	}
+	m.ProcessMessage(context.Background(), tg.Entities{}, other(3))
+	m.Wait()
+	m.ProcessMessage(context.Background(), tg.Entities{}, other(4))
+	m.Wait()
+	if len(rec.sent) != 3 {
+		t.Errorf("alerts = %d, want 3", len(rec.sent))
+	}
+}
@@ -0,0 +1,179 @@
+package monitor
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var nearOp = regexp.MustCompile(`\s+NEAR/(\d+)\s+`)
+
+// nearRule — правило вида «ищу NEAR/5 разработчик»: термы стоят не дальше N слов
+// друг от друга в любом порядке. Терм совпадает с началом слова, поэтому
+// «разработчик» находит и «разработчика», и «разработчиками», а также слово,
+// написанное по буквам («р а з р а б о т ч и к а»).
+type nearRule struct {
+	line  string
+	terms []string
+	gaps  []int
+	keys  [][]string
+}
+
+func parseNearRule(line string) (nearRule, bool) {
+	line = strings.TrimSpace(line)
+	ops := nearOp.FindAllStringSubmatchIndex(line, -1)
+	if len(ops) == 0 {
+		return nearRule{}, false
+	}
+	r := nearRule{line: line}
+	prev := 0
+	for _, op := range ops {
+		n, err := strconv.Atoi(line[op[2]:op[3]])
+		if err != nil || n < 1 {
+			return nearRule{}, false
+		}
+		r.terms = append(r.terms, strings.TrimSpace(line[prev:op[0]]))
+		r.gaps = append(r.gaps, n)
+		prev = op[1]
+	}
+	r.terms = append(r.terms, strings.TrimSpace(line[prev:]))
+	for _, t := range r.terms {
+		if t == "" {
+			return nearRule{}, false
+		}
+	}
+	return r, true
+}
+
+type token struct {
+	text       string
+	start, end int
+}
+
+func tokenize(s string) []token {
+	var out []token
+	start := -1
+	for i, r := range s {
+		word := unicode.IsLetter(r) || unicode.IsDigit(r)
+		switch {
+		case word && start < 0:
+			start = i
+		case !word && start >= 0:
+			out = append(out, token{text: s[start:i], start: start, end: i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		out = append(out, token{text: s[start:], start: start, end: len(s)})
+	}
+	return out
+}
+
+type occurrence struct {
+	first, last int
+}
+
+// find ищет термы в словах текста и возвращает байтовый диапазон совпадения.
+func (r nearRule) find(tokens []token) (int, int, bool) {
+	occs := make([][]occurrence, len(r.keys))
+	for i, key := range r.keys {
+		if len(key) == 0 {
+			return 0, 0, false
+		}
+		for pos := 0; pos < len(tokens); pos++ {
+			if last, ok := termAt(tokens, pos, key); ok {
+				occs[i] = append(occs[i], occurrence{first: pos, last: last})
+			}
+		}
+		if len(occs[i]) == 0 {
+			return 0, 0, false
+		}
+	}
+
+	chosen := make([]occurrence, len(occs))
+	var walk func(i int) bool
+	walk = func(i int) bool {
+		if i == len(occs) {
+			return true
+		}
+		for _, o := range occs[i] {
+			if i > 0 {
+				d := distance(chosen[i-1], o)
+				if d < 1 || d > r.gaps[i-1] {
+					continue
+				}
+			}
+			chosen[i] = o
+			if walk(i + 1) {
+				return true
+			}
+		}
+		return false
+	}
+	if !walk(0) {
+		return 0, 0, false
+	}
+
+	first, last := chosen[0].first, chosen[0].last
+	for _, o := range chosen[1:] {
+		first = min(first, o.first)
+		last = max(last, o.last)
+	}
+	return tokens[first].start, tokens[last].end, true
+}
+
+// termAt сопоставляет слова терма со словами текста начиная с pos и возвращает
+// индекс последнего совпавшего слова текста.
+func termAt(tokens []token, pos int, key []string) (int, bool) {
+	i := pos
+	for _, k := range key {
+		if i >= len(tokens) {
+			return 0, false
+		}
+		if strings.HasPrefix(tokens[i].text, k) {
+			i++
+			continue
+		}
+		n, ok := spelledAt(tokens, i, k)
+		if !ok {
+			return 0, false
+		}
+		i += n
+	}
+	return i - 1, true
+}
+
+// spelledAt — слово k написано по буквам: каждая буква отдельным словом начиная с pos.
+// Возвращает число занятых слов.
+func spelledAt(tokens []token, pos int, k string) (int, bool) {
+	letters := []rune(k)
+	if len(letters) < minSpelled || pos+len(letters) > len(tokens) {
+		return 0, false
+	}
+	for j, r := range letters {
+		if tokens[pos+j].text != string(r) {
+			return 0, false
+		}
+	}
+	return len(letters), true
+}
+
+// distance — расстояние в словах между вхождениями; 0, если они перекрываются.
+func distance(a, b occurrence) int {
+	switch {
+	case a.last < b.first:
+		return b.first - a.last
+	case b.last < a.first:
+		return a.first - b.last
+	}
+	return 0
+}
+
+func tokenTexts(s string) []string {
+	var out []string
+	for _, t := range tokenize(s) {
+		out = append(out, t.text)
+	}
+	return out
+}
@@ -0,0 +1,77 @@
+package monitor
+
+import "testing"
+
+func TestNearRule(t *testing.T) {
+	m := NewMatcher([]string{"ищу NEAR/3 разработчик"}, nil, false).WithNormalize()
+	for _, tc := range []struct {
+		name string
+		text string
+		want string // совпавший фрагмент исходного текста, пусто — нет совпадения
+	}{
+		{"adjacent", "ищу разработчика", "ищу разработчика"},
+		{"punctuation", "Ищу, срочно!!! разработчика.", "Ищу, срочно!!! разработчика"},
+		{"line breaks", "ищу\nопытного\n\nразработчика\nна проект", "ищу\nопытного\n\nразработчика"},
+		{"reverse order", "Разработчика (Go) ищу", "Разработчика (Go) ищу"},
+		{"hyphen", "ищу-разработчика", "ищу-разработчика"},
+		{"quotes and emoji", "«ищу» 🔥 «разработчиков»", "ищу» 🔥 «разработчиков"},
+		{"too far", "ищу кота, собаку, хомяка и разработчика", ""},
+		{"only one term", "ищу дизайнера", ""},
+		{"spelled with spaces", "и щ у р а з р а б о т ч и к а", "и щ у р а з р а б о т ч и к"},
+		{"spelled with hyphens", "и-щ-у р-а-з-р-а-б-о-т-ч-и-к-а", "и-щ-у р-а-з-р-а-б-о-т-ч-и-к"},
+		{"spelled term only", "ищу р а з р а б о т ч и к а", "ищу р а з р а б о т ч и к"},
+		{"zero width between words", "ищу\u200bразработчика", "ищу\u200bразработчика"},
+		{"homoglyphs", "Ищy PaзpaбoтчикA", "Ищy PaзpaбoтчикA"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			match, ok := m.Locate(tc.text, MessageEntities{})
+			if tc.want == "" {
+				if ok {
+					t.Fatalf("Locate(%q) = %q, want no match", tc.text, tc.text[match.Start:match.End])
+				}
+				return
+			}
+			if !ok {
+				t.Fatalf("Locate(%q): no match", tc.text)
+			}
+			if got := tc.text[match.Start:match.End]; got != tc.want {
+				t.Errorf("Locate(%q) = %q, want %q", tc.text, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestNearRuleChain(t *testing.T) {
+	m := NewMatcher([]string{"ищу NEAR/2 go NEAR/2 разработчик"}, nil, false)
+	for _, tc := range []struct {
+		text string
+		want bool
+	}{
+		{"ищу Go-разработчика", true},
+		{"Ищу: go, senior разработчик", true},
+		{"разработчик на go, ищу", true},
+		{"ищу разработчика, пишущего на go", false},
+	} {
+		if got := m.Match(tc.text); got != tc.want {
+			t.Errorf("Match(%q) = %v, want %v", tc.text, got, tc.want)
+		}
+	}
+}
+
+func TestParseNearRule(t *testing.T) {
+	for _, tc := range []struct {
+		line string
+		ok   bool
+	}{
+		{"ищу NEAR/5 разработчик", true},
+		{"ищу  NEAR/2\tgo NEAR/2 разработчик", true},
+		{"ищу NEAR/0 разработчик", false},
+		{"ищу NEAR разработчик", false},
+		{"ищу NEAR/3 ", false},
+		{"ищу разработчика", false},
+	} {
+		if _, ok := parseNearRule(tc.line); ok != tc.ok {
+			t.Errorf("parseNearRule(%q) ok = %v, want %v", tc.line, ok, tc.ok)
+		}
+	}
+}
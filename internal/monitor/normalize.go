@@ -0,0 +1,287 @@
+package monitor
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Normalized — текст после нормализации и соответствие его байтов исходному тексту.
+type Normalized struct {
+	Text   string
+	starts []int
+	ends   []int
+	// Spaced — тот же текст, где невидимые разделители (U+200B и т. п.) стали
+	// пробелами; nil, если их не было. Такой символ бывает и вставкой внутри слова
+	// («раз\u200bработчик»), и единственным разделителем слов («ищу\u200bразработчика»),
+	// поэтому совпадение ищется в обоих вариантах.
+	Spaced *Normalized
+}
+
+// Span переводит байтовый диапазон нормализованного текста в диапазон исходного.
+func (n Normalized) Span(start, end int) (int, int) {
+	if start < 0 || end <= start || end > len(n.starts) {
+		return 0, 0
+	}
+	return n.starts[start], n.ends[end-1]
+}
+
+// readings — основной вариант текста и, если есть, вариант с пробелами вместо
+// невидимых разделителей.
+func (n Normalized) readings() []Normalized {
+	if n.Spaced == nil {
+		return []Normalized{n}
+	}
+	return []Normalized{n, *n.Spaced}
+}
+
+var homoglyphs = map[rune]rune{
+	// Заглавные латинские, похожие на кириллицу.
+	'A': 'а', 'B': 'в', 'C': 'с', 'E': 'е', 'H': 'н', 'K': 'к', 'M': 'м',
+	'O': 'о', 'P': 'р', 'T': 'т', 'X': 'х', 'Y': 'у',
+	// Строчные латинские.
+	'a': 'а', 'c': 'с', 'e': 'е', 'k': 'к', 'o': 'о', 'p': 'р', 'x': 'х', 'y': 'у',
+	// Leetspeak.
+	'0': 'о', '3': 'з', '4': 'ч', '6': 'б', '@': 'а',
+	'ё': 'е', 'Ё': 'е',
+}
+
+// softBreak — невидимый разделитель в промежуточном тексте, до выбора варианта.
+const softBreak = '\u200b'
+
+func isSoftBreak(r rune) bool {
+	switch r {
+	case '\u200b', '\u200c', '\u200d', '\u2060', '\ufeff':
+		return true
+	}
+	return false
+}
+
+func isInvisible(r rune) bool {
+	switch r {
+	case '\u00ad', '\ufe0e', '\ufe0f':
+		return true
+	}
+	return isSoftBreak(r) || unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Sk, r) || unicode.Is(unicode.Cf, r)
+}
+
+func isSeparator(r rune) bool {
+	return unicode.IsSpace(r) || unicode.IsPunct(r) || unicode.Is(unicode.So, r) || unicode.Is(unicode.Sm, r)
+}
+
+// isSpellSeparator — разделители, которыми слово явно пишут по буквам: «и-щ-у», «и.щ.у».
+func isSpellSeparator(r rune) bool {
+	switch r {
+	case '-', '\u2010', '\u2011', '.', '_', '*', '·', '•':
+		return true
+	}
+	return false
+}
+
+// Виды разделителей между словами в normRune.sep.
+const (
+	sepSpace = ' '
+	sepSpell = '-'
+)
+
+type normRune struct {
+	r          rune
+	start, end int
+	// sep — вид разделителя для пробела: sepSpace или sepSpell.
+	sep rune
+}
+
+// Normalize приводит текст к виду, устойчивому к обфускации: NFKC, нижний регистр,
+// латинские двойники и leetspeak → кириллица, ё → е, без невидимых символов и эмодзи,
+// разделители схлопнуты в один пробел, слова «по буквам» («и щ у») склеены.
+func Normalize(s string) Normalized {
+	return normalize(s, true)
+}
+
+// normalizeWords — Normalize без склейки слов по буквам, для правил NEAR.
+func normalizeWords(s string) Normalized {
+	return normalize(s, false)
+}
+
+func normalize(s string, join bool) Normalized {
+	rs := foldRunes(s)
+	n := build(resolveSoftBreaks(rs, false), join)
+	for _, nr := range rs {
+		if nr.r == softBreak {
+			spaced := build(resolveSoftBreaks(rs, true), join)
+			n.Spaced = &spaced
+			break
+		}
+	}
+	return n
+}
+
+func foldRunes(s string) []normRune {
+	var out []normRune
+	var sep rune
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		start, end := i, i+size
+		i = end
+		if r == '\u0306' && len(out) > 0 && out[len(out)-1].r == 'и' {
+			out[len(out)-1].r = 'й'
+			out[len(out)-1].end = end
+			continue
+		}
+		if isSoftBreak(r) {
+			if len(out) > 0 && sep == 0 {
+				out = append(out, normRune{r: softBreak, start: start, end: start})
+			}
+			continue
+		}
+		if isInvisible(r) {
+			continue
+		}
+		for _, nr := range norm.NFKC.String(string(r)) {
+			if isInvisible(nr) {
+				continue
+			}
+			if h, ok := homoglyphs[nr]; ok {
+				nr = h
+			}
+			if isSeparator(nr) {
+				switch {
+				case len(out) == 0:
+				case isSpellSeparator(nr):
+					sep = sepSpell
+				case sep == 0:
+					sep = sepSpace
+				}
+				continue
+			}
+			nr = unicode.ToLower(nr)
+			if h, ok := homoglyphs[nr]; ok {
+				nr = h
+			}
+			if sep != 0 {
+				out = append(out, normRune{r: ' ', start: start, end: start, sep: sep})
+				sep = 0
+			}
+			out = append(out, normRune{r: nr, start: start, end: end})
+		}
+	}
+	return out
+}
+
+// resolveSoftBreaks убирает невидимые разделители или, если spaced, заменяет их
+// пробелами (кроме стоящих рядом с обычным разделителем или в конце текста).
+func resolveSoftBreaks(rs []normRune, spaced bool) []normRune {
+	out := make([]normRune, 0, len(rs))
+	for i, nr := range rs {
+		if nr.r != softBreak {
+			out = append(out, nr)
+			continue
+		}
+		if !spaced || i+1 == len(rs) || rs[i+1].r == ' ' || rs[i+1].r == softBreak {
+			continue
+		}
+		out = append(out, normRune{r: ' ', start: nr.start, end: nr.end, sep: sepSpace})
+	}
+	return out
+}
+
+func build(rs []normRune, join bool) Normalized {
+	if join {
+		rs = joinSpelled(rs)
+	}
+	var sb strings.Builder
+	n := Normalized{}
+	for _, nr := range rs {
+		sb.WriteRune(nr.r)
+		for k := 0; k < utf8.RuneLen(nr.r); k++ {
+			n.starts = append(n.starts, nr.start)
+			n.ends = append(n.ends, nr.end)
+		}
+	}
+	n.Text = sb.String()
+	return n
+}
+
+// minSpelled — с какой длины цепочка однобуквенных слов считается словом по буквам.
+const minSpelled = 3
+
+// standalone — буквы, которые сами по себе бывают словами: «я и в а» — не слово по буквам.
+const standalone = "абвжикосуя"
+
+// joinSpelled склеивает слова, написанные по буквам: цепочку из minSpelled и более
+// однобуквенных слов через дефисы или точки («и-щ-у») или через пробелы, если среди
+// букв есть не встречающиеся отдельным словом («и щ у», но не «я и в а»). Смена
+// разделителя обрывает цепочку: «и-щ-у р а з р а б о т ч и к а» → «ищу разработчика».
+func joinSpelled(rs []normRune) []normRune {
+	var words [][]normRune
+	var gaps []normRune
+	word := []normRune{}
+	for _, nr := range rs {
+		if nr.r == ' ' {
+			words = append(words, word)
+			gaps = append(gaps, nr)
+			word = []normRune{}
+			continue
+		}
+		word = append(word, nr)
+	}
+	words = append(words, word)
+
+	single := func(w []normRune) bool {
+		return len(w) == 1 && unicode.IsLetter(w[0].r)
+	}
+	out := make([]normRune, 0, len(rs))
+	for i := 0; i < len(words); {
+		if i > 0 {
+			out = append(out, gaps[i-1])
+		}
+		j := i + 1
+		if single(words[i]) {
+			for j < len(words) && single(words[j]) && gaps[j-1].sep == gaps[i].sep {
+				j++
+			}
+		}
+		if j-i >= minSpelled && spelled(words[i:j], gaps[i].sep) {
+			for _, w := range words[i:j] {
+				out = append(out, w...)
+			}
+			i = j
+			continue
+		}
+		out = append(out, words[i]...)
+		i++
+	}
+	return out
+}
+
+func spelled(words [][]normRune, sep rune) bool {
+	if sep == sepSpell {
+		return true
+	}
+	for _, w := range words {
+		if !strings.ContainsRune(standalone, w[0].r) {
+			return true
+		}
+	}
+	return false
+}
+
+// lowerMapped — только нижний регистр, со смещениями для подсветки.
+func lowerMapped(s string) Normalized {
+	n := Normalized{}
+	var sb strings.Builder
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		lr := unicode.ToLower(r)
+		sb.WriteRune(lr)
+		for k := 0; k < utf8.RuneLen(lr); k++ {
+			n.starts = append(n.starts, i)
+			n.ends = append(n.ends, i+size)
+		}
+		i += size
+	}
+	n.Text = sb.String()
+	return n
+}
@@ -0,0 +1,86 @@
+package monitor
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		in     string
+		want   string
+		spaced string
+	}{
+		{"latin homoglyphs", "Ищу PaзpaбoтчикA", "ищу разработчика", ""},
+		{"leetspeak", "пр0гр@ммист", "программист", ""},
+		{"leet digits", "3аказ 4ат-б0та", "заказ чат бота", ""},
+		{"yo and case", "ЁЛКА ещё", "елка еще", ""},
+		{"combining breve", "и\u0306щу", "йщу", ""},
+		{"emoji and punctuation", "🔥ищу!!! разработчика 👉", "ищу разработчика", ""},
+		{"soft hyphen inside word", "раз\u00adработчик", "разработчик", ""},
+		{"zero width inside word", "раз\u200bработ\u2060чик", "разработчик", "раз работ чик"},
+		{"zero width between words", "ищу\u200bразработчика", "ищуразработчика", "ищу разработчика"},
+		{"zero width next to space", "ищу \u200bразработчика\u200b", "ищу разработчика", "ищу разработчика"},
+		{"spelled with spaces", "и щ у разработчика", "ищу разработчика", ""},
+		{"spelled with hyphens", "и-щ-у", "ищу", ""},
+		{"spelled with dots", "и.щ.у дизайнера", "ищу дизайнера", ""},
+		{"spelled separators split words", "и-щ-у р а з р а б о т ч и к а", "ищу разработчика", ""},
+		{"one-letter words kept", "я и в а", "я и в а", ""},
+		{"one-letter words in phrase", "а я в офис и к вам", "а я в офис и к вам", ""},
+		{"two letters not joined", "и щ", "и щ", ""},
+		{"digits not spelled", "1 2 5", "1 2 5", ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			n := Normalize(tc.in)
+			if n.Text != tc.want {
+				t.Errorf("Normalize(%q) = %q, want %q", tc.in, n.Text, tc.want)
+			}
+			switch {
+			case tc.spaced == "" && n.Spaced != nil:
+				t.Errorf("Spaced = %q, want none", n.Spaced.Text)
+			case tc.spaced != "" && n.Spaced == nil:
+				t.Errorf("Spaced = none, want %q", tc.spaced)
+			case tc.spaced != "" && n.Spaced.Text != tc.spaced:
+				t.Errorf("Spaced = %q, want %q", n.Spaced.Text, tc.spaced)
+			}
+		})
+	}
+}
+
+func TestNormalizeSpan(t *testing.T) {
+	in := "Срочно: ИЩУ\u200bPaзpaбoтчикa!"
+	n := Normalize(in)
+	for _, r := range n.readings() {
+		key := "разработчика"
+		idx := strings.Index(r.Text, key)
+		if idx < 0 {
+			continue
+		}
+		start, end := r.Span(idx, idx+len(key))
+		if got := in[start:end]; got != "Paзpaбoтчикa" {
+			t.Errorf("Span in %q = %q, want original word", r.Text, got)
+		}
+	}
+}
+
+func TestMatcherNormalized(t *testing.T) {
+	m := NewMatcher([]string{"ищу разработчика", "дизайнер"}, []string{"реклама"}, false).WithNormalize()
+	for _, tc := range []struct {
+		text string
+		want bool
+	}{
+		{"ищу\u200bразработчика на проект", true},
+		{"ИЩУ РАЗРАБОТЧ\u200bИКА", true},
+		{"и-щ-у р а з р а б о т ч и к а", true},
+		{"Нужен d1зайнер", false},
+		{"Нужен дизайнер", true},
+		{"нужен ди\u200bзайнер", true},
+		{"ищу разработчика, ре\u200bклама", false},
+		{"ищу\u200bразработчика\u200bреклама", false},
+	} {
+		if got := m.Match(tc.text); got != tc.want {
+			t.Errorf("Match(%q) = %v, want %v", tc.text, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,412 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"getclient/internal/telegramutil"
+
+	"github.com/gotd/td/telegram/query"
+	"github.com/gotd/td/telegram/query/dialogs"
+	"github.com/gotd/td/tg"
+	"go.uber.org/zap"
+)
+
+const (
+	plannerHeartbeat = 15 * time.Second
+	// Аккаунт без успешного запроса дольше plannerAliveTTL считается недоступным:
+	// это пропущенная проверка связи с запасом на её таймаут.
+	plannerAliveTTL = 2 * plannerHeartbeat
+	plannerReload   = 30 * time.Minute
+	// В текстовом отчёте перечисляется не больше стольких чатов.
+	reportMaxChats = 30
+)
+
+// Planner распределяет чаты между аккаунтами: у каждого чата один основной
+// аккаунт из состоящих в нём, остальные его сообщения не обрабатывают. Основные
+// аккаунты выбираются равномерно по нагрузке и не меняются, пока аккаунт состоит
+// в чате; если основной недоступен, чат временно берёт другой аккаунт-участник,
+// а после восстановления возвращает.
+// Чаты, о которых планировщик не знает, обрабатывают все (дубли отсекает globalSeen).
+type Planner struct {
+	accounts []string
+
+	mu      sync.Mutex
+	titles  map[string]string
+	members map[string]map[string]struct{}
+	loaded  map[string]bool
+	alive   map[string]time.Time
+	down    map[string]bool
+	// primary — основной аккаунт чата, owner — текущий с учётом недоступных.
+	primary map[string]string
+	owner   map[string]string
+	dirty   bool
+	online  string
+	// changed — доступность аккаунта изменилась; expires — когда истечёт
+	// ближайшая отметка alive и набор доступных аккаунтов может измениться сам.
+	changed bool
+	expires time.Time
+}
+
+func NewPlanner(accounts []string) *Planner {
+	return &Planner{
+		accounts: accounts,
+		titles:   make(map[string]string),
+		members:  make(map[string]map[string]struct{}),
+		loaded:   make(map[string]bool),
+		alive:    make(map[string]time.Time),
+		down:     make(map[string]bool),
+		primary:  make(map[string]string),
+		owner:    make(map[string]string),
+	}
+}
+
+// SetChats заменяет список групп аккаунта.
+func (p *Planner) SetChats(account string, chats map[string]string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	set := make(map[string]struct{}, len(chats))
+	for key, title := range chats {
+		set[key] = struct{}{}
+		p.titles[key] = title
+	}
+	p.members[account] = set
+	p.loaded[account] = true
+	p.dirty = true
+}
+
+// Observe отмечает, что аккаунт получил сообщение из чата, а значит состоит в нём.
+func (p *Planner) Observe(account, key, title string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.alive[account] = time.Now()
+	set := p.members[account]
+	if set == nil {
+		set = make(map[string]struct{})
+		p.members[account] = set
+	}
+	if _, ok := set[key]; ok {
+		return
+	}
+	set[key] = struct{}{}
+	if p.titles[key] == "" {
+		p.titles[key] = title
+	}
+	p.dirty = true
+}
+
+func (p *Planner) Alive(account string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.isOnline(account) {
+		p.changed = true
+	}
+	p.alive[account] = time.Now()
+	delete(p.down, account)
+}
+
+func (p *Planner) Down(account string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.isOnline(account) {
+		p.changed = true
+	}
+	p.down[account] = true
+}
+
+// Owns — аккаунт должен обрабатывать сообщения чата. План пересчитывается,
+// только если что-то могло измениться, а не на каждое сообщение.
+func (p *Planner) Owns(account, key string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.dirty || p.changed || !time.Now().Before(p.expires) {
+		p.replan()
+	}
+	owner, ok := p.owner[key]
+	return !ok || owner == "" || owner == account
+}
+
+// isOnline вызывается под p.mu.
+func (p *Planner) isOnline(account string) bool {
+	return !p.down[account] && time.Since(p.alive[account]) < plannerAliveTTL
+}
+
+// replan пересчитывает основные аккаунты при изменении состава чатов и текущих
+// владельцев при изменении доступности аккаунтов. Вызывается под p.mu.
+func (p *Planner) replan() {
+	var sb strings.Builder
+	p.changed, p.expires = false, time.Time{}
+	for _, a := range p.accounts {
+		if p.isOnline(a) {
+			sb.WriteString(a)
+			sb.WriteByte(',')
+			if exp := p.alive[a].Add(plannerAliveTTL); p.expires.IsZero() || exp.Before(p.expires) {
+				p.expires = exp
+			}
+		}
+	}
+	online := sb.String()
+	if !p.dirty && online == p.online {
+		return
+	}
+	if p.dirty {
+		p.assign()
+		p.dirty = false
+	}
+	p.online = online
+	for key, primary := range p.primary {
+		p.owner[key] = primary
+	}
+	// Чаты недоступных аккаунтов достаются наименее загруженным доступным участникам.
+	load := make(map[string]int)
+	var orphaned []string
+	for key, owner := range p.owner {
+		if p.isOnline(owner) {
+			load[owner]++
+			continue
+		}
+		orphaned = append(orphaned, key)
+	}
+	sort.Strings(orphaned)
+	for _, key := range orphaned {
+		p.owner[key] = p.pick(key, load, p.isOnline)
+	}
+}
+
+// assign выбирает основные аккаунты без учёта доступности для новых чатов и чатов,
+// из которых основной аккаунт вышел, начиная с чатов с наименьшим числом
+// участников, чтобы у остальных оставался выбор. Прежние назначения не меняются:
+// новый участник чата не перетасовывает план. Пока не все аккаунты загрузили
+// список чатов, план строится заново — иначе первый загрузившийся забрал бы все
+// общие чаты.
+func (p *Planner) assign() {
+	chats := make(map[string]int)
+	for _, a := range p.accounts {
+		for key := range p.members[a] {
+			chats[key]++
+		}
+	}
+	if len(p.loaded) < len(p.accounts) {
+		p.primary = make(map[string]string, len(chats))
+	}
+	load := make(map[string]int)
+	for key, a := range p.primary {
+		if _, ok := chats[key]; !ok {
+			delete(p.primary, key)
+			delete(p.owner, key)
+			continue
+		}
+		if _, member := p.members[a][key]; !member {
+			delete(p.primary, key)
+			continue
+		}
+		load[a]++
+	}
+	keys := make([]string, 0, len(chats))
+	for key := range chats {
+		if _, ok := p.primary[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if chats[keys[i]] != chats[keys[j]] {
+			return chats[keys[i]] < chats[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	all := func(string) bool { return true }
+	for _, key := range keys {
+		p.primary[key] = p.pick(key, load, all)
+	}
+}
+
+// pick выбирает из участников чата наименее загруженный подходящий аккаунт;
+// при равной нагрузке — первый по порядку в конфиге.
+func (p *Planner) pick(key string, load map[string]int, ok func(string) bool) string {
+	best := ""
+	for _, a := range p.accounts {
+		if _, member := p.members[a][key]; !member || !ok(a) {
+			continue
+		}
+		if best == "" || load[a] < load[best] {
+			best = a
+		}
+	}
+	if best != "" {
+		load[best]++
+	}
+	return best
+}
+
+// PlanAccount — строка отчёта по аккаунту.
+type PlanAccount struct {
+	Name    string `json:"name"`
+	Online  bool   `json:"online"`
+	Chats   int    `json:"chats"`
+	Primary int    `json:"primary"`
+	Owned   int    `json:"owned"`
+}
+
+// PlanChat — чат в отчёте: участники и текущий владелец.
+type PlanChat struct {
+	Key     string   `json:"key"`
+	Title   string   `json:"title"`
+	Members []string `json:"members"`
+	Owner   string   `json:"owner,omitempty"`
+}
+
+// PlanReport — покрытие чатов аккаунтами. Unmonitored — чаты, в которых нет ни
+// одного доступного аккаунта; NoBackup — чаты с единственным участником.
+type PlanReport struct {
+	Time        time.Time     `json:"time"`
+	Accounts    []PlanAccount `json:"accounts"`
+	Chats       int           `json:"chats"`
+	Covered     int           `json:"covered"`
+	Shared      int           `json:"shared"`
+	NoBackup    []PlanChat    `json:"no_backup,omitempty"`
+	Unmonitored []PlanChat    `json:"unmonitored,omitempty"`
+}
+
+func (p *Planner) Report() PlanReport {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.replan()
+	r := PlanReport{Time: time.Now(), Chats: len(p.primary)}
+	stats := make(map[string]*PlanAccount, len(p.accounts))
+	for _, a := range p.accounts {
+		r.Accounts = append(r.Accounts, PlanAccount{Name: a, Online: p.isOnline(a), Chats: len(p.members[a])})
+	}
+	for i := range r.Accounts {
+		stats[r.Accounts[i].Name] = &r.Accounts[i]
+	}
+	keys := make([]string, 0, len(p.primary))
+	for key := range p.primary {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		c := PlanChat{Key: key, Title: p.titles[key], Owner: p.owner[key]}
+		for _, a := range p.accounts {
+			if _, ok := p.members[a][key]; ok {
+				c.Members = append(c.Members, a)
+			}
+		}
+		if s := stats[p.primary[key]]; s != nil {
+			s.Primary++
+		}
+		if s := stats[c.Owner]; s != nil {
+			s.Owned++
+		}
+		if len(c.Members) > 1 {
+			r.Shared++
+		} else {
+			r.NoBackup = append(r.NoBackup, c)
+		}
+		if c.Owner == "" {
+			r.Unmonitored = append(r.Unmonitored, c)
+		} else {
+			r.Covered++
+		}
+	}
+	return r
+}
+
+// String — отчёт для бота и логов.
+func (r PlanReport) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Чатов: %d, под наблюдением: %d, в нескольких аккаунтах: %d\n", r.Chats, r.Covered, r.Shared)
+	for _, a := range r.Accounts {
+		state := "в сети"
+		if !a.Online {
+			state = "недоступен"
+		}
+		fmt.Fprintf(&sb, "%s (%s): состоит в %d, основной для %d, сейчас ведёт %d\n", a.Name, state, a.Chats, a.Primary, a.Owned)
+	}
+	if len(r.Unmonitored) > 0 {
+		fmt.Fprintf(&sb, "Без наблюдения (%d):\n", len(r.Unmonitored))
+		for i, c := range r.Unmonitored {
+			if i == reportMaxChats {
+				fmt.Fprintf(&sb, "  …и ещё %d\n", len(r.Unmonitored)-i)
+				break
+			}
+			fmt.Fprintf(&sb, "  %s — %s\n", chatLabel(c), strings.Join(c.Members, ", "))
+		}
+	}
+	if len(r.NoBackup) > 0 {
+		fmt.Fprintf(&sb, "Без запасного аккаунта: %d\n", len(r.NoBackup))
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+func chatLabel(c PlanChat) string {
+	if c.Title == "" {
+		return c.Key
+	}
+	return c.Title
+}
+
+// Run загружает группы аккаунта из диалогов и, пока ctx не отменён, раз в
+// plannerHeartbeat проверяет связь с Telegram; при ошибке аккаунт сразу считается
+// недоступным, и его чаты берут другие. Список групп перечитывается раз в
+// plannerReload. После выхода аккаунт считается недоступным.
+func (p *Planner) Run(ctx context.Context, api *tg.Client, account string, logger *zap.Logger) {
+	defer p.Down(account)
+	load := func() {
+		chats, err := accountGroups(ctx, api)
+		if err != nil {
+			logger.Warn("Список чатов не загружен", zap.String("account", account), zap.Error(err))
+			return
+		}
+		p.SetChats(account, chats)
+	}
+	p.Alive(account)
+	load()
+	heartbeat := time.NewTicker(plannerHeartbeat)
+	defer heartbeat.Stop()
+	reload := time.NewTicker(plannerReload)
+	defer reload.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-reload.C:
+			load()
+		case <-heartbeat.C:
+			hctx, cancel := context.WithTimeout(ctx, plannerHeartbeat/2)
+			_, err := api.UpdatesGetState(hctx)
+			cancel()
+			if err != nil {
+				p.Down(account)
+				continue
+			}
+			p.Alive(account)
+		}
+	}
+}
+
+// accountGroups возвращает группы и супергруппы из диалогов аккаунта: ключ → название.
+func accountGroups(ctx context.Context, api *tg.Client) (map[string]string, error) {
+	out := make(map[string]string)
+	err := query.GetDialogs(api).BatchSize(100).ForEach(ctx, func(ctx context.Context, e dialogs.Elem) error {
+		switch peer := e.Peer.(type) {
+		case *tg.InputPeerChat:
+			title := ""
+			if c, ok := e.Entities.Chat(peer.ChatID); ok {
+				title = c.Title
+			}
+			out[telegramutil.PeerKey(&tg.PeerChat{ChatID: peer.ChatID})] = title
+		case *tg.InputPeerChannel:
+			c, ok := e.Entities.Channel(peer.ChannelID)
+			if !ok || !c.Megagroup {
+				return nil
+			}
+			out[telegramutil.PeerKey(&tg.PeerChannel{ChannelID: peer.ChannelID})] = c.Title
+		}
+		return nil
+	})
+	return out, err
+}
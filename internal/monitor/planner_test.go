@@ -0,0 +1,114 @@
+package monitor
+
+import (
+	"maps"
+	"testing"
+	"time"
+)
+
+func newTestPlanner() *Planner {
+	p := NewPlanner([]string{"a", "b"})
+	p.SetChats("a", map[string]string{"c1": "Первый", "c2": "Второй"})
+	p.SetChats("b", map[string]string{"c1": "Первый"})
+	p.Alive("a")
+	p.Alive("b")
+	return p
+}
+
+func TestPlannerOwns(t *testing.T) {
+	p := newTestPlanner()
+	// c1 — общий: его получает b, у которого меньше чатов; c2 есть только у a.
+	for _, tc := range []struct {
+		account, key string
+		want         bool
+	}{
+		{"a", "c1", false},
+		{"b", "c1", true},
+		{"a", "c2", true},
+		{"b", "c2", false},
+		{"a", "unknown", true},
+		{"b", "unknown", true},
+	} {
+		if got := p.Owns(tc.account, tc.key); got != tc.want {
+			t.Errorf("Owns(%s, %s) = %v, want %v", tc.account, tc.key, got, tc.want)
+		}
+	}
+}
+
+func TestPlannerFailover(t *testing.T) {
+	p := newTestPlanner()
+
+	p.Down("b")
+	if !p.Owns("a", "c1") {
+		t.Fatal("chat of a down account did not move to a")
+	}
+	p.Alive("b")
+	if p.Owns("a", "c1") || !p.Owns("b", "c1") {
+		t.Fatal("chat did not return to b after recovery")
+	}
+
+	// Проверка связи не проходила дольше plannerAliveTTL — аккаунт недоступен,
+	// даже если Down не вызывался.
+	p.mu.Lock()
+	p.alive["b"] = time.Now().Add(-plannerAliveTTL - time.Second)
+	p.expires = p.alive["b"].Add(plannerAliveTTL)
+	p.mu.Unlock()
+	if !p.Owns("a", "c1") {
+		t.Fatal("chat of a stale account did not move to a")
+	}
+
+	p.Down("a")
+	if r := p.Report(); len(r.Unmonitored) != 2 {
+		t.Errorf("unmonitored = %v, want both chats", r.Unmonitored)
+	}
+}
+
+func TestPlannerStablePrimaries(t *testing.T) {
+	p := NewPlanner([]string{"a", "b", "c"})
+	p.SetChats("a", map[string]string{"c1": "", "c2": "", "c3": ""})
+	p.SetChats("b", map[string]string{"c1": "", "c2": ""})
+	p.SetChats("c", map[string]string{})
+	primaries := func() map[string]string {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		p.replan()
+		return maps.Clone(p.primary)
+	}
+	check := func(step string, want map[string]string) {
+		t.Helper()
+		if got := primaries(); !maps.Equal(got, want) {
+			t.Errorf("%s: primaries = %v, want %v", step, got, want)
+		}
+	}
+	check("initial", map[string]string{"c1": "b", "c2": "a", "c3": "a"})
+
+	// Новый участник общих чатов не забирает их; новый чат достаётся наименее загруженному.
+	p.Observe("c", "c1", "")
+	p.Observe("c", "c2", "")
+	p.Observe("c", "c4", "Четвёртый")
+	check("new member", map[string]string{"c1": "b", "c2": "a", "c3": "a", "c4": "c"})
+
+	// Основной вышел из чата — чат получает другой участник, остальные на месте.
+	p.SetChats("b", map[string]string{"c2": ""})
+	check("primary left", map[string]string{"c1": "c", "c2": "a", "c3": "a", "c4": "c"})
+
+	// Чат, в котором не осталось аккаунтов, из плана удаляется.
+	p.SetChats("a", map[string]string{"c1": "", "c2": ""})
+	check("chat left by all", map[string]string{"c1": "c", "c2": "a", "c4": "c"})
+}
+
+func TestPlannerOwnsDoesNotReplan(t *testing.T) {
+	p := newTestPlanner()
+	p.Owns("a", "c1")
+	p.mu.Lock()
+	// Подменённый план сохраняется, пока состав чатов и доступность не меняются.
+	p.owner["c1"] = "a"
+	p.mu.Unlock()
+	if !p.Owns("a", "c1") {
+		t.Fatal("Owns replanned without changes")
+	}
+	p.Observe("b", "c3", "")
+	if p.Owns("a", "c1") {
+		t.Fatal("Owns did not replan after a membership change")
+	}
+}
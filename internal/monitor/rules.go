@@ -0,0 +1,194 @@
+package monitor
+
+import (
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gotd/td/tg"
+
+	"getclient/internal/telegramutil"
+)
+
+type RuleSet struct {
+	Name    string
+	Matcher *Matcher
+	Senders SenderFilter
+	Chats   ChatFilter
+	// Languages — языки сообщений (см. lang.Detect), на которые срабатывает набор.
+	// Сообщения, язык которых не определён, проверяются всегда.
+	Languages []string
+
+	ForwardTo   string
+	ForwardCopy bool
+	AutoReply   ReplyRule
+}
+
+type Rules struct {
+	mu           sync.RWMutex
+	ruleSets     []RuleSet
+	mutedSenders map[int64]struct{}
+	mutedChats   map[string]struct{}
+	scripts      *Scripts
+	paused       atomic.Bool
+}
+
+func NewRules(ruleSets []RuleSet, mutedSenders []int64, mutedChats []string) *Rules {
+	r := &Rules{
+		ruleSets:     ruleSets,
+		mutedSenders: make(map[int64]struct{}, len(mutedSenders)),
+		mutedChats:   make(map[string]struct{}, len(mutedChats)),
+	}
+	for _, id := range mutedSenders {
+		r.mutedSenders[id] = struct{}{}
+	}
+	for _, key := range mutedChats {
+		r.mutedChats[key] = struct{}{}
+	}
+	return r
+}
+
+func (r *Rules) RuleSets() []RuleSet {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.ruleSets
+}
+
+func (r *Rules) SetRuleSets(ruleSets []RuleSet) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ruleSets = ruleSets
+}
+
+func (r *Rules) SetScripts(s *Scripts) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.scripts = s
+}
+
+func (r *Rules) Scripts() *Scripts {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.scripts
+}
+
+func (r *Rules) SetPaused(paused bool) {
+	r.paused.Store(paused)
+}
+
+func (r *Rules) Paused() bool {
+	return r.paused.Load()
+}
+
+func (r *Rules) MuteSender(id int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.mutedSenders[id] = struct{}{}
+}
+
+func (r *Rules) MuteChat(key string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.mutedChats[key] = struct{}{}
+}
+
+func (r *Rules) SenderMuted(id int64) bool {
+	if id == 0 {
+		return false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.mutedSenders[id]
+	return ok
+}
+
+func (r *Rules) ChatMuted(key string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.mutedChats[key]
+	return ok
+}
+
+type matchResult struct {
+	ruleSet RuleSet
+	keyword string
+	part    string
+	// Байтовый диапазон совпадения в JoinText(parts); end == 0 — без позиции.
+	start, end int
+	score      float64
+	terms      []string
+}
+
+// needsChatInfo — число участников или дата создания нужны фильтрам чатов или
+// скриптам, и их стоит запросить, если в апдейте их нет.
+func (r *Rules) needsChatInfo() bool {
+	if r.Scripts().Len() > 0 {
+		return true
+	}
+	for _, rs := range r.RuleSets() {
+		if rs.Chats.MinMembers > 0 || rs.Chats.MinAge > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// candidate — проверяемое сообщение со всем, что о нём известно.
+type candidate struct {
+	parts  []TextPart
+	text   string
+	msgID  int
+	msg    *tg.Message
+	lang   string
+	ents   MessageEntities
+	chat   telegramutil.ChatInfo
+	sender telegramutil.SenderInfo
+	// ruleSets — наборы, включённые для аккаунта; пусто — все.
+	ruleSets []string
+}
+
+// match возвращает первый сработавший набор правил. Скрипт набора — дополнительное
+// условие к ключевым фразам, а у набора без фраз — единственное.
+func (r *Rules) match(c candidate) (matchResult, bool) {
+	scripts := r.Scripts()
+	for _, rs := range r.RuleSets() {
+		if len(c.ruleSets) > 0 && !slices.Contains(c.ruleSets, rs.Name) {
+			continue
+		}
+		if rs.Senders.Ignored(c.sender) || !rs.Chats.Allows(c.chat) {
+			continue
+		}
+		if len(rs.Languages) > 0 && c.lang != "" && !slices.Contains(rs.Languages, c.lang) {
+			continue
+		}
+		if entry, ok := rs.Senders.Watch.Find(c.sender); ok {
+			return matchResult{ruleSet: rs, keyword: entry, part: PartSender}, true
+		}
+		prog := scripts.program(rs.Name)
+		if rs.Matcher == nil || rs.Matcher.Empty() {
+			if prog == nil {
+				continue
+			}
+			res := matchResult{ruleSet: rs, keyword: rs.Name + scriptExt, part: PartScript}
+			if scripts.run(rs.Name, prog, c.env(res, false)) {
+				return res, true
+			}
+			continue
+		}
+		m, ok := rs.Matcher.Locate(c.text, c.ents)
+		if !ok {
+			continue
+		}
+		res := matchResult{ruleSet: rs, keyword: m.Keyword, start: m.Start, end: m.End, score: m.Score, terms: m.Terms}
+		if m.End == 0 {
+			res.part = PartEntities
+		} else {
+			res.part = MatchedPart(c.parts, m.Start)
+		}
+		if prog != nil && !scripts.run(rs.Name, prog, c.env(res, true)) {
+			continue
+		}
+		return res, true
+	}
+	return matchResult{}, false
+}
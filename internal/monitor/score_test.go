@@ -0,0 +1,108 @@
+package monitor
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitWeight(t *testing.T) {
+	for _, tc := range []struct {
+		in     string
+		line   string
+		weight float64
+	}{
+		{"ищу разработчика", "ищу разработчика", 1},
+		{"ищу разработчика =2.5", "ищу разработчика", 2.5},
+		{"  go  =  3  ", "go", 3},
+		{"реклама =-2", "реклама", -2},
+		{"скидка = -1,5", "скидка", -1.5},
+		{"вакансия =+2", "вакансия", 2},
+		{"a=b", "a=b", 1},
+		{"2+2 =4", "2+2", 4},
+		{"формула x=1", "формула x=1", 1},
+		{"ноль =0", "ноль", 0},
+	} {
+		line, weight := splitWeight(tc.in)
+		if line != tc.line || weight != tc.weight {
+			t.Errorf("splitWeight(%q) = %q, %g, want %q, %g", tc.in, line, weight, tc.line, tc.weight)
+		}
+	}
+}
+
+func TestMatcherThreshold(t *testing.T) {
+	m := NewMatcher([]string{
+		"ищу =2",
+		"разработчик =1.5",
+		"go =1",
+		"реклама =-3",
+		"бесплатно =-0.5",
+		"ноль =0",
+	}, nil, false).WithThreshold(3.5)
+	for _, tc := range []struct {
+		name    string
+		text    string
+		ok      bool
+		score   float64
+		keyword string
+	}{
+		{"exactly threshold", "ищу разработчика", true, 3.5, "ищу"},
+		{"above threshold", "ищу go разработчика", true, 4.5, "ищу"},
+		{"below threshold", "ищу go", false, 0, ""},
+		{"negative pulls below", "ищу go разработчика, бесплатно", true, 4, "ищу"},
+		{"negative pulls far below", "реклама: ищу go разработчика", false, 0, ""},
+		{"only negative", "реклама бесплатно", false, 0, ""},
+		{"zero weight ignored", "ноль", false, 0, ""},
+		{"other word form not counted", "разработчик на go ищет", false, 0, ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			match, ok := m.Locate(tc.text, MessageEntities{})
+			if ok != tc.ok {
+				t.Fatalf("Locate(%q) ok = %v, want %v (score %g)", tc.text, ok, tc.ok, match.Score)
+			}
+			if match.Score != tc.score || match.Keyword != tc.keyword {
+				t.Errorf("Locate(%q) = score %g keyword %q, want %g %q", tc.text, match.Score, match.Keyword, tc.score, tc.keyword)
+			}
+		})
+	}
+}
+
+func TestMatcherThresholdTerms(t *testing.T) {
+	m := NewMatcher([]string{"ищу =2", "реклама =-0.5", "разработчик =2"}, nil, false).WithThreshold(3)
+	text := "Реклама! Ищу разработчика"
+	match, ok := m.Locate(text, MessageEntities{})
+	if !ok {
+		t.Fatal("no match")
+	}
+	want := []string{"ищу +2", "реклама -0.5", "разработчик +2"}
+	if !reflect.DeepEqual(match.Terms, want) {
+		t.Errorf("Terms = %q, want %q", match.Terms, want)
+	}
+	if match.Score != 3.5 {
+		t.Errorf("Score = %g, want 3.5", match.Score)
+	}
+	// Позиция — у первого положительного терма, а не у «рекламы».
+	if got := text[match.Start:match.End]; got != "Ищу" {
+		t.Errorf("matched text = %q, want %q", got, "Ищу")
+	}
+}
+
+func TestMatcherNegativeWeightWithoutThreshold(t *testing.T) {
+	m := NewMatcher([]string{"ищу разработчика", "реклама =-1", "дизайнер =5"}, nil, false)
+	for _, tc := range []struct {
+		text    string
+		keyword string
+	}{
+		{"ищу разработчика", "ищу разработчика"},
+		{"нужен дизайнер", "дизайнер"},
+		{"реклама: ищу разработчика", ""},
+		{"реклама", ""},
+	} {
+		got, ok := m.Find(tc.text)
+		if got != tc.keyword || ok != (tc.keyword != "") {
+			t.Errorf("Find(%q) = %q, %v, want %q", tc.text, got, ok, tc.keyword)
+		}
+		if match, _ := m.Locate(tc.text, MessageEntities{}); match.Score != 0 || match.Terms != nil {
+			t.Errorf("Locate(%q) scored without threshold: %g %q", tc.text, match.Score, match.Terms)
+		}
+	}
+}
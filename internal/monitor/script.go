@@ -0,0 +1,409 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/ast"
+	"github.com/expr-lang/expr/vm"
+	"go.uber.org/zap"
+)
+
+// PartScript — сработал скрипт набора правил без ключевых фраз.
+const PartScript = "script"
+
+const scriptExt = ".expr"
+
+// ScriptEnv — данные, доступные выражению: text, message, chat, sender, match
+// и функция matches(regexp) для поиска по тексту без учёта регистра.
+type ScriptEnv struct {
+	Text    ScriptText    `expr:"text"`
+	Message ScriptMessage `expr:"message"`
+	Chat    ScriptChat    `expr:"chat"`
+	Sender  ScriptSender  `expr:"sender"`
+	Match   ScriptMatch   `expr:"match"`
+
+	TextMatches func(pattern string) bool `expr:"matches_"`
+}
+
+type ScriptText struct {
+	Body  string `expr:"body"`
+	Len   int    `expr:"len"`
+	Lines int    `expr:"lines"`
+}
+
+type ScriptMessage struct {
+	ID       int      `expr:"id"`
+	Language string   `expr:"language"`
+	Reply    bool     `expr:"reply"`
+	Media    bool     `expr:"media"`
+	URLs     []string `expr:"urls"`
+	Domains  []string `expr:"domains"`
+	Hashtags []string `expr:"hashtags"`
+	Mentions []string `expr:"mentions"`
+}
+
+type ScriptChat struct {
+	Key      string `expr:"key"`
+	Title    string `expr:"title"`
+	Username string `expr:"username"`
+	Members  int    `expr:"members"`
+	Public   bool   `expr:"public"`
+	Forum    bool   `expr:"forum"`
+	AgeDays  int    `expr:"age_days"`
+	Language string `expr:"language"`
+}
+
+type ScriptSender struct {
+	ID       int64  `expr:"id"`
+	Name     string `expr:"name"`
+	Username string `expr:"username"`
+	Bot      bool   `expr:"bot"`
+	Channel  bool   `expr:"channel"`
+}
+
+type ScriptMatch struct {
+	Found   bool     `expr:"found"`
+	RuleSet string   `expr:"rule_set"`
+	Keyword string   `expr:"keyword"`
+	In      string   `expr:"in"`
+	Score   float64  `expr:"score"`
+	Terms   []string `expr:"terms"`
+}
+
+// Scripts — выражения из каталога: файл <набор правил>.expr — дополнительное
+// условие для набора. Файлы перечитываются при изменении.
+type Scripts struct {
+	dir    string
+	logger *zap.Logger
+	mu     sync.RWMutex
+	progs  map[string]*Script
+	sig    string
+}
+
+// Script — скомпилированное выражение и шаблоны-литералы из его вызовов matches(...).
+type Script struct {
+	prog     *vm.Program
+	patterns map[string]*regexp.Regexp
+}
+
+// LoadScripts читает и проверяет все скрипты каталога. Отсутствующий каталог — не ошибка.
+func LoadScripts(dir string, logger *zap.Logger) (*Scripts, error) {
+	s := &Scripts{dir: dir, logger: logger, progs: map[string]*Script{}}
+	if err := s.reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Names возвращает наборы правил, для которых есть скрипты.
+func (s *Scripts) Names() []string {
+	if s == nil {
+		return nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	names := make([]string, 0, len(s.progs))
+	for name := range s.progs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (s *Scripts) Len() int {
+	if s == nil {
+		return 0
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.progs)
+}
+
+func (s *Scripts) program(ruleSet string) *Script {
+	if s == nil {
+		return nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.progs[ruleSet]
+}
+
+// Watch перечитывает каталог раз в interval. Если новая версия скрипта не компилируется,
+// остаются прежние скрипты.
+func (s *Scripts) Watch(ctx context.Context, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			sig, err := s.signature()
+			if err != nil || sig == s.sig {
+				continue
+			}
+			if err := s.reload(); err != nil {
+				s.sig = sig
+				s.logger.Warn("Скрипты не перезагружены", zap.Error(err))
+				continue
+			}
+			s.logger.Info("Скрипты перезагружены", zap.Strings("rule_sets", s.Names()))
+		}
+	}
+}
+
+func (s *Scripts) files() ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(s.dir, "*"+scriptExt))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// signature меняется при добавлении, удалении или изменении файла.
+func (s *Scripts) signature() (string, error) {
+	files, err := s.files()
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	for _, f := range files {
+		fi, err := os.Stat(f)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&sb, "%s:%d:%d;", f, fi.Size(), fi.ModTime().UnixNano())
+	}
+	return sb.String(), nil
+}
+
+func (s *Scripts) reload() error {
+	sig, err := s.signature()
+	if err != nil {
+		return err
+	}
+	files, err := s.files()
+	if err != nil {
+		return err
+	}
+	progs := make(map[string]*Script, len(files))
+	var errs []error
+	for _, f := range files {
+		code, err := os.ReadFile(f)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		prog, err := CompileScript(string(code))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", f, err))
+			continue
+		}
+		progs[strings.TrimSuffix(filepath.Base(f), scriptExt)] = prog
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	s.mu.Lock()
+	s.progs, s.sig = progs, sig
+	s.mu.Unlock()
+	return nil
+}
+
+// CompileScript проверяет выражение: синтаксис, имена полей, логический результат
+// и регулярные выражения, переданные в matches(...) строкой.
+func CompileScript(code string) (*Script, error) {
+	if strings.TrimSpace(code) == "" {
+		return nil, fmt.Errorf("пустой скрипт")
+	}
+	check := &patternCheck{patterns: map[string]*regexp.Regexp{}}
+	prog, err := expr.Compile(rewriteMatches(code), expr.Env(ScriptEnv{}), expr.AsBool(), expr.Patch(check))
+	if err != nil {
+		return nil, err
+	}
+	if len(check.errs) > 0 {
+		return nil, errors.Join(check.errs...)
+	}
+	return &Script{prog: prog, patterns: check.patterns}, nil
+}
+
+// patternCheck компилирует шаблоны-литералы в вызовах matches(...).
+type patternCheck struct {
+	patterns map[string]*regexp.Regexp
+	errs     []error
+}
+
+func (c *patternCheck) Visit(node *ast.Node) {
+	call, ok := (*node).(*ast.CallNode)
+	if !ok || len(call.Arguments) != 1 {
+		return
+	}
+	if id, ok := call.Callee.(*ast.IdentifierNode); !ok || id.Value != "matches_" {
+		return
+	}
+	if pattern, ok := call.Arguments[0].(*ast.StringNode); ok {
+		re, err := regexp.Compile("(?i)" + pattern.Value)
+		if err != nil {
+			c.errs = append(c.errs, fmt.Errorf("matches(%q): %w", pattern.Value, err))
+			return
+		}
+		c.patterns[pattern.Value] = re
+	}
+}
+
+// run выполняет скрипт набора; ошибка выполнения считается несовпадением.
+func (s *Scripts) run(ruleSet string, sc *Script, env ScriptEnv) bool {
+	env.TextMatches = func(pattern string) bool {
+		re := sc.regexp(pattern)
+		if re == nil {
+			var err error
+			if re, err = regexp.Compile("(?i)" + pattern); err != nil {
+				s.logger.Debug("Неверное регулярное выражение в скрипте", zap.String("rule_set", ruleSet), zap.String("pattern", pattern), zap.Error(err))
+				return false
+			}
+		}
+		return re.MatchString(env.Text.Body)
+	}
+	out, err := expr.Run(sc.prog, env)
+	if err != nil {
+		s.logger.Warn("Script failed", zap.String("rule_set", ruleSet), zap.Error(err))
+		return false
+	}
+	ok, _ := out.(bool)
+	return ok
+}
+
+// regexp возвращает шаблон-литерал, скомпилированный вместе со скриптом. Шаблоны,
+// собранные из данных сообщения, не кэшируются: их набор не ограничен.
+func (sc *Script) regexp(pattern string) *regexp.Regexp {
+	return sc.patterns[pattern]
+}
+
+// rewriteMatches заменяет вызов matches(...) на функцию matches_: в expr «matches» —
+// бинарный оператор (text matches "re"), и как имя функции парсер его не принимает.
+func rewriteMatches(code string) string {
+	const word = "matches"
+	var sb strings.Builder
+	var quote byte
+	for i := 0; i < len(code); i++ {
+		c := code[i]
+		if quote != 0 {
+			sb.WriteByte(c)
+			switch {
+			case c == '\\' && quote != '`' && i+1 < len(code):
+				i++
+				sb.WriteByte(code[i])
+			case c == quote:
+				quote = 0
+			}
+			continue
+		}
+		if c == '"' || c == '\'' || c == '`' {
+			quote = c
+		}
+		if strings.HasPrefix(code[i:], word) && isCallStart(code, i, i+len(word)) {
+			sb.WriteString(word + "_")
+			i += len(word) - 1
+			continue
+		}
+		sb.WriteByte(c)
+	}
+	return sb.String()
+}
+
+// isCallStart — слово code[start:end] стоит на месте операнда и за ним идёт «(».
+func isCallStart(code string, start, end int) bool {
+	if end < len(code) && isIdentByte(code[end]) {
+		return false
+	}
+	if !strings.HasPrefix(strings.TrimLeft(code[end:], " \t\r\n"), "(") {
+		return false
+	}
+	before := strings.TrimRight(code[:start], " \t\r\n")
+	if before == "" {
+		return true
+	}
+	if start > 0 && isIdentByte(code[start-1]) {
+		return false
+	}
+	last := before[len(before)-1]
+	if !isIdentByte(last) && last != ')' && last != ']' && last != '"' && last != '\'' && last != '`' {
+		return true
+	}
+	// После not/and/or/in слово — операнд, а не оператор.
+	i := len(before)
+	for i > 0 && isIdentByte(before[i-1]) {
+		i--
+	}
+	switch before[i:] {
+	case "not", "and", "or", "in", "return":
+		return true
+	}
+	return false
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || c == '.' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func (in candidate) env(res matchResult, found bool) ScriptEnv {
+	env := ScriptEnv{
+		Text: ScriptText{
+			Body:  in.text,
+			Len:   utf8.RuneCountInString(in.text),
+			Lines: len(strings.FieldsFunc(in.text, func(r rune) bool { return r == '\n' })),
+		},
+		Message: ScriptMessage{
+			ID:       in.msgID,
+			Language: in.lang,
+			URLs:     in.ents.URLs,
+			Domains:  in.ents.Domains,
+			Hashtags: in.ents.Hashtags,
+			Mentions: in.ents.Mentions,
+		},
+		Chat: ScriptChat{
+			Key:      in.chat.Key,
+			Title:    in.chat.Title,
+			Username: in.chat.Username,
+			Members:  in.chat.Members,
+			Public:   in.chat.Username != "",
+			Forum:    in.chat.Forum,
+			Language: in.chat.Language,
+		},
+		Sender: ScriptSender{
+			ID:       in.sender.ID,
+			Name:     in.sender.Name,
+			Username: in.sender.Username,
+			Bot:      in.sender.Bot,
+			Channel:  in.sender.IsChannel,
+		},
+		Match: ScriptMatch{
+			Found:   found,
+			RuleSet: res.ruleSet.Name,
+			Keyword: res.keyword,
+			In:      res.part,
+			Score:   res.score,
+			Terms:   res.terms,
+		},
+	}
+	if !in.chat.Created.IsZero() {
+		env.Chat.AgeDays = int(time.Since(in.chat.Created).Hours() / 24)
+	}
+	if in.msg != nil {
+		env.Message.Reply = in.msg.ReplyTo != nil
+		env.Message.Media = in.msg.Media != nil
+	}
+	return env
+}
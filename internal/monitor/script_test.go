@@ -0,0 +1,96 @@
+package monitor
+
+import (
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestCompileScript(t *testing.T) {
+	for _, tc := range []struct {
+		code string
+		ok   bool
+	}{
+		{`matches("ищу\\s+разраб")`, true},
+		{`text.len > 20 and not matches('реклам')`, true},
+		{`text.body matches "^ищу"`, true},
+		{`matches("ищу(")`, false},
+		{`text.len > 0 and matches("[а-я")`, false},
+		{`text.body matches "ищу("`, false},
+		{`text.len`, false},
+		{`unknown > 1`, false},
+		{` `, false},
+	} {
+		_, err := CompileScript(tc.code)
+		if (err == nil) != tc.ok {
+			t.Errorf("CompileScript(%q) error = %v, want ok = %v", tc.code, err, tc.ok)
+		}
+	}
+}
+
+func TestScriptRun(t *testing.T) {
+	s := &Scripts{logger: zap.NewNop()}
+	env := func(body string) ScriptEnv {
+		return ScriptEnv{Text: ScriptText{Body: body, Len: len([]rune(body))}, Chat: ScriptChat{Title: "("}}
+	}
+	for _, tc := range []struct {
+		code string
+		body string
+		want bool
+	}{
+		{`matches("ищу\\s+разраб")`, "Ищу   разработчика", true},
+		{`matches("ищу\\s+разраб")`, "Нужен дизайнер", false},
+		// Шаблон из данных сообщения не проверить заранее: неверный — несовпадение.
+		{`matches(chat.title)`, "(", false},
+		{`matches(chat.title) or text.len > 0`, "текст", true},
+	} {
+		prog, err := CompileScript(tc.code)
+		if err != nil {
+			t.Fatalf("CompileScript(%q): %v", tc.code, err)
+		}
+		for i := 0; i < 2; i++ {
+			if got := s.run("test", prog, env(tc.body)); got != tc.want {
+				t.Errorf("run(%q, %q) = %v, want %v", tc.code, tc.body, got, tc.want)
+			}
+		}
+	}
+}
+
+func TestRewriteMatches(t *testing.T) {
+	for _, tc := range []struct {
+		in, want string
+	}{
+		{`matches("a")`, `matches_("a")`},
+		{`not matches ("a")`, `not matches_ ("a")`},
+		{`text.body matches "a"`, `text.body matches "a"`},
+		{`text.body matches ("a")`, `text.body matches ("a")`},
+		{`"matches(" == text.body`, `"matches(" == text.body`},
+		{`matches("a") and matches('b')`, `matches_("a") and matches_('b')`},
+	} {
+		if got := rewriteMatches(tc.in); got != tc.want {
+			t.Errorf("rewriteMatches(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestCompileScriptPatterns(t *testing.T) {
+	sc, err := CompileScript(`matches("ищу\\s+разраб") or matches('go') or matches(chat.title) or text.body matches "^a"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sc.patterns) != 2 || sc.regexp(`ищу\s+разраб`) == nil || sc.regexp("go") == nil {
+		t.Errorf("literal patterns = %v", sc.patterns)
+	}
+
+	// Шаблоны из данных сообщения компилируются на месте и не копятся.
+	s := &Scripts{logger: zap.NewNop()}
+	for _, title := range []string{"Golang", "Python", "Rust"} {
+		env := ScriptEnv{Text: ScriptText{Body: "вакансия " + title}, Chat: ScriptChat{Title: title}}
+		if !s.run("test", sc, env) {
+			t.Errorf("run with chat %q = false", title)
+		}
+	}
+	if len(sc.patterns) != 2 {
+		t.Errorf("patterns after run = %d, want 2", len(sc.patterns))
+	}
+}
@@ -0,0 +1,78 @@
+package monitor
+
+import (
+	"strconv"
+	"strings"
+
+	"getclient/internal/telegramutil"
+)
+
+const PartSender = "sender"
+
+// SenderList — список отправителей по ID или @username.
+type SenderList struct {
+	ids       map[int64]string
+	usernames map[string]string
+}
+
+func NewSenderList(entries []string) SenderList {
+	l := SenderList{ids: make(map[int64]string), usernames: make(map[string]string)}
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if id, err := strconv.ParseInt(entry, 10, 64); err == nil {
+			l.ids[id] = entry
+			continue
+		}
+		l.usernames[strings.ToLower(strings.TrimPrefix(entry, "@"))] = entry
+	}
+	return l
+}
+
+func (l SenderList) Len() int {
+	return len(l.ids) + len(l.usernames)
+}
+
+// Find возвращает запись списка, под которую попал отправитель.
+func (l SenderList) Find(s telegramutil.SenderInfo) (string, bool) {
+	if s.ID != 0 {
+		if entry, ok := l.ids[s.ID]; ok {
+			return entry, true
+		}
+	}
+	if s.Username != "" {
+		if entry, ok := l.usernames[strings.ToLower(s.Username)]; ok {
+			return entry, true
+		}
+	}
+	return "", false
+}
+
+type SenderFilter struct {
+	Block SenderList
+	Watch SenderList
+
+	IgnoreBots       bool
+	IgnoreNoUsername bool
+	IgnoreChannels   bool
+}
+
+func (f SenderFilter) Ignored(s telegramutil.SenderInfo) bool {
+	if _, ok := f.Block.Find(s); ok {
+		return true
+	}
+	if _, ok := f.Watch.Find(s); ok {
+		return false
+	}
+	switch {
+	case f.IgnoreBots && s.Bot:
+		return true
+	case f.IgnoreChannels && s.IsChannel:
+		return true
+	case f.IgnoreNoUsername && s.Username == "":
+		return true
+	}
+	return false
+}
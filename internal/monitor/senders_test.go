@@ -0,0 +1,65 @@
+package monitor
+
+import (
+	"testing"
+
+	"getclient/internal/telegramutil"
+)
+
+func TestSenderListFind(t *testing.T) {
+	l := NewSenderList([]string{"123", "@Ivan_Dev", " anna ", ""})
+	if l.Len() != 3 {
+		t.Errorf("Len = %d, want 3", l.Len())
+	}
+	for _, tc := range []struct {
+		sender telegramutil.SenderInfo
+		want   string
+	}{
+		{telegramutil.SenderInfo{ID: 123}, "123"},
+		{telegramutil.SenderInfo{ID: 5, Username: "ivan_dev"}, "@Ivan_Dev"},
+		{telegramutil.SenderInfo{Username: "ANNA"}, "anna"},
+		{telegramutil.SenderInfo{ID: 5, Username: "other"}, ""},
+		{telegramutil.SenderInfo{}, ""},
+	} {
+		got, ok := l.Find(tc.sender)
+		if got != tc.want || ok != (tc.want != "") {
+			t.Errorf("Find(%+v) = %q, %v, want %q", tc.sender, got, ok, tc.want)
+		}
+	}
+}
+
+func TestSenderFilterIgnored(t *testing.T) {
+	f := SenderFilter{
+		Block:            NewSenderList([]string{"@spammer", "1"}),
+		Watch:            NewSenderList([]string{"@watched_bot", "2"}),
+		IgnoreBots:       true,
+		IgnoreNoUsername: true,
+		IgnoreChannels:   true,
+	}
+	for _, tc := range []struct {
+		name   string
+		sender telegramutil.SenderInfo
+		want   bool
+	}{
+		{"blocked username", telegramutil.SenderInfo{ID: 10, Username: "Spammer"}, true},
+		{"blocked id", telegramutil.SenderInfo{ID: 1, Username: "any"}, true},
+		{"bot", telegramutil.SenderInfo{ID: 11, Username: "some_bot", Bot: true}, true},
+		{"channel", telegramutil.SenderInfo{ID: 12, Username: "news", IsChannel: true}, true},
+		{"no username", telegramutil.SenderInfo{ID: 13, Name: "Иван"}, true},
+		{"regular", telegramutil.SenderInfo{ID: 14, Username: "ivan"}, false},
+		{"watched bot", telegramutil.SenderInfo{ID: 15, Username: "watched_bot", Bot: true}, false},
+		{"watched without username", telegramutil.SenderInfo{ID: 2}, false},
+	} {
+		if got := f.Ignored(tc.sender); got != tc.want {
+			t.Errorf("%s: Ignored = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+	// Блокировка сильнее наблюдения.
+	both := SenderFilter{Block: NewSenderList([]string{"3"}), Watch: NewSenderList([]string{"3"})}
+	if !both.Ignored(telegramutil.SenderInfo{ID: 3}) {
+		t.Error("blocked and watched sender is not ignored")
+	}
+	if (SenderFilter{}).Ignored(telegramutil.SenderInfo{Bot: true, IsChannel: true}) {
+		t.Error("empty filter ignored a sender")
+	}
+}
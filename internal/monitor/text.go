@@ -0,0 +1,97 @@
+package monitor
+
+import (
+	"strings"
+
+	"github.com/gotd/td/tg"
+)
+
+const (
+	PartText    = "text"
+	PartPoll    = "poll"
+	PartButtons = "buttons"
+	PartWebPage = "webpage"
+)
+
+const partSeparator = "\n\n"
+
+type TextPart struct {
+	Source string
+	Text   string
+}
+
+// ExtractText собирает текст сообщения для поиска: сам текст или подпись к медиа,
+// опрос, подписи inline-кнопок и превью ссылки.
+func ExtractText(msg *tg.Message) []TextPart {
+	if msg == nil {
+		return nil
+	}
+	var parts []TextPart
+	add := func(source string, texts ...string) {
+		var lines []string
+		for _, t := range texts {
+			if t = strings.TrimSpace(t); t != "" {
+				lines = append(lines, t)
+			}
+		}
+		if len(lines) > 0 {
+			parts = append(parts, TextPart{Source: source, Text: strings.Join(lines, "\n")})
+		}
+	}
+
+	add(PartText, msg.Message)
+
+	switch media := msg.Media.(type) {
+	case *tg.MessageMediaPoll:
+		texts := []string{media.Poll.Question}
+		for _, a := range media.Poll.Answers {
+			texts = append(texts, a.Text)
+		}
+		add(PartPoll, texts...)
+	case *tg.MessageMediaWebPage:
+		if page, ok := media.Webpage.(*tg.WebPage); ok {
+			add(PartWebPage, page.SiteName, page.Title, page.Description)
+		}
+	}
+
+	var rows []tg.KeyboardButtonRow
+	switch markup := msg.ReplyMarkup.(type) {
+	case *tg.ReplyInlineMarkup:
+		rows = markup.Rows
+	case *tg.ReplyKeyboardMarkup:
+		rows = markup.Rows
+	}
+	var buttons []string
+	for _, row := range rows {
+		for _, b := range row.Buttons {
+			buttons = append(buttons, b.GetText())
+		}
+	}
+	add(PartButtons, buttons...)
+
+	return parts
+}
+
+func JoinText(parts []TextPart) string {
+	texts := make([]string, 0, len(parts))
+	for _, p := range parts {
+		texts = append(texts, p.Text)
+	}
+	return strings.Join(texts, partSeparator)
+}
+
+// MatchedPart возвращает источник, которому принадлежит байтовое смещение в JoinText(parts).
+func MatchedPart(parts []TextPart, offset int) string {
+	pos := 0
+	for _, p := range parts {
+		pos += len(p.Text)
+		if offset < pos {
+			return p.Source
+		}
+		pos += len(partSeparator)
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return parts[len(parts)-1].Source
+}
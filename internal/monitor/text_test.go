@@ -0,0 +1,128 @@
+package monitor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gotd/td/tg"
+)
+
+func TestExtractText(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		msg  *tg.Message
+		want []TextPart
+	}{
+		{
+			name: "caption",
+			msg: &tg.Message{
+				Message: "  Ищу дизайнера, портфолио в ЛС  ",
+				Media:   &tg.MessageMediaPhoto{},
+			},
+			want: []TextPart{{PartText, "Ищу дизайнера, портфолио в ЛС"}},
+		},
+		{
+			name: "poll",
+			msg: &tg.Message{
+				Media: &tg.MessageMediaPoll{Poll: tg.Poll{
+					Question: "Кто возьмёт заказ на бота?",
+					Answers: []tg.PollAnswer{
+						{Text: "Я", Option: []byte{0}},
+						{Text: " ", Option: []byte{1}},
+						{Text: "Никто", Option: []byte{2}},
+					},
+				}},
+			},
+			want: []TextPart{{PartPoll, "Кто возьмёт заказ на бота?\nЯ\nНикто"}},
+		},
+		{
+			name: "inline buttons",
+			msg: &tg.Message{
+				Message: "Вакансия",
+				ReplyMarkup: &tg.ReplyInlineMarkup{Rows: []tg.KeyboardButtonRow{
+					{Buttons: []tg.KeyboardButtonClass{
+						&tg.KeyboardButtonURL{Text: "Откликнуться", URL: "https://example.com"},
+						&tg.KeyboardButtonCallback{Text: "Go-разработчик"},
+					}},
+					{Buttons: []tg.KeyboardButtonClass{&tg.KeyboardButtonCallback{Text: "Удалёнка"}}},
+				}},
+			},
+			want: []TextPart{
+				{PartText, "Вакансия"},
+				{PartButtons, "Откликнуться\nGo-разработчик\nУдалёнка"},
+			},
+		},
+		{
+			name: "webpage preview",
+			msg: &tg.Message{
+				Message: "https://hh.ru/vacancy/1",
+				Media: &tg.MessageMediaWebPage{Webpage: &tg.WebPage{
+					SiteName:    "hh.ru",
+					Title:       "Backend-разработчик (Go)",
+					Description: "Удалённая работа, полный день",
+				}},
+			},
+			want: []TextPart{
+				{PartText, "https://hh.ru/vacancy/1"},
+				{PartWebPage, "hh.ru\nBackend-разработчик (Go)\nУдалённая работа, полный день"},
+			},
+		},
+		{
+			name: "pending webpage",
+			msg: &tg.Message{
+				Message: "ссылка",
+				Media:   &tg.MessageMediaWebPage{Webpage: &tg.WebPagePending{}},
+			},
+			want: []TextPart{{PartText, "ссылка"}},
+		},
+		{
+			name: "empty",
+			msg:  &tg.Message{},
+			want: nil,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := ExtractText(tc.msg)
+			if len(got) != len(tc.want) {
+				t.Fatalf("ExtractText = %q, want %q", got, tc.want)
+			}
+			for i := range got {
+				if got[i] != tc.want[i] {
+					t.Errorf("part %d = %q, want %q", i, got[i], tc.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestMatchedPart(t *testing.T) {
+	parts := []TextPart{
+		{PartText, "Вакансия"},
+		{PartPoll, "Кто возьмёт заказ?"},
+		{PartButtons, "Откликнуться"},
+	}
+	text := JoinText(parts)
+	for _, tc := range []struct {
+		needle string
+		want   string
+	}{
+		{"Вакансия", PartText},
+		{"возьмёт", PartPoll},
+		{"Откликнуться", PartButtons},
+	} {
+		offset := strings.Index(text, tc.needle)
+		if got := MatchedPart(parts, offset); got != tc.want {
+			t.Errorf("MatchedPart(%q) = %q, want %q", tc.needle, got, tc.want)
+		}
+	}
+	// Смещение на разделителе относится к следующей части, за концом — к последней.
+	if got := MatchedPart(parts, len("Вакансия")+1); got != PartPoll {
+		t.Errorf("MatchedPart(separator) = %q, want %q", got, PartPoll)
+	}
+	if got := MatchedPart(parts, len(text)+10); got != PartButtons {
+		t.Errorf("MatchedPart(past end) = %q, want %q", got, PartButtons)
+	}
+	if got := MatchedPart(nil, 0); got != "" {
+		t.Errorf("MatchedPart(nil) = %q, want empty", got)
+	}
+}
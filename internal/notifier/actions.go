@@ -0,0 +1,81 @@
+package notifier
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+const (
+	ActionMuteSender = "ms"
+	ActionMuteChat   = "mc"
+	ActionStopword   = "sw"
+	ActionDone       = "done"
+	ActionLabel      = "cl"
+	ActionNoop       = "noop"
+)
+
+// Telegram ограничивает callback_data 64 байтами.
+const maxCallbackData = 64
+
+func ParseAction(data string) (action, arg string) {
+	action, arg, _ = strings.Cut(data, ":")
+	return action, arg
+}
+
+func actionData(action, arg string) string {
+	if arg == "" {
+		return action
+	}
+	data := action + ":" + arg
+	if len(data) > maxCallbackData {
+		return ""
+	}
+	return data
+}
+
+func alertKeyboard(n Notification, feedback bool) *InlineKeyboard {
+	var row []InlineButton
+	if n.SenderID != 0 {
+		row = append(row, InlineButton{Text: "🔇 Автор", CallbackData: actionData(ActionMuteSender, strconv.FormatInt(n.SenderID, 10))})
+	}
+	if data := actionData(ActionMuteChat, n.ChatKey); n.ChatKey != "" && data != "" {
+		row = append(row, InlineButton{Text: "🔇 Чат", CallbackData: data})
+	}
+	if data := actionData(ActionStopword, n.RuleSet); data != "" {
+		row = append(row, InlineButton{Text: "➕ Стоп-слово", CallbackData: data})
+	}
+	row = append(row, InlineButton{Text: "✅ Обработано", CallbackData: ActionDone})
+	kb := &InlineKeyboard{InlineKeyboard: [][]InlineButton{row}}
+	if key := n.HitKey(); feedback && key != "" {
+		relevant, irrelevant := actionData(ActionLabel, "1:"+key), actionData(ActionLabel, "0:"+key)
+		if relevant != "" && irrelevant != "" {
+			kb.InlineKeyboard = append(kb.InlineKeyboard, []InlineButton{
+				{Text: "👍 В тему", CallbackData: relevant},
+				{Text: "👎 Мимо", CallbackData: irrelevant},
+			})
+		}
+	}
+	return kb
+}
+
+// ParseLabel разбирает аргумент ActionLabel: «1:<ключ алерта>» или «0:<ключ алерта>».
+func ParseLabel(arg string) (key string, relevant bool, ok bool) {
+	flag, key, found := strings.Cut(arg, ":")
+	if !found || key == "" || (flag != "0" && flag != "1") {
+		return "", false, false
+	}
+	return key, flag == "1", true
+}
+
+func DoneKeyboard() *InlineKeyboard {
+	return &InlineKeyboard{InlineKeyboard: [][]InlineButton{{{Text: "✅ Обработано", CallbackData: ActionNoop}}}}
+}
+
+func encodeKeyboard(kb *InlineKeyboard) string {
+	data, err := json.Marshal(kb)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
@@ -0,0 +1,132 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const DefaultBotAPIURL = "https://api.telegram.org"
+
+// maxMessageLen — предел длины текста сообщения в Bot API, в символах.
+const maxMessageLen = 4096
+
+type APIError struct {
+	Method      string
+	Code        int
+	Description string
+	RetryAfter  time.Duration
+}
+
+func (e *APIError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("%s: %d %s (retry after %s)", e.Method, e.Code, e.Description, e.RetryAfter)
+	}
+	return fmt.Sprintf("%s: %d %s", e.Method, e.Code, e.Description)
+}
+
+func (e *APIError) Permanent() bool {
+	switch e.Code {
+	case http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound:
+		return true
+	}
+	return false
+}
+
+// isFormatError — Telegram отклонил текст из-за разметки или длины; такое
+// сообщение можно отправить ещё раз простым укороченным текстом.
+func isFormatError(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != http.StatusBadRequest {
+		return false
+	}
+	desc := strings.ToLower(apiErr.Description)
+	return strings.Contains(desc, "can't parse entities") || strings.Contains(desc, "message is too long")
+}
+
+var htmlTag = regexp.MustCompile(`<[^>]*>`)
+
+// plainText убирает HTML-разметку и обрезает текст до maxMessageLen символов.
+func plainText(s string) string {
+	s = html.UnescapeString(htmlTag.ReplaceAllString(s, ""))
+	if r := []rune(s); len(r) > maxMessageLen {
+		s = string(r[:maxMessageLen-1]) + "…"
+	}
+	return s
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string   { return e.err.Error() }
+func (e permanentError) Unwrap() error   { return e.err }
+func (e permanentError) Permanent() bool { return true }
+
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return permanentError{err: err}
+}
+
+func IsPermanent(err error) bool {
+	var p interface{ Permanent() bool }
+	return errors.As(err, &p) && p.Permanent()
+}
+
+type apiResponse struct {
+	OK          bool            `json:"ok"`
+	Result      json.RawMessage `json:"result"`
+	ErrorCode   int             `json:"error_code"`
+	Description string          `json:"description"`
+	Parameters  *struct {
+		RetryAfter int `json:"retry_after"`
+	} `json:"parameters"`
+}
+
+func (b *TelegramBot) call(ctx context.Context, method string, form url.Values, result any) error {
+	endpoint := fmt.Sprintf("%s/bot%s/%s", b.apiURL, b.token, method)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := b.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+
+	var r apiResponse
+	if err := json.Unmarshal(body, &r); err != nil {
+		return fmt.Errorf("%s: status %s, body: %s", method, resp.Status, string(body))
+	}
+	if !r.OK {
+		apiErr := &APIError{Method: method, Code: r.ErrorCode, Description: r.Description}
+		if apiErr.Code == 0 {
+			apiErr.Code = resp.StatusCode
+		}
+		if r.Parameters != nil && r.Parameters.RetryAfter > 0 {
+			apiErr.RetryAfter = time.Duration(r.Parameters.RetryAfter) * time.Second
+		}
+		return apiErr
+	}
+	if result != nil && len(r.Result) > 0 {
+		return json.Unmarshal(r.Result, result)
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+// fakeBotAPI — локальный Bot API: отвечает по очереди заготовленными ответами
+// (последний повторяется) и запоминает запросы.
+type fakeBotAPI struct {
+	t   *testing.T
+	srv *httptest.Server
+
+	mu        sync.Mutex
+	responses map[string][]fakeResponse
+	calls     []fakeCall
+}
+
+type fakeResponse struct {
+	status int
+	body   any
+}
+
+type fakeCall struct {
+	method string
+	form   url.Values
+	at     time.Time
+}
+
+func newFakeBotAPI(t *testing.T) *fakeBotAPI {
+	f := &fakeBotAPI{t: t, responses: make(map[string][]fakeResponse)}
+	f.srv = httptest.NewServer(http.HandlerFunc(f.serve))
+	t.Cleanup(f.srv.Close)
+	return f
+}
+
+func (f *fakeBotAPI) serve(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		f.t.Errorf("ParseForm: %v", err)
+	}
+	method := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+
+	f.mu.Lock()
+	f.calls = append(f.calls, fakeCall{method: method, form: r.PostForm, at: time.Now()})
+	resp := fakeResponse{status: http.StatusOK, body: map[string]any{"ok": true, "result": true}}
+	if queue := f.responses[method]; len(queue) > 0 {
+		resp = queue[0]
+		if len(queue) > 1 {
+			f.responses[method] = queue[1:]
+		}
+	}
+	f.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(resp.status)
+	_ = json.NewEncoder(w).Encode(resp.body)
+}
+
+// reply задаёт очередь ответов на метод.
+func (f *fakeBotAPI) reply(method string, responses ...fakeResponse) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.responses[method] = responses
+}
+
+func (f *fakeBotAPI) callsTo(method string) []fakeCall {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []fakeCall
+	for _, c := range f.calls {
+		if c.method == method {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+func okResult(result any) fakeResponse {
+	return fakeResponse{status: http.StatusOK, body: map[string]any{"ok": true, "result": result}}
+}
+
+func apiError(code int, desc string, retryAfter int) fakeResponse {
+	body := map[string]any{"ok": false, "error_code": code, "description": desc}
+	if retryAfter > 0 {
+		body["parameters"] = map[string]any{"retry_after": retryAfter}
+	}
+	return fakeResponse{status: code, body: body}
+}
+
+// newTestBot создаёт бота с уникальным токеном, чтобы тесты не делили ограничитель.
+func newTestBot(t *testing.T, f *fakeBotAPI, chatID int64) *TelegramBot {
+	return NewTelegramBot("test-"+t.Name(), chatID).WithAPIURL(f.srv.URL)
+}
+
+func TestNotifyRetryAfter(t *testing.T) {
+	f := newFakeBotAPI(t)
+	f.reply("sendMessage",
+		apiError(http.StatusTooManyRequests, "Too Many Requests: retry after 1", 1),
+		okResult(map[string]any{"message_id": 1}),
+	)
+	b := newTestBot(t, f, -100)
+
+	if err := b.Notify(context.Background(), Notification{Text: "ищу разработчика"}); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	calls := f.callsTo("sendMessage")
+	if len(calls) != 2 {
+		t.Fatalf("sendMessage calls = %d, want 2", len(calls))
+	}
+	if gap := calls[1].at.Sub(calls[0].at); gap < time.Second {
+		t.Errorf("retry after %s, want at least retry_after = 1s", gap)
+	}
+}
+
+func TestNotifyPermanentErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		code int
+		desc string
+	}{
+		{"bad request", http.StatusBadRequest, "Bad Request: chat not found"},
+		{"forbidden", http.StatusForbidden, "Forbidden: bot was blocked by the user"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			f := newFakeBotAPI(t)
+			f.reply("sendMessage", apiError(tc.code, tc.desc, 0))
+			b := newTestBot(t, f, -100)
+
+			err := b.Notify(context.Background(), Notification{Text: "текст"})
+			if err == nil || !IsPermanent(err) {
+				t.Fatalf("Notify error = %v, want permanent", err)
+			}
+			if n := len(f.callsTo("sendMessage")); n != 1 {
+				t.Errorf("sendMessage calls = %d, want 1 (no retry)", n)
+			}
+		})
+	}
+}
+
+func TestNotifyFormatFallback(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		desc string
+		text string
+	}{
+		{"entities", "Bad Request: can't parse entities: unsupported start tag", "ищу разработчика"},
+		{"too long", "Bad Request: message is too long", strings.Repeat("я", 5000)},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			f := newFakeBotAPI(t)
+			f.reply("sendMessage",
+				apiError(http.StatusBadRequest, tc.desc, 0),
+				okResult(map[string]any{"message_id": 1}),
+			)
+			b := newTestBot(t, f, -100)
+
+			n := Notification{Text: tc.text, Link: "https://t.me/c/1/2", From: "Иван"}
+			if err := b.Notify(context.Background(), n); err != nil {
+				t.Fatalf("Notify: %v", err)
+			}
+			calls := f.callsTo("sendMessage")
+			if len(calls) != 2 {
+				t.Fatalf("sendMessage calls = %d, want 2", len(calls))
+			}
+			if calls[0].form.Get("parse_mode") != "HTML" {
+				t.Fatalf("first attempt parse_mode = %q, want HTML", calls[0].form.Get("parse_mode"))
+			}
+			retry := calls[1].form
+			if retry.Get("parse_mode") != "" {
+				t.Errorf("fallback parse_mode = %q, want plain text", retry.Get("parse_mode"))
+			}
+			text := retry.Get("text")
+			if strings.Contains(text, "<a ") {
+				t.Errorf("fallback text still has markup: %q", text[:50])
+			}
+			if n := utf8.RuneCountInString(text); n > maxMessageLen {
+				t.Errorf("fallback text length = %d, want <= %d", n, maxMessageLen)
+			}
+		})
+	}
+}
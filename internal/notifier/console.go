@@ -0,0 +1,75 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+type Console struct {
+	out       io.Writer
+	templates Templates
+	mu        sync.Mutex
+}
+
+func NewConsole(out io.Writer) *Console {
+	return &Console{out: out}
+}
+
+func (c *Console) WithTemplates(t Templates) *Console {
+	c.templates = t
+	return c
+}
+
+func (c *Console) Notify(ctx context.Context, n Notification) error {
+	text := consoleFormat(n)
+	if s, ok := c.templates.Render(n); ok {
+		text = "\n" + s + "\n\n"
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, err := io.WriteString(c.out, text)
+	return err
+}
+
+func consoleFormat(n Notification) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "\n\033[32m[ALERT]\033[0m Найдено аккаунтом: \033[1m%s\033[0m\n", n.Account)
+	fmt.Fprintf(&sb, "Чат: \033[33m%s\033[0m\n", n.ChatTitle)
+	fmt.Fprintf(&sb, "От: \033[36m%s\033[0m\n", consoleSender(n))
+	if n.Link != "" {
+		fmt.Fprintf(&sb, "Ссылка: \033[34m%s\033[0m\n", n.Link)
+	}
+	for _, q := range n.Context {
+		fmt.Fprintf(&sb, "  \033[90m%s\033[0m\n", q)
+	}
+	if n.ReplyTo != nil {
+		fmt.Fprintf(&sb, "В ответ на: \033[90m%s\033[0m\n", n.ReplyTo)
+	}
+	text := n.Text
+	if before, match, after, ok := n.splitMatch(); ok {
+		text = before + "\033[1;33m" + match + "\033[0m" + after
+	}
+	for _, line := range n.InfoLines() {
+		if line != "" {
+			fmt.Fprintf(&sb, "%s\n", line)
+		}
+	}
+	fmt.Fprintf(&sb, "Текст: %s\n\n", text)
+	return sb.String()
+}
+
+func consoleSender(n Notification) string {
+	switch {
+	case n.SenderUsername != "":
+		return "@" + n.SenderUsername
+	case n.SenderName != "":
+		return n.SenderName
+	case n.SenderID != 0:
+		return fmt.Sprintf("id:%d", n.SenderID)
+	}
+	return ""
+}
@@ -0,0 +1,53 @@
+package notifier
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+type File struct {
+	path      string
+	templates Templates
+	mu        sync.Mutex
+}
+
+func NewFile(path string) *File {
+	return &File{path: strings.TrimSpace(path)}
+}
+
+// WithTemplates добавляет в каждую строку поле formatted — текст алерта по шаблону.
+func (f *File) WithTemplates(t Templates) *File {
+	f.templates = t
+	return f
+}
+
+func (f *File) Notify(ctx context.Context, n Notification) error {
+	if f.path == "" {
+		return nil
+	}
+	if n.Time.IsZero() {
+		n.Time = time.Now()
+	}
+	line, err := f.templates.payload(n)
+	if err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if dir := filepath.Dir(f.path); dir != "." && dir != "" {
+		_ = os.MkdirAll(dir, 0o700)
+	}
+	out, err := os.OpenFile(f.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	_, err = out.Write(append(line, '\n'))
+	return err
+}
@@ -0,0 +1,115 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// PriorityLow — получатель только для алертов с низкой релевантностью.
+	PriorityLow = "low"
+	// PriorityAll — получатель всех алертов независимо от релевантности.
+	PriorityAll = "all"
+)
+
+type Target struct {
+	Name     string
+	Notifier Notifier
+	Priority string
+}
+
+type Multi struct {
+	targets []Target
+	logger  *zap.Logger
+}
+
+func NewMulti(logger *zap.Logger, targets ...Target) *Multi {
+	return &Multi{targets: targets, logger: logger}
+}
+
+func (m *Multi) Len() int {
+	return len(m.targets)
+}
+
+// route выбирает получателей: низкоприоритетные алерты уходят получателям с PriorityLow,
+// а если таких нет — обычным, чтобы алерт не потерялся. Если внешний фильтр задал
+// n.Route, из остальных получателей остаются только перечисленные; если ни одного
+// из них нет, алерт маршрутизируется как обычно.
+func (m *Multi) route(n Notification) []Target {
+	if len(n.Route) > 0 {
+		if out, ok := m.routeNamed(n.Route); ok {
+			return out
+		}
+		m.logger.Warn("Получатели из внешнего фильтра не найдены", zap.Strings("route", n.Route))
+	}
+	low := false
+	for _, t := range m.targets {
+		if t.Priority == PriorityLow {
+			low = true
+		}
+	}
+	var out []Target
+	for _, t := range m.targets {
+		switch {
+		case t.Priority == PriorityAll:
+		case n.LowPriority && low:
+			if t.Priority != PriorityLow {
+				continue
+			}
+		case t.Priority == PriorityLow:
+			continue
+		}
+		out = append(out, t)
+	}
+	return out
+}
+
+// routeNamed возвращает перечисленных получателей и получателей с PriorityAll;
+// ok == false, если ни одно имя не совпало.
+func (m *Multi) routeNamed(names []string) (out []Target, ok bool) {
+	for _, t := range m.targets {
+		named := slices.Contains(names, t.Name)
+		if named || t.Priority == PriorityAll {
+			out = append(out, t)
+		}
+		ok = ok || named
+	}
+	return out, ok
+}
+
+func (m *Multi) Notify(ctx context.Context, n Notification) error {
+	targets := m.route(n)
+	if len(targets) == 0 {
+		return nil
+	}
+
+	errs := make([]error, len(targets))
+	var wg sync.WaitGroup
+	for i, t := range targets {
+		wg.Add(1)
+		go func(i int, t Target) {
+			defer wg.Done()
+			if err := t.Notifier.Notify(ctx, n); err != nil {
+				errs[i] = fmt.Errorf("%s: %w", t.Name, err)
+				m.logger.Warn("Notify failed", zap.String("destination", t.Name), zap.Error(err))
+			}
+		}(i, t)
+	}
+	wg.Wait()
+
+	failed := 0
+	for _, err := range errs {
+		if err != nil {
+			failed++
+		}
+	}
+	if failed < len(targets) {
+		return nil
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,63 @@
+package notifier
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestMultiRoute(t *testing.T) {
+	var mu sync.Mutex
+	var got []string
+	target := func(name, priority string) Target {
+		return Target{Name: name, Priority: priority, Notifier: notifyFunc(func(ctx context.Context, n Notification) error {
+			mu.Lock()
+			defer mu.Unlock()
+			got = append(got, name)
+			return nil
+		})}
+	}
+	m := NewMulti(zap.NewNop(),
+		target("console", PriorityAll),
+		target("bot", ""),
+		target("webhook", ""),
+		target("review", PriorityLow),
+	)
+
+	for _, tc := range []struct {
+		name string
+		n    Notification
+		want []string
+	}{
+		{"normal", Notification{}, []string{"bot", "console", "webhook"}},
+		{"low priority", Notification{LowPriority: true}, []string{"console", "review"}},
+		{"hook route", Notification{Route: []string{"webhook"}}, []string{"console", "webhook"}},
+		{"hook route partly unknown", Notification{Route: []string{"nope", "bot"}}, []string{"bot", "console"}},
+		{"hook route unknown", Notification{Route: []string{"nope"}}, []string{"bot", "console", "webhook"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got = nil
+			if err := m.Notify(context.Background(), tc.n); err != nil {
+				t.Fatal(err)
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("targets = %v, want %v", got, tc.want)
+			}
+		})
+	}
+
+	// Без получателей PriorityLow низкоприоритетные алерты идут как обычные.
+	got = nil
+	m = NewMulti(zap.NewNop(), target("console", PriorityAll), target("bot", ""))
+	if err := m.Notify(context.Background(), Notification{LowPriority: true}); err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(got)
+	if want := []string{"bot", "console"}; !slices.Equal(got, want) {
+		t.Errorf("low priority without review targets = %v, want %v", got, want)
+	}
+}
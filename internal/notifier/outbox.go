@@ -0,0 +1,197 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	outboxMinBackoff = 2 * time.Second
+	outboxMaxBackoff = 5 * time.Minute
+)
+
+type Outbox struct {
+	dir    string
+	name   string
+	next   Notifier
+	logger *zap.Logger
+
+	mu   sync.Mutex
+	seq  uint64
+	wake chan struct{}
+}
+
+func NewOutbox(dir, name string, next Notifier, logger *zap.Logger) (*Outbox, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("outbox dir: %w", err)
+	}
+	return &Outbox{
+		dir:    dir,
+		name:   name,
+		next:   next,
+		logger: logger,
+		wake:   make(chan struct{}, 1),
+	}, nil
+}
+
+func (o *Outbox) Name() string {
+	return o.name
+}
+
+func (o *Outbox) Notify(ctx context.Context, n Notification) error {
+	data, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+
+	o.mu.Lock()
+	o.seq++
+	base := fmt.Sprintf("%020d-%06d", time.Now().UnixNano(), o.seq%1000000)
+	o.mu.Unlock()
+
+	tmp := filepath.Join(o.dir, base+".tmp")
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, filepath.Join(o.dir, base+".json")); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+func (o *Outbox) Depth() int {
+	return len(o.pending())
+}
+
+func (o *Outbox) Run(ctx context.Context) {
+	backoff := outboxMinBackoff
+	for {
+		files := o.pending()
+		if len(files) == 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-o.wake:
+			}
+			continue
+		}
+
+		for i, path := range files {
+			if ctx.Err() != nil {
+				return
+			}
+			err := o.deliver(ctx, path)
+			if err == nil {
+				backoff = outboxMinBackoff
+				continue
+			}
+			if ctx.Err() != nil {
+				return
+			}
+			if IsPermanent(err) {
+				o.logger.Error("Уведомление отклонено получателем",
+					zap.String("destination", o.name),
+					zap.String("file", strings.TrimSuffix(path, ".json")+".failed"),
+					zap.Error(err),
+				)
+				_ = os.Rename(path, strings.TrimSuffix(path, ".json")+".failed")
+				continue
+			}
+
+			o.logger.Warn("Доставка отложена",
+				zap.String("destination", o.name),
+				zap.Int("pending", len(files)-i),
+				zap.Duration("retry_in", backoff),
+				zap.Error(err),
+			)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+			if backoff > outboxMaxBackoff {
+				backoff = outboxMaxBackoff
+			}
+			break
+		}
+	}
+}
+
+func (o *Outbox) deliver(ctx context.Context, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var n Notification
+	if err := json.Unmarshal(data, &n); err != nil {
+		o.logger.Warn("Повреждённая запись в очереди удалена", zap.String("file", path), zap.Error(err))
+		return os.Remove(path)
+	}
+	if err := o.next.Notify(ctx, n); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+func (o *Outbox) pending() []string {
+	entries, err := os.ReadDir(o.dir)
+	if err != nil {
+		return nil
+	}
+	var out []string
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		out = append(out, filepath.Join(o.dir, e.Name()))
+	}
+	sort.Strings(out)
+	return out
+}
+
+// PendingCount — число неотправленных уведомлений в папках очередей dirs.
+func PendingCount(dirs ...string) int {
+	count := 0
+	for _, dir := range dirs {
+		count += len((&Outbox{dir: dir}).pending())
+	}
+	return count
+}
+
+// Orphaned возвращает папки очередей внутри root, которых нет среди dirs, но в
+// которых остались уведомления: получатель удалён или переименован, и их никто
+// не отправит.
+func Orphaned(root string, dirs []string) []string {
+	known := make(map[string]bool, len(dirs))
+	for _, dir := range dirs {
+		known[filepath.Clean(dir)] = true
+	}
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil
+	}
+	var out []string
+	for _, e := range entries {
+		dir := filepath.Join(root, e.Name())
+		if e.IsDir() && !known[dir] && PendingCount(dir) > 0 {
+			out = append(out, dir)
+		}
+	}
+	return out
+}
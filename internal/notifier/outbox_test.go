@@ -0,0 +1,118 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type notifyFunc func(ctx context.Context, n Notification) error
+
+func (f notifyFunc) Notify(ctx context.Context, n Notification) error { return f(ctx, n) }
+
+func TestOutboxPermanentFailure(t *testing.T) {
+	dir := t.TempDir()
+	o, err := NewOutbox(dir, "test", notifyFunc(func(ctx context.Context, n Notification) error {
+		return Permanent(errors.New("403 Forbidden"))
+	}), zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Notify(context.Background(), Notification{Text: "текст"}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		o.Run(ctx)
+		close(done)
+	}()
+	deadline := time.Now().Add(2 * time.Second)
+	for o.Depth() > 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	if o.Depth() != 0 {
+		t.Fatalf("pending = %d, want 0", o.Depth())
+	}
+	failed, _ := filepath.Glob(filepath.Join(dir, "*.failed"))
+	if len(failed) != 1 {
+		t.Fatalf("failed files = %d, want 1", len(failed))
+	}
+}
+
+func TestOutboxRetriesTemporaryFailure(t *testing.T) {
+	dir := t.TempDir()
+	attempts := 0
+	o, err := NewOutbox(dir, "test", notifyFunc(func(ctx context.Context, n Notification) error {
+		attempts++
+		if attempts == 1 {
+			return errors.New("connection refused")
+		}
+		return nil
+	}), zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Notify(context.Background(), Notification{Text: "текст"}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*outboxMinBackoff+time.Second)
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		o.Run(ctx)
+		close(done)
+	}()
+	for o.Depth() > 0 && ctx.Err() == nil {
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	if attempts != 2 {
+		t.Errorf("attempts = %d, want 2", attempts)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 0 {
+		t.Errorf("outbox not empty: %d files", len(entries))
+	}
+}
+
+func TestPendingCountOrphaned(t *testing.T) {
+	root := t.TempDir()
+	write := func(dir, name string) {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(root, dir, name), []byte("{}"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("bot", "1.json")
+	write("bot", "2.json")
+	write("bot", "3.failed")
+	write("hook", "1.json")
+	write("removed", "1.json")
+	write("removed", "2.json")
+	write("empty", "1.failed")
+
+	dirs := []string{filepath.Join(root, "bot"), filepath.Join(root, "hook"), filepath.Join(root, "missing")}
+	if got := PendingCount(dirs...); got != 3 {
+		t.Errorf("PendingCount = %d, want 3", got)
+	}
+	got := Orphaned(root, dirs)
+	if want := []string{filepath.Join(root, "removed")}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Orphaned = %v, want %v", got, want)
+	}
+}
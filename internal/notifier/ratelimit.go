@@ -0,0 +1,103 @@
+package notifier
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Ограничения Bot API: около одного сообщения в секунду в личный чат
+// и 20 сообщений в минуту в группу.
+const (
+	privateChatInterval = time.Second
+	groupChatInterval   = 3 * time.Second
+	groupChatBurst      = 3
+)
+
+var chatLimiters sync.Map
+
+type chatLimiter struct {
+	mu      sync.Mutex
+	buckets map[int64]*bucket
+}
+
+type bucket struct {
+	tokens   float64
+	burst    float64
+	interval time.Duration
+	last     time.Time
+	blocked  time.Time
+}
+
+func limiterFor(token string) *chatLimiter {
+	l, _ := chatLimiters.LoadOrStore(token, &chatLimiter{buckets: make(map[int64]*bucket)})
+	return l.(*chatLimiter)
+}
+
+func (l *chatLimiter) bucket(chatID int64, now time.Time) *bucket {
+	b, ok := l.buckets[chatID]
+	if !ok {
+		b = &bucket{interval: privateChatInterval, burst: 1, last: now}
+		if chatID < 0 {
+			b.interval = groupChatInterval
+			b.burst = groupChatBurst
+		}
+		b.tokens = b.burst
+		l.buckets[chatID] = b
+	}
+	return b
+}
+
+func (l *chatLimiter) reserve(chatID int64) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	b := l.bucket(chatID, now)
+	b.tokens += float64(now.Sub(b.last)) / float64(b.interval)
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+
+	var wait time.Duration
+	if b.blocked.After(now) {
+		wait = b.blocked.Sub(now)
+	}
+	b.tokens--
+	if b.tokens < 0 {
+		if d := time.Duration(-b.tokens * float64(b.interval)); d > wait {
+			wait = d
+		}
+	}
+	return wait
+}
+
+func (l *chatLimiter) Wait(ctx context.Context, chatID int64) error {
+	return sleepCtx(ctx, l.reserve(chatID))
+}
+
+func (l *chatLimiter) Block(chatID int64, d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	b := l.bucket(chatID, now)
+	if until := now.Add(d); until.After(b.blocked) {
+		b.blocked = until
+	}
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
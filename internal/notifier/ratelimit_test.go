@@ -0,0 +1,43 @@
+package notifier
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLimiterSpacing(t *testing.T) {
+	l := &chatLimiter{buckets: make(map[int64]*bucket)}
+
+	// Личный чат: без запаса, следующее сообщение через секунду.
+	if d := l.reserve(1); d != 0 {
+		t.Errorf("private first wait = %s, want 0", d)
+	}
+	if d := l.reserve(1); d < privateChatInterval-50*time.Millisecond || d > privateChatInterval {
+		t.Errorf("private second wait = %s, want ~%s", d, privateChatInterval)
+	}
+
+	// Группа: groupChatBurst сообщений сразу, дальше по одному в groupChatInterval.
+	for i := 0; i < groupChatBurst; i++ {
+		if d := l.reserve(-1); d != 0 {
+			t.Errorf("group message %d wait = %s, want 0", i+1, d)
+		}
+	}
+	if d := l.reserve(-1); d < groupChatInterval-50*time.Millisecond || d > groupChatInterval {
+		t.Errorf("group wait after burst = %s, want ~%s", d, groupChatInterval)
+	}
+	if d := l.reserve(-1); d < 2*groupChatInterval-50*time.Millisecond {
+		t.Errorf("group second wait after burst = %s, want ~%s", d, 2*groupChatInterval)
+	}
+}
+
+func TestLimiterBlock(t *testing.T) {
+	l := &chatLimiter{buckets: make(map[int64]*bucket)}
+	l.Block(-2, 5*time.Second)
+	if d := l.reserve(-2); d < 4*time.Second {
+		t.Errorf("wait after Block = %s, want ~5s", d)
+	}
+	// Блокировка одного чата не задерживает другие.
+	if d := l.reserve(-3); d != 0 {
+		t.Errorf("other chat wait = %s, want 0", d)
+	}
+}
@@ -2,19 +2,88 @@ package notifier
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"io"
 	"net/http"
 	"net/url"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
+
+	"getclient/internal/extract"
 )
 
 type Notification struct {
-	ChatTitle string
-	From      string
-	Link      string
-	Text      string
+	ChatTitle string `json:"chat_title"`
+	From      string `json:"from"`
+	Link      string `json:"link,omitempty"`
+	Text      string `json:"text"`
+
+	Account        string    `json:"account,omitempty"`
+	RuleSet        string    `json:"rule_set,omitempty"`
+	Keyword        string    `json:"keyword,omitempty"`
+	MatchedIn      string    `json:"matched_in,omitempty"`
+	MatchStart     int       `json:"match_start,omitempty"`
+	MatchEnd       int       `json:"match_end,omitempty"`
+	Score          float64   `json:"score,omitempty"`
+	Terms          []string  `json:"terms,omitempty"`
+	Relevance      float64   `json:"relevance,omitempty"`
+	LowPriority    bool      `json:"low_priority,omitempty"`
+	Language       string    `json:"language,omitempty"`
+	SenderID       int64     `json:"sender_id,omitempty"`
+	SenderName     string    `json:"sender_name,omitempty"`
+	SenderUsername string    `json:"sender_username,omitempty"`
+	ChatKey        string    `json:"chat_key,omitempty"`
+	MessageID      int       `json:"message_id,omitempty"`
+	Time           time.Time `json:"time"`
+
+	ReplyTo *Quote  `json:"reply_to,omitempty"`
+	Context []Quote `json:"context,omitempty"`
+
+	// Extracted — контакты, суммы и ссылки из текста.
+	Extracted *extract.Fields `json:"extracted,omitempty"`
+
+	// Extra и Route заполняет внешний фильтр (см. monitor.Hook).
+	Extra map[string]string `json:"extra,omitempty"`
+	Route []string          `json:"route,omitempty"`
+}
+
+// HitKey — ключ сообщения «чат:id», под которым алерт хранится для разметки.
+func (n Notification) HitKey() string {
+	if n.ChatKey == "" || n.MessageID == 0 {
+		return ""
+	}
+	return n.ChatKey + ":" + strconv.Itoa(n.MessageID)
+}
+
+// splitMatch делит текст на части до, внутри и после найденного совпадения.
+func (n Notification) splitMatch() (before, match, after string, ok bool) {
+	if n.MatchEnd <= n.MatchStart || n.MatchEnd > len(n.Text) {
+		return n.Text, "", "", false
+	}
+	if !utf8.RuneStart(n.Text[n.MatchStart]) || (n.MatchEnd < len(n.Text) && !utf8.RuneStart(n.Text[n.MatchEnd])) {
+		return n.Text, "", "", false
+	}
+	return n.Text[:n.MatchStart], n.Text[n.MatchStart:n.MatchEnd], n.Text[n.MatchEnd:], true
+}
+
+type Quote struct {
+	MessageID int    `json:"message_id"`
+	From      string `json:"from"`
+	Text      string `json:"text"`
+}
+
+func (q Quote) String() string {
+	text := strings.Join(strings.Fields(q.Text), " ")
+	if r := []rune(text); len(r) > 200 {
+		text = string(r[:200]) + "…"
+	}
+	if q.From == "" {
+		return text
+	}
+	return q.From + ": " + text
 }
 
 type Notifier interface {
@@ -22,21 +91,54 @@ type Notifier interface {
 }
 
 type TelegramBot struct {
-	token  string
-	chatID int64
-	http   *http.Client
+	token   string
+	chatID  int64
+	apiURL  string
+	http    *http.Client
+	limiter *chatLimiter
+
+	templates Templates
+	actions   bool
+	feedback  bool
+	topics    *topicRouter
 }
 
 func NewTelegramBot(token string, chatID int64) *TelegramBot {
+	token = strings.TrimSpace(token)
 	return &TelegramBot{
-		token:  strings.TrimSpace(token),
+		token:  token,
 		chatID: chatID,
+		apiURL: DefaultBotAPIURL,
 		http: &http.Client{
 			Timeout: 7 * time.Second,
 		},
+		limiter: limiterFor(token),
 	}
 }
 
+func (b *TelegramBot) WithAPIURL(apiURL string) *TelegramBot {
+	if apiURL = strings.TrimRight(strings.TrimSpace(apiURL), "/"); apiURL != "" {
+		b.apiURL = apiURL
+	}
+	return b
+}
+
+func (b *TelegramBot) WithTemplates(t Templates) *TelegramBot {
+	b.templates = t
+	return b
+}
+
+// WithFeedback добавляет к кнопкам алерта разметку для классификатора («в тему» / «мимо»).
+func (b *TelegramBot) WithFeedback(enabled bool) *TelegramBot {
+	b.feedback = enabled
+	return b
+}
+
+func (b *TelegramBot) WithActions(enabled bool) *TelegramBot {
+	b.actions = enabled
+	return b
+}
+
 func (b *TelegramBot) Enabled() bool {
 	return b != nil && b.token != "" && b.chatID != 0
 }
@@ -47,6 +149,9 @@ func (b *TelegramBot) Notify(ctx context.Context, n Notification) error {
 	}
 
 	text, parseMode := format(n)
+	if s, ok := b.templates.Render(n); ok {
+		text, parseMode = s, "HTML"
+	}
 	form := url.Values{}
 	form.Set("chat_id", fmt.Sprintf("%d", b.chatID))
 	form.Set("text", text)
@@ -54,41 +159,161 @@ func (b *TelegramBot) Notify(ctx context.Context, n Notification) error {
 		form.Set("parse_mode", parseMode)
 	}
 	form.Set("disable_web_page_preview", "true")
+	if b.actions {
+		if kb := encodeKeyboard(alertKeyboard(n, b.feedback)); kb != "" {
+			form.Set("reply_markup", kb)
+		}
+	}
 
-	endpoint := fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", b.token)
+	thread := b.topicFor(ctx, n)
+	if thread != 0 {
+		form.Set("message_thread_id", strconv.Itoa(thread))
+	}
+
+	err := b.send(ctx, b.chatID, "sendMessage", form, nil)
+	if thread != 0 && isThreadNotFound(err) {
+		form.Del("message_thread_id")
+		err = b.send(ctx, b.chatID, "sendMessage", form, nil)
+	}
+	if isFormatError(err) {
+		// Разметка не разобралась или текст слишком длинный — шлём простым текстом.
+		form.Set("text", plainText(text))
+		form.Del("parse_mode")
+		err = b.send(ctx, b.chatID, "sendMessage", form, nil)
+	}
+	return err
+}
+
+func (b *TelegramBot) send(ctx context.Context, chatID int64, method string, form url.Values, result any) error {
+	const attempts = 3
 
 	var lastErr error
-	for i := 0; i < 3; i++ {
-		req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
-		if err != nil {
+	for i := 0; i < attempts; i++ {
+		if err := b.limiter.Wait(ctx, chatID); err != nil {
 			return err
 		}
-		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-
-		resp, err := b.http.Do(req)
+		err := b.call(ctx, method, form, result)
 		if err == nil {
-			defer resp.Body.Close()
-			if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-				return nil
-			}
-			body, _ := io.ReadAll(resp.Body)
-			lastErr = fmt.Errorf("status %s, body: %s", resp.Status, string(body))
-		} else {
-			lastErr = err
+			return nil
+		}
+		if IsPermanent(err) || ctx.Err() != nil {
+			return err
 		}
+		lastErr = err
 
-		time.Sleep(time.Second * time.Duration(i+1))
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+			b.limiter.Block(chatID, apiErr.RetryAfter)
+			continue
+		}
+		if i < attempts-1 {
+			if err := sleepCtx(ctx, time.Second*time.Duration(i+1)); err != nil {
+				return err
+			}
+		}
 	}
 
-	return fmt.Errorf("after 3 attempts: %w", lastErr)
+	return fmt.Errorf("after %d attempts: %w", attempts, lastErr)
 }
 
 func format(n Notification) (string, string) {
+	text, mode := formatBody(n)
+	for _, line := range n.InfoLines() {
+		if line == "" {
+			continue
+		}
+		if mode == "HTML" {
+			line = "<i>" + htmlEscape(line) + "</i>"
+		}
+		text += "\n" + line
+	}
+	return text, mode
+}
+
+// ScoreLine — оценка сообщения и сработавшие термы, если набор правил работает по порогу.
+func (n Notification) ScoreLine() string {
+	if len(n.Terms) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("Оценка %g: %s", n.Score, strings.Join(n.Terms, ", "))
+}
+
+// RelevanceLine — оценка классификатора, если он уже обучен.
+func (n Notification) RelevanceLine() string {
+	if n.Relevance == 0 {
+		return ""
+	}
+	return fmt.Sprintf("Релевантность: %.0f%%", n.Relevance*100)
+}
+
+// InfoLines — строки под текстом алерта: оценка, релевантность, извлечённые
+// контакты и суммы, поля от внешнего фильтра.
+func (n Notification) InfoLines() []string {
+	lines := []string{n.ScoreLine(), n.RelevanceLine()}
+	lines = append(lines, n.ExtractedLines()...)
+	return append(lines, n.ExtraLines()...)
+}
+
+// ExtractedLines — телефоны, юзернеймы, email, суммы и ссылки из текста.
+func (n Notification) ExtractedLines() []string {
+	f := n.Extracted
+	if f == nil {
+		return nil
+	}
+	var prices []string
+	for _, p := range f.Prices {
+		prices = append(prices, p.String())
+	}
+	var lines []string
+	for _, item := range []struct {
+		label  string
+		values []string
+	}{
+		{"Телефоны", f.Phones},
+		{"Telegram", f.Usernames},
+		{"Email", f.Emails},
+		{"Суммы", prices},
+		{"Ссылки", f.URLs},
+	} {
+		if len(item.values) > 0 {
+			lines = append(lines, item.label+": "+strings.Join(item.values, ", "))
+		}
+	}
+	return lines
+}
+
+// ExtraLines — поля от внешнего фильтра в виде «ключ: значение», по алфавиту.
+func (n Notification) ExtraLines() []string {
+	keys := make([]string, 0, len(n.Extra))
+	for k := range n.Extra {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	lines := make([]string, 0, len(keys))
+	for _, k := range keys {
+		lines = append(lines, k+": "+n.Extra[k])
+	}
+	return lines
+}
+
+func formatBody(n Notification) (string, string) {
 	msg := strings.TrimSpace(n.Text)
 	from := strings.TrimSpace(n.From)
 
+	if quotes := formatQuotes(n); quotes != "" {
+		body := textHTML(n)
+		if n.Link != "" && msg != "" {
+			body = fmt.Sprintf(`<a href="%s">%s</a>`, htmlEscape(n.Link), textHTML(n))
+		}
+		out := quotes + "\n" + body
+		if from != "" {
+			out += "\n" + htmlEscape(from)
+		}
+		return out, "HTML"
+	}
+
 	if n.Link != "" && msg != "" {
-		linked := fmt.Sprintf(`<a href="%s">%s</a>`, htmlEscape(n.Link), htmlEscape(msg))
+		linked := fmt.Sprintf(`<a href="%s">%s</a>`, htmlEscape(n.Link), textHTML(n))
 		if from != "" {
 			return linked + "\n" + htmlEscape(from), "HTML"
 		}
@@ -104,6 +329,25 @@ func format(n Notification) (string, string) {
 	return from, ""
 }
 
+func textHTML(n Notification) string {
+	before, match, after, ok := n.splitMatch()
+	if !ok {
+		return htmlEscape(strings.TrimSpace(n.Text))
+	}
+	return strings.TrimSpace(htmlEscape(before) + "<b>" + htmlEscape(match) + "</b>" + htmlEscape(after))
+}
+
+func formatQuotes(n Notification) string {
+	var lines []string
+	for _, q := range n.Context {
+		lines = append(lines, "<i>"+htmlEscape(q.String())+"</i>")
+	}
+	if n.ReplyTo != nil {
+		lines = append(lines, "↪ <i>"+htmlEscape(n.ReplyTo.String())+"</i>")
+	}
+	return strings.Join(lines, "\n")
+}
+
 func htmlEscape(s string) string {
 	r := strings.NewReplacer(
 		"&", "&amp;",
@@ -113,5 +357,3 @@ func htmlEscape(s string) string {
 	)
 	return r.Replace(s)
 }
-
-
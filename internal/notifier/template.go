@@ -0,0 +1,134 @@
+package notifier
+
+import (
+	"encoding/json"
+	htmltemplate "html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	texttemplate "text/template"
+	"time"
+	"unicode/utf8"
+
+	"getclient/internal/extract"
+)
+
+type Template interface {
+	Execute(w io.Writer, data any) error
+}
+
+type Templates struct {
+	Default  Template
+	RuleSets map[string]Template
+	// OnError вызывается, если шаблон не сработал и алерт уходит в стандартном формате.
+	OnError func(ruleSet string, err error)
+}
+
+func (t Templates) For(ruleSet string) Template {
+	if tmpl, ok := t.RuleSets[ruleSet]; ok && tmpl != nil {
+		return tmpl
+	}
+	return t.Default
+}
+
+// Render применяет шаблон набора правил уведомления. false — шаблона нет, он
+// вернул пустой текст или не сработал (ошибка передаётся в OnError).
+func (t Templates) Render(n Notification) (string, bool) {
+	tmpl := t.For(n.RuleSet)
+	if tmpl == nil {
+		return "", false
+	}
+	s, err := Render(tmpl, n)
+	if err != nil {
+		if t.OnError != nil {
+			t.OnError(n.RuleSet, err)
+		}
+		return "", false
+	}
+	return s, s != ""
+}
+
+// templated — уведомление для вебхука и файла с текстом по шаблону получателя.
+type templated struct {
+	Notification
+	Formatted string `json:"formatted"`
+}
+
+// payload возвращает JSON уведомления; если у получателя есть шаблон, добавляется
+// поле formatted.
+func (t Templates) payload(n Notification) ([]byte, error) {
+	if s, ok := t.Render(n); ok {
+		return json.Marshal(templated{Notification: n, Formatted: s})
+	}
+	return json.Marshal(n)
+}
+
+var templateFuncs = map[string]any{
+	"truncate": func(n int, s string) string {
+		if utf8.RuneCountInString(s) <= n {
+			return s
+		}
+		return string([]rune(s)[:n]) + "…"
+	},
+	"oneline": func(s string) string {
+		return strings.Join(strings.Fields(s), " ")
+	},
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"join":  strings.Join,
+	"date": func(layout string, t time.Time) string {
+		return t.Local().Format(layout)
+	},
+}
+
+func ParseTemplate(name, body string, html bool) (Template, error) {
+	if html {
+		return htmltemplate.New(name).Funcs(templateFuncs).Parse(body)
+	}
+	return texttemplate.New(name).Funcs(templateFuncs).Parse(body)
+}
+
+func ParseTemplateFile(path string, html bool) (Template, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseTemplate(filepath.Base(path), string(data), html)
+}
+
+func Render(t Template, n Notification) (string, error) {
+	var sb strings.Builder
+	if err := t.Execute(&sb, n); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(sb.String()), nil
+}
+
+func SampleNotification() Notification {
+	n := Notification{
+		ChatTitle:      "Фриланс чат",
+		From:           "@ivan_petrov (через acc1)",
+		Link:           "https://t.me/freelance_chat/12345",
+		Text:           "Всем привет! Ищу разработчика на Go для бота, бюджет 50к, пишите @ivan_petrov.",
+		Account:        "acc1",
+		RuleSet:        "default",
+		Keyword:        "ищу разработчика",
+		MatchedIn:      "text",
+		Language:       "ru",
+		SenderID:       123456789,
+		SenderName:     "Иван Петров",
+		SenderUsername: "ivan_petrov",
+		ChatKey:        "ch:1234567890",
+		MessageID:      12345,
+		Time:           time.Now(),
+		ReplyTo:        &Quote{MessageID: 12340, From: "@anna_k", Text: "Кто-нибудь делает ботов под ключ?"},
+	}
+	n.Extracted = &extract.Fields{
+		Usernames: []string{"@ivan_petrov"},
+		Prices:    []extract.Price{{Text: "бюджет 50к", Amount: 50000}},
+	}
+	n.MatchStart = strings.Index(n.Text, "Ищу разработчика")
+	n.MatchEnd = n.MatchStart + len("Ищу разработчика")
+	return n
+}
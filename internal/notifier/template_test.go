@@ -0,0 +1,117 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func mustTemplate(t *testing.T, body string, html bool) Template {
+	t.Helper()
+	tmpl, err := ParseTemplate("test", body, html)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tmpl
+}
+
+func TestTemplatesRender(t *testing.T) {
+	var failed []string
+	tmpls := Templates{
+		Default: mustTemplate(t, "{{.ChatTitle}}: {{.Keyword}}", false),
+		RuleSets: map[string]Template{
+			"empty":  mustTemplate(t, "  ", false),
+			"broken": mustTemplate(t, "{{.Missing}}", false),
+		},
+		OnError: func(ruleSet string, err error) { failed = append(failed, ruleSet) },
+	}
+	for _, tc := range []struct {
+		ruleSet string
+		want    string
+		ok      bool
+	}{
+		{"default", "Чат: ищу", true},
+		{"empty", "", false},
+		{"broken", "", false},
+	} {
+		got, ok := tmpls.Render(Notification{ChatTitle: "Чат", Keyword: "ищу", RuleSet: tc.ruleSet})
+		if got != tc.want || ok != tc.ok {
+			t.Errorf("Render(%s) = %q, %v, want %q, %v", tc.ruleSet, got, ok, tc.want, tc.ok)
+		}
+	}
+	if len(failed) != 1 || failed[0] != "broken" {
+		t.Errorf("OnError calls = %v, want [broken]", failed)
+	}
+	if _, ok := (Templates{}).Render(Notification{}); ok {
+		t.Error("Render without templates = ok")
+	}
+}
+
+func TestConsoleTemplateError(t *testing.T) {
+	var out strings.Builder
+	var errs []error
+	c := NewConsole(&out).WithTemplates(Templates{
+		Default: mustTemplate(t, "{{.Missing}}", false),
+		OnError: func(_ string, err error) { errs = append(errs, err) },
+	})
+	if err := c.Notify(context.Background(), Notification{ChatTitle: "Чат", Text: "ищу"}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "[ALERT]") {
+		t.Errorf("output = %q, want default format", out.String())
+	}
+	if len(errs) != 1 {
+		t.Errorf("OnError calls = %d, want 1", len(errs))
+	}
+}
+
+func TestWebhookFormatted(t *testing.T) {
+	var got map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(data, &got)
+	}))
+	defer srv.Close()
+
+	n := Notification{ChatTitle: "Чат <1>", Text: "ищу", RuleSet: "dev"}
+	w := NewWebhook(srv.URL).WithTemplates(Templates{Default: mustTemplate(t, "{{.ChatTitle}} — {{.Text}}", false)})
+	if err := w.Notify(context.Background(), n); err != nil {
+		t.Fatal(err)
+	}
+	if got["formatted"] != "Чат <1> — ищу" || got["text"] != "ищу" || got["rule_set"] != "dev" {
+		t.Errorf("payload = %v", got)
+	}
+
+	got = nil
+	if err := NewWebhook(srv.URL).Notify(context.Background(), n); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := got["formatted"]; ok || got["text"] != "ищу" {
+		t.Errorf("payload without template = %v", got)
+	}
+}
+
+func TestFileFormatted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "alerts.jsonl")
+	f := NewFile(path).WithTemplates(Templates{Default: mustTemplate(t, "{{upper .Text}}", false)})
+	if err := f.Notify(context.Background(), Notification{Text: "ищу"}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got["formatted"] != "ИЩУ" || got["text"] != "ищу" {
+		t.Errorf("line = %s", data)
+	}
+}
@@ -0,0 +1,158 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Topics struct {
+	RuleSets map[string]int
+	Accounts map[string]int
+	Default  int
+	Auto     bool
+	OnCreate func(ruleSet string, id int)
+}
+
+// Если создание темы оборвалось без ответа Telegram, тема могла появиться, и сразу
+// повторять нельзя — будет дубль. Следующая попытка не раньше чем через столько.
+const topicRetryDelay = 10 * time.Minute
+
+type topicRouter struct {
+	cfg     Topics
+	mu      sync.Mutex
+	created map[string]int
+	pending map[string]*topicCall
+	failed  map[string]time.Time
+}
+
+// topicCall — создание темы, результата которого ждут остальные алерты набора.
+type topicCall struct {
+	done chan struct{}
+	id   int
+}
+
+func (b *TelegramBot) WithTopics(t Topics) *TelegramBot {
+	b.topics = &topicRouter{
+		cfg:     t,
+		created: make(map[string]int),
+		pending: make(map[string]*topicCall),
+		failed:  make(map[string]time.Time),
+	}
+	return b
+}
+
+func (b *TelegramBot) topicFor(ctx context.Context, n Notification) int {
+	r := b.topics
+	if r == nil {
+		return 0
+	}
+	if id := r.cfg.RuleSets[n.RuleSet]; id != 0 {
+		return id
+	}
+	if id := r.cfg.Accounts[n.Account]; id != 0 {
+		return id
+	}
+	if r.cfg.Auto && n.RuleSet != "" {
+		if id := b.ensureTopic(ctx, n.RuleSet); id != 0 {
+			return id
+		}
+	}
+	return r.cfg.Default
+}
+
+// ensureTopic возвращает тему набора правил, создавая её при первом алерте.
+// Запрос идёт без блокировки: алерты других наборов его не ждут, а алерты того же
+// набора дожидаются результата вместо создания второй темы.
+func (b *TelegramBot) ensureTopic(ctx context.Context, name string) int {
+	r := b.topics
+	r.mu.Lock()
+	if id, ok := r.created[name]; ok {
+		r.mu.Unlock()
+		return id
+	}
+	if time.Now().Before(r.failed[name]) {
+		r.mu.Unlock()
+		return 0
+	}
+	if c, ok := r.pending[name]; ok {
+		r.mu.Unlock()
+		select {
+		case <-c.done:
+			return c.id
+		case <-ctx.Done():
+			return 0
+		}
+	}
+	c := &topicCall{done: make(chan struct{})}
+	r.pending[name] = c
+	r.mu.Unlock()
+
+	id, err := b.createTopic(ctx, name)
+
+	r.mu.Lock()
+	delete(r.pending, name)
+	var apiErr *APIError
+	switch {
+	case err == nil:
+		r.created[name] = id
+	case IsPermanent(err):
+		// Не форум или нет прав на темы: больше не пробуем для этого названия.
+		r.created[name] = 0
+	case !errors.As(err, &apiErr) && ctx.Err() == nil:
+		r.failed[name] = time.Now().Add(topicRetryDelay)
+	}
+	c.id = id
+	r.mu.Unlock()
+	close(c.done)
+
+	if err == nil && r.cfg.OnCreate != nil {
+		r.cfg.OnCreate(name, id)
+	}
+	return id
+}
+
+// createTopic вызывает createForumTopic. Повторяются только ошибки, на которые
+// ответил сам Telegram, — тогда тема точно не создана. Обрыв соединения или
+// таймаут не повторяются.
+func (b *TelegramBot) createTopic(ctx context.Context, name string) (int, error) {
+	const attempts = 3
+
+	form := url.Values{}
+	form.Set("chat_id", strconv.FormatInt(b.chatID, 10))
+	form.Set("name", name)
+	var res struct {
+		MessageThreadID int `json:"message_thread_id"`
+	}
+	var err error
+	for i := 0; i < attempts; i++ {
+		if err = b.limiter.Wait(ctx, b.chatID); err != nil {
+			return 0, err
+		}
+		err = b.call(ctx, "createForumTopic", form, &res)
+		var apiErr *APIError
+		if err == nil || IsPermanent(err) || !errors.As(err, &apiErr) {
+			break
+		}
+		if apiErr.RetryAfter > 0 {
+			b.limiter.Block(b.chatID, apiErr.RetryAfter)
+		} else if i < attempts-1 {
+			if err := sleepCtx(ctx, time.Second*time.Duration(i+1)); err != nil {
+				return 0, err
+			}
+		}
+	}
+	if err != nil {
+		return 0, err
+	}
+	return res.MessageThreadID, nil
+}
+
+func isThreadNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && strings.Contains(strings.ToLower(apiErr.Description), "thread not found")
+}
@@ -0,0 +1,120 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// topicServer — Bot API, в котором createForumTopic обрабатывает handle.
+func topicServer(t *testing.T, handle func(w http.ResponseWriter, r *http.Request)) *TelegramBot {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/createForumTopic") {
+			handle(w, r)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "result": true})
+	}))
+	t.Cleanup(srv.Close)
+	return NewTelegramBot("test-"+t.Name(), -100).WithAPIURL(srv.URL)
+}
+
+func writeTopic(w http.ResponseWriter, id int) {
+	_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "result": map[string]any{"message_thread_id": id}})
+}
+
+func TestEnsureTopicSingleflight(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	bot := topicServer(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		<-release
+		writeTopic(w, 55)
+	})
+	var created []int
+	bot.WithTopics(Topics{Auto: true, RuleSets: map[string]int{"fixed": 7}, OnCreate: func(_ string, id int) { created = append(created, id) }})
+
+	ids := make([]int, 5)
+	var wg sync.WaitGroup
+	for i := range ids {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ids[i] = bot.topicFor(context.Background(), Notification{RuleSet: "dev"})
+		}(i)
+	}
+	// Пока тема создаётся, алерты других наборов не ждут.
+	done := make(chan int)
+	go func() { done <- bot.topicFor(context.Background(), Notification{RuleSet: "fixed"}) }()
+	select {
+	case id := <-done:
+		if id != 7 {
+			t.Errorf("fixed topic = %d, want 7", id)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("topic lookup blocked by createForumTopic")
+	}
+	close(release)
+	wg.Wait()
+
+	for i, id := range ids {
+		if id != 55 {
+			t.Errorf("topic %d = %d, want 55", i, id)
+		}
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("createForumTopic calls = %d, want 1", n)
+	}
+	if len(created) != 1 {
+		t.Errorf("OnCreate calls = %d, want 1", len(created))
+	}
+	if id := bot.topicFor(context.Background(), Notification{RuleSet: "dev"}); id != 55 || calls.Load() != 1 {
+		t.Errorf("cached topic = %d after %d calls", id, calls.Load())
+	}
+}
+
+func TestEnsureTopicNoRetryOnDroppedConnection(t *testing.T) {
+	var calls atomic.Int32
+	bot := topicServer(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		// Соединение обрывается без ответа: тема могла быть создана.
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err == nil {
+			conn.Close()
+		}
+	})
+	bot.WithTopics(Topics{Auto: true, Default: 3})
+	for i := 0; i < 3; i++ {
+		if id := bot.topicFor(context.Background(), Notification{RuleSet: "dev"}); id != 3 {
+			t.Errorf("topic = %d, want default 3", id)
+		}
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("createForumTopic calls = %d, want 1", n)
+	}
+}
+
+func TestEnsureTopicRetriesAPIError(t *testing.T) {
+	var calls atomic.Int32
+	bot := topicServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			_ = json.NewEncoder(w).Encode(map[string]any{"ok": false, "error_code": 429, "description": "Too Many Requests", "parameters": map[string]any{"retry_after": 1}})
+			return
+		}
+		writeTopic(w, 9)
+	})
+	bot.WithTopics(Topics{Auto: true})
+	if id := bot.topicFor(context.Background(), Notification{RuleSet: "dev"}); id != 9 {
+		t.Errorf("topic = %d, want 9", id)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("createForumTopic calls = %d, want 2", n)
+	}
+}
@@ -0,0 +1,148 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const pollTimeout = 25 * time.Second
+
+type Update struct {
+	UpdateID      int64          `json:"update_id"`
+	Message       *Message       `json:"message,omitempty"`
+	CallbackQuery *CallbackQuery `json:"callback_query,omitempty"`
+}
+
+type Message struct {
+	MessageID      int      `json:"message_id"`
+	Chat           Chat     `json:"chat"`
+	From           *User    `json:"from,omitempty"`
+	Text           string   `json:"text"`
+	ReplyToMessage *Message `json:"reply_to_message,omitempty"`
+}
+
+type Chat struct {
+	ID int64 `json:"id"`
+}
+
+type User struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+}
+
+type CallbackQuery struct {
+	ID      string   `json:"id"`
+	From    User     `json:"from"`
+	Message *Message `json:"message,omitempty"`
+	Data    string   `json:"data"`
+}
+
+type InlineButton struct {
+	Text         string `json:"text"`
+	CallbackData string `json:"callback_data,omitempty"`
+	URL          string `json:"url,omitempty"`
+}
+
+type InlineKeyboard struct {
+	InlineKeyboard [][]InlineButton `json:"inline_keyboard"`
+}
+
+func (b *TelegramBot) ChatID() int64 {
+	return b.chatID
+}
+
+// PollUpdates опрашивает getUpdates (long polling), пока ctx не отменён. Ошибки
+// передаются в onError, запрос повторяется с нарастающей паузой.
+func (b *TelegramBot) PollUpdates(ctx context.Context, handle func(ctx context.Context, u Update), onError func(err error)) {
+	poller := *b.http
+	poller.Timeout = pollTimeout + 10*time.Second
+	client := *b
+	client.http = &poller
+
+	var offset int64
+	backoff := time.Second
+	for ctx.Err() == nil {
+		form := url.Values{}
+		form.Set("timeout", strconv.Itoa(int(pollTimeout/time.Second)))
+		form.Set("allowed_updates", `["message","callback_query"]`)
+		if offset != 0 {
+			form.Set("offset", strconv.FormatInt(offset, 10))
+		}
+
+		var updates []Update
+		if err := client.call(ctx, "getUpdates", form, &updates); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			var apiErr *APIError
+			if errors.As(err, &apiErr) {
+				if apiErr.Code == http.StatusConflict {
+					err = fmt.Errorf("%w (у бота настроен webhook или запущен другой экземпляр)", err)
+				}
+				if apiErr.RetryAfter > 0 {
+					backoff = apiErr.RetryAfter
+				}
+			}
+			if onError != nil {
+				onError(err)
+			}
+			_ = sleepCtx(ctx, backoff)
+			if backoff *= 2; backoff > time.Minute {
+				backoff = time.Minute
+			}
+			continue
+		}
+		backoff = time.Second
+
+		for _, u := range updates {
+			if u.UpdateID >= offset {
+				offset = u.UpdateID + 1
+			}
+			handle(ctx, u)
+		}
+	}
+}
+
+func (b *TelegramBot) SendText(ctx context.Context, chatID int64, text string, extra url.Values) (*Message, error) {
+	form := url.Values{}
+	for k, v := range extra {
+		form[k] = v
+	}
+	form.Set("chat_id", strconv.FormatInt(chatID, 10))
+	form.Set("text", text)
+	var msg Message
+	if err := b.send(ctx, chatID, "sendMessage", form, &msg); err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+func (b *TelegramBot) AnswerCallback(ctx context.Context, id, text string) error {
+	form := url.Values{}
+	form.Set("callback_query_id", id)
+	if text != "" {
+		form.Set("text", text)
+	}
+	return b.call(ctx, "answerCallbackQuery", form, nil)
+}
+
+func (b *TelegramBot) EditReplyMarkup(ctx context.Context, chatID int64, messageID int, kb *InlineKeyboard) error {
+	form := url.Values{}
+	form.Set("chat_id", strconv.FormatInt(chatID, 10))
+	form.Set("message_id", strconv.Itoa(messageID))
+	if kb != nil {
+		form.Set("reply_markup", encodeKeyboard(kb))
+	}
+	return b.call(ctx, "editMessageReplyMarkup", form, nil)
+}
+
+func ForceReply() url.Values {
+	v := url.Values{}
+	v.Set("reply_markup", `{"force_reply":true,"selective":true}`)
+	return v
+}
@@ -0,0 +1,102 @@
+package notifier
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPollUpdatesOffset(t *testing.T) {
+	f := newFakeBotAPI(t)
+	f.reply("getUpdates",
+		okResult([]map[string]any{
+			{"update_id": 10, "message": map[string]any{"message_id": 1, "chat": map[string]any{"id": 5}, "text": "/status"}},
+			{"update_id": 11, "callback_query": map[string]any{"id": "q1", "data": "done", "from": map[string]any{"id": 7}}},
+		}),
+		okResult([]map[string]any{
+			{"update_id": 12, "message": map[string]any{"message_id": 2, "chat": map[string]any{"id": 5}, "text": "/help"}},
+		}),
+		okResult([]map[string]any{}),
+	)
+	b := newTestBot(t, f, 5)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var mu sync.Mutex
+	var got []int64
+	go b.PollUpdates(ctx, func(ctx context.Context, u Update) {
+		mu.Lock()
+		defer mu.Unlock()
+		got = append(got, u.UpdateID)
+		if len(got) == 3 {
+			cancel()
+		}
+	}, func(err error) { t.Errorf("onError: %v", err) })
+	<-ctx.Done()
+	time.Sleep(50 * time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(got) != 3 || got[0] != 10 || got[1] != 11 || got[2] != 12 {
+		t.Fatalf("updates = %v, want [10 11 12]", got)
+	}
+	calls := f.callsTo("getUpdates")
+	if len(calls) < 2 {
+		t.Fatalf("getUpdates calls = %d, want >= 2", len(calls))
+	}
+	if off := calls[0].form.Get("offset"); off != "" {
+		t.Errorf("first offset = %q, want none", off)
+	}
+	if off := calls[1].form.Get("offset"); off != "12" {
+		t.Errorf("second offset = %q, want 12", off)
+	}
+}
+
+func TestPollUpdatesError(t *testing.T) {
+	f := newFakeBotAPI(t)
+	f.reply("getUpdates",
+		apiError(http.StatusConflict, "Conflict: terminated by other getUpdates request", 0),
+		okResult([]map[string]any{{"update_id": 1, "message": map[string]any{"message_id": 1, "chat": map[string]any{"id": 5}}}}),
+		okResult([]map[string]any{}),
+	)
+	b := newTestBot(t, f, 5)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	errs := make(chan error, 1)
+	go b.PollUpdates(ctx, func(ctx context.Context, u Update) { cancel() }, func(err error) {
+		select {
+		case errs <- err:
+		default:
+		}
+	})
+	<-ctx.Done()
+	if ctx.Err() == context.DeadlineExceeded {
+		t.Fatal("update after error not delivered")
+	}
+	select {
+	case err := <-errs:
+		if IsPermanent(err) {
+			t.Errorf("conflict reported as permanent: %v", err)
+		}
+	default:
+		t.Fatal("onError not called")
+	}
+}
+
+func TestAnswerCallback(t *testing.T) {
+	f := newFakeBotAPI(t)
+	b := newTestBot(t, f, 5)
+	if err := b.AnswerCallback(context.Background(), "q1", "Готово"); err != nil {
+		t.Fatal(err)
+	}
+	calls := f.callsTo("answerCallbackQuery")
+	if len(calls) != 1 {
+		t.Fatalf("answerCallbackQuery calls = %d, want 1", len(calls))
+	}
+	if id, text := calls[0].form.Get("callback_query_id"), calls[0].form.Get("text"); id != "q1" || text != "Готово" {
+		t.Errorf("answerCallbackQuery form = %v", calls[0].form)
+	}
+}
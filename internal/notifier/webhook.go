@@ -0,0 +1,63 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type Webhook struct {
+	url       string
+	http      *http.Client
+	templates Templates
+}
+
+func NewWebhook(url string) *Webhook {
+	return &Webhook{
+		url: strings.TrimSpace(url),
+		http: &http.Client{
+			Timeout: 7 * time.Second,
+		},
+	}
+}
+
+// WithTemplates добавляет в JSON поле formatted — текст алерта по шаблону.
+func (w *Webhook) WithTemplates(t Templates) *Webhook {
+	w.templates = t
+	return w
+}
+
+func (w *Webhook) Notify(ctx context.Context, n Notification) error {
+	if w.url == "" {
+		return nil
+	}
+	body, err := w.templates.payload(n)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	err = fmt.Errorf("status %s, body: %s", resp.Status, string(data))
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 &&
+		resp.StatusCode != http.StatusRequestTimeout && resp.StatusCode != http.StatusTooManyRequests {
+		return Permanent(err)
+	}
+	return err
+}
@@ -12,21 +12,30 @@ import (
 
 type SenderLimiter interface {
 	Allow(ctx context.Context, account string, senderID int64) (bool, error)
+	// Forget снимает отметку, поставленную Allow, — например, если действие не удалось.
+	Forget(ctx context.Context, account string, senderID int64) error
 	Close() error
 }
 
 type BaseDB struct {
 	path string
+	ttl  time.Duration
 	mu   sync.Mutex
 	Seen map[string]int64 `json:"seen"`
 }
 
 func OpenBaseDB(path string) (*BaseDB, error) {
+	return OpenBaseDBTTL(path, 24*time.Hour)
+}
+
+// OpenBaseDBTTL — то же, но отправитель снова разрешается только через ttl.
+func OpenBaseDBTTL(path string, ttl time.Duration) (*BaseDB, error) {
 	if path == "" {
 		path = "data/base.json"
 	}
 	db := &BaseDB{
 		path: path,
+		ttl:  ttl,
 		Seen: make(map[string]int64),
 	}
 
@@ -40,7 +49,7 @@ func OpenBaseDB(path string) (*BaseDB, error) {
 }
 
 func (b *BaseDB) cleanup() {
-	cutoff := time.Now().Add(-24 * time.Hour).Unix()
+	cutoff := time.Now().Add(-b.ttl).Unix()
 	changed := false
 	for k, ts := range b.Seen {
 		if ts < cutoff {
@@ -72,7 +81,7 @@ func (b *BaseDB) Allow(ctx context.Context, account string, senderID int64) (boo
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
-	cutoff := time.Now().Add(-24 * time.Hour).Unix()
+	cutoff := time.Now().Add(-b.ttl).Unix()
 	if ts, ok := b.Seen[key]; ok && ts >= cutoff {
 		return false, nil
 	}
@@ -83,6 +92,19 @@ func (b *BaseDB) Allow(ctx context.Context, account string, senderID int64) (boo
 	return true, nil
 }
 
+func (b *BaseDB) Forget(ctx context.Context, account string, senderID int64) error {
+	key := fmt.Sprintf("%s:%d", account, senderID)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.Seen[key]; !ok {
+		return nil
+	}
+	delete(b.Seen, key)
+	return b.save()
+}
+
 func (b *BaseDB) Close() error {
 	b.mu.Lock()
 	defer b.mu.Unlock()
@@ -6,11 +6,78 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 )
 
 type Account struct {
 	Name        string `json:"name"`
 	SessionPath string `json:"session"`
+	TopicID     int    `json:"topic_id,omitempty"`
+	// Proxy — socks5://, http://, mtproxy:// или ссылка tg://proxy (см. telegramutil.ProxyResolver).
+	Proxy  string `json:"proxy,omitempty"`
+	Device Device `json:"device,omitempty"`
+	// RuleSets — наборы правил аккаунта; пусто — все.
+	RuleSets []string `json:"rule_sets,omitempty"`
+	Disabled bool     `json:"disabled,omitempty"`
+}
+
+// Device — как аккаунт представляется Telegram; пустые поля — значения по умолчанию.
+type Device struct {
+	Model         string `json:"device_model,omitempty"`
+	SystemVersion string `json:"system_version,omitempty"`
+	AppVersion    string `json:"app_version,omitempty"`
+	LangCode      string `json:"lang_code,omitempty"`
+}
+
+type RuleSet struct {
+	Name          string            `json:"name"`
+	KeywordsFile  string            `json:"keywords_file"`
+	StopwordsFile string            `json:"stopwords_file,omitempty"`
+	UseRegex      bool              `json:"use_regex,omitempty"`
+	Normalize     bool              `json:"normalize,omitempty"`
+	Threshold     float64           `json:"threshold,omitempty"`
+	Templates     map[string]string `json:"templates,omitempty"`
+	ForwardTo     string            `json:"forward_to,omitempty"`
+	ForwardCopy   bool              `json:"forward_copy,omitempty"`
+	Senders       SenderFilter      `json:"senders,omitempty"`
+	Chats         ChatFilter        `json:"chats,omitempty"`
+	Languages     []string          `json:"languages,omitempty"`
+	AutoReply     AutoReply         `json:"auto_reply,omitempty"`
+}
+
+// AutoReply — автоответ автору совпавшего сообщения. Template — файл шаблона
+// text/template (пусто = выключен), Mode: "dm" — в личные, "reply" — ответом в чате.
+type AutoReply struct {
+	Template string `json:"template,omitempty"`
+	Mode     string `json:"mode,omitempty"`
+}
+
+// ChatFilter — условия на чат. Forum: "only" — только форумы, "exclude" — без форумов.
+type ChatFilter struct {
+	MinMembers int      `json:"min_members,omitempty"`
+	PublicOnly bool     `json:"public_only,omitempty"`
+	Forum      string   `json:"forum,omitempty"`
+	MinAgeDays int      `json:"min_age_days,omitempty"`
+	Languages  []string `json:"languages,omitempty"`
+}
+
+type SenderFilter struct {
+	Block            []string `json:"block,omitempty"`
+	Watch            []string `json:"watch,omitempty"`
+	IgnoreBots       bool     `json:"ignore_bots,omitempty"`
+	IgnoreNoUsername bool     `json:"ignore_no_username,omitempty"`
+	IgnoreChannels   bool     `json:"ignore_channels,omitempty"`
+}
+
+type Destination struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	BotToken string `json:"bot_token,omitempty"`
+	ChatID   int64  `json:"chat_id,omitempty"`
+	URL      string `json:"url,omitempty"`
+	Path     string `json:"path,omitempty"`
+	TopicID  int    `json:"topic_id,omitempty"`
+	Priority string `json:"priority,omitempty"`
 }
 
 type State struct {
@@ -21,6 +88,14 @@ type State struct {
 
 	BotToken  string `json:"bot_token"`
 	BotChatID int64  `json:"bot_chat_id"`
+	BotAPIURL string `json:"bot_api_url,omitempty"`
+
+	RuleSetTopics map[string]int `json:"rule_set_topics,omitempty"`
+	AutoTopics    bool           `json:"auto_topics,omitempty"`
+
+	Destinations []Destination     `json:"destinations"`
+	Templates    map[string]string `json:"templates,omitempty"`
+	RuleSets     []RuleSet         `json:"rule_sets,omitempty"`
 
 	KeywordsFile   string `json:"keywords_file"`
 	StopwordsFile  string `json:"stopwords_file"`
@@ -28,6 +103,27 @@ type State struct {
 	PollIntervalMs int64  `json:"poll_interval_ms"`
 	PollLimit      int    `json:"poll_limit"`
 	UseRegex       bool   `json:"use_regex"`
+
+	MutedSenders []int64  `json:"muted_senders,omitempty"`
+	MutedChats   []string `json:"muted_chats,omitempty"`
+	BotControl   bool     `json:"bot_control,omitempty"`
+	ControlChats []int64  `json:"control_chat_ids,omitempty"`
+	Paused       bool     `json:"paused,omitempty"`
+
+	ContextReplies  bool `json:"context_replies,omitempty"`
+	ContextMessages int  `json:"context_messages,omitempty"`
+
+	Classifier          bool    `json:"classifier,omitempty"`
+	ClassifierThreshold float64 `json:"classifier_threshold,omitempty"`
+
+	Hook           string `json:"hook,omitempty"`
+	HookTimeoutMs  int64  `json:"hook_timeout_ms,omitempty"`
+	HookFailClosed bool   `json:"hook_fail_closed,omitempty"`
+
+	AutoReplyDailyLimit  int  `json:"auto_reply_daily_limit,omitempty"`
+	AutoReplyMinDelaySec int  `json:"auto_reply_min_delay_sec,omitempty"`
+	AutoReplyMaxDelaySec int  `json:"auto_reply_max_delay_sec,omitempty"`
+	AutoReplyDryRun      bool `json:"auto_reply_dry_run,omitempty"`
 }
 
 func Default() State {
@@ -78,4 +174,15 @@ func Save(path string, s State) error {
 	return os.WriteFile(path, data, 0o600)
 }
 
+var updateMu sync.Mutex
 
+func Update(path string, fn func(s *State)) error {
+	updateMu.Lock()
+	defer updateMu.Unlock()
+	s, err := Load(path)
+	if err != nil {
+		return err
+	}
+	fn(&s)
+	return Save(path, s)
+}
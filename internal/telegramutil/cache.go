@@ -0,0 +1,131 @@
+package telegramutil
+
+import (
+	"sync"
+
+	"github.com/gotd/td/tg"
+)
+
+type EntityCache struct {
+	mu       sync.RWMutex
+	users    map[int64]*tg.User
+	chats    map[int64]*tg.Chat
+	channels map[int64]*tg.Channel
+}
+
+func NewEntityCache() *EntityCache {
+	return &EntityCache{
+		users:    make(map[int64]*tg.User),
+		chats:    make(map[int64]*tg.Chat),
+		channels: make(map[int64]*tg.Channel),
+	}
+}
+
+func (c *EntityCache) Add(e tg.Entities) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for id, u := range e.Users {
+		if old, ok := c.users[id]; u != nil && (!ok || !u.Min || old.Min) {
+			c.users[id] = u
+		}
+	}
+	for id, ch := range e.Chats {
+		if ch != nil {
+			c.chats[id] = ch
+		}
+	}
+	for id, ch := range e.Channels {
+		if old, ok := c.channels[id]; ch != nil && (!ok || !ch.Min || old.Min) {
+			c.channels[id] = ch
+		}
+	}
+}
+
+func (c *EntityCache) AddUsersChats(users []tg.UserClass, chats []tg.ChatClass) {
+	c.Add(BuildEntities(users, chats))
+}
+
+// Complete возвращает копию e, дополненную указанными пирами из кэша.
+func (c *EntityCache) Complete(e tg.Entities, peers ...tg.PeerClass) tg.Entities {
+	out := tg.Entities{
+		Users:    make(map[int64]*tg.User, len(e.Users)+len(peers)),
+		Chats:    make(map[int64]*tg.Chat, len(e.Chats)),
+		Channels: make(map[int64]*tg.Channel, len(e.Channels)),
+	}
+	for k, v := range e.Users {
+		out.Users[k] = v
+	}
+	for k, v := range e.Chats {
+		out.Chats[k] = v
+	}
+	for k, v := range e.Channels {
+		out.Channels[k] = v
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, peer := range peers {
+		switch p := peer.(type) {
+		case *tg.PeerUser:
+			if _, ok := out.Users[p.UserID]; !ok {
+				if u, ok := c.users[p.UserID]; ok {
+					out.Users[p.UserID] = u
+				}
+			}
+		case *tg.PeerChat:
+			if _, ok := out.Chats[p.ChatID]; !ok {
+				if ch, ok := c.chats[p.ChatID]; ok {
+					out.Chats[p.ChatID] = ch
+				}
+			}
+		case *tg.PeerChannel:
+			if _, ok := out.Channels[p.ChannelID]; !ok {
+				if ch, ok := c.channels[p.ChannelID]; ok {
+					out.Channels[p.ChannelID] = ch
+				}
+			}
+		}
+	}
+	return out
+}
+
+func (c *EntityCache) User(id int64) (*tg.User, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	u, ok := c.users[id]
+	return u, ok
+}
+
+func (c *EntityCache) Channel(id int64) (*tg.Channel, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	ch, ok := c.channels[id]
+	return ch, ok
+}
+
+func (c *EntityCache) InputPeer(peer tg.PeerClass) (tg.InputPeerClass, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	switch p := peer.(type) {
+	case *tg.PeerUser:
+		if u, ok := c.users[p.UserID]; ok && !u.Min {
+			return u.AsInputPeer(), true
+		}
+	case *tg.PeerChat:
+		return &tg.InputPeerChat{ChatID: p.ChatID}, true
+	case *tg.PeerChannel:
+		if ch, ok := c.channels[p.ChannelID]; ok && !ch.Min {
+			return ch.AsInputPeer(), true
+		}
+	}
+	return nil, false
+}
+
+func (c *EntityCache) InputChannel(id int64) (*tg.InputChannel, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if ch, ok := c.channels[id]; ok && !ch.Min {
+		return ch.AsInput(), true
+	}
+	return nil, false
+}
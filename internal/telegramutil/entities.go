@@ -3,6 +3,7 @@ package telegramutil
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/gotd/td/tg"
 )
@@ -72,9 +73,11 @@ func PeerKey(peer tg.PeerClass) string {
 }
 
 type SenderInfo struct {
-	ID       int64
-	Username string
-	Name     string
+	ID        int64
+	Username  string
+	Name      string
+	Bot       bool
+	IsChannel bool
 }
 
 func Sender(from tg.PeerClass, e tg.Entities) SenderInfo {
@@ -82,19 +85,64 @@ func Sender(from tg.PeerClass, e tg.Entities) SenderInfo {
 	case *tg.PeerUser:
 		if u, ok := e.Users[p.UserID]; ok && u != nil {
 			name := strings.TrimSpace(strings.TrimSpace(u.FirstName + " " + u.LastName))
-			return SenderInfo{ID: u.ID, Username: u.Username, Name: name}
+			return SenderInfo{ID: u.ID, Username: u.Username, Name: name, Bot: u.Bot}
 		}
 		return SenderInfo{ID: p.UserID}
 	case *tg.PeerChannel:
 		if c, ok := e.Channels[p.ChannelID]; ok && c != nil {
-			return SenderInfo{ID: c.ID, Username: c.Username, Name: c.Title}
+			return SenderInfo{ID: c.ID, Username: c.Username, Name: c.Title, IsChannel: true}
 		}
-		return SenderInfo{ID: p.ChannelID}
+		return SenderInfo{ID: p.ChannelID, IsChannel: true}
 	default:
 		return SenderInfo{}
 	}
 }
 
+// ChatInfo — сведения о чате, известные из сущностей апдейта.
+// Members == 0, если Telegram не прислал число участников. Created — дата создания
+// чата; у супергрупп, где аккаунт состоит, Telegram вместо неё отдаёт дату вступления,
+// поэтому для них она остаётся пустой.
+// Language — преобладающий язык сообщений, пока не определён — пустой.
+type ChatInfo struct {
+	Key      string
+	Title    string
+	Username string
+	Members  int
+	Forum    bool
+	Created  time.Time
+	Language string
+}
+
+func Chat(peer tg.PeerClass, e tg.Entities) ChatInfo {
+	info := ChatInfo{Key: PeerKey(peer), Title: PeerTitle(peer, e)}
+	switch p := peer.(type) {
+	case *tg.PeerChat:
+		if c, ok := e.Chats[p.ChatID]; ok && c != nil {
+			info.Members = c.ParticipantsCount
+			info.Created = unixTime(c.Date)
+		}
+	case *tg.PeerChannel:
+		if c, ok := e.Channels[p.ChannelID]; ok && c != nil {
+			info.Username = c.Username
+			info.Forum = c.Forum
+			if c.Left {
+				info.Created = unixTime(c.Date)
+			}
+			if n, ok := c.GetParticipantsCount(); ok {
+				info.Members = n
+			}
+		}
+	}
+	return info
+}
+
+func unixTime(ts int) time.Time {
+	if ts <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(int64(ts), 0)
+}
+
 func MessageLink(peer tg.PeerClass, msgID int, e tg.Entities) string {
 	switch p := peer.(type) {
 	case *tg.PeerChannel:
@@ -110,4 +158,16 @@ func MessageLink(peer tg.PeerClass, msgID int, e tg.Entities) string {
 	}
 }
 
-
+func NoForwards(peer tg.PeerClass, e tg.Entities) bool {
+	switch p := peer.(type) {
+	case *tg.PeerChat:
+		if c, ok := e.Chats[p.ChatID]; ok && c != nil {
+			return c.Noforwards
+		}
+	case *tg.PeerChannel:
+		if c, ok := e.Channels[p.ChannelID]; ok && c != nil {
+			return c.Noforwards
+		}
+	}
+	return false
+}
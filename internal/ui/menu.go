@@ -65,12 +65,17 @@ const (
 	ActionExit Action = iota
 	ActionStart
 	ActionAddAccount
-	ActionRemoveAccount
+	ActionAccounts
 	ActionAppSettings
 	ActionBotSettings
 	ActionKeywordsAdd
 	ActionStopwordsAdd
 	ActionResetBase
+	ActionDestinations
+	ActionTemplates
+	ActionRuleSets
+	ActionClassifier
+	ActionJoin
 )
 
 func (m *Menu) Choose(ctx context.Context, info string) (Action, error) {
@@ -81,12 +86,17 @@ func (m *Menu) Choose(ctx context.Context, info string) (Action, error) {
 	m.Title("Telegram монитор")
 	m.Linef("1) Запустить мониторинг")
 	m.Linef("2) Добавить аккаунт")
-	m.Linef("3) Удалить аккаунт")
-	m.Linef("4) Настройки приложения (API_ID/API_HASH)")
+	m.Linef("3) Аккаунты (прокси, устройство, наборы правил, удаление)")
+	m.Linef("4) Настройки приложения (API_ID/API_HASH, внешний фильтр)")
 	m.Linef("5) Настройки бота")
 	m.Linef("6) Добавить ключевую фразу")
 	m.Linef("7) Добавить стоп-слово")
 	m.Linef("8) Сбросить базу (лимит 24ч)")
+	m.Linef("9) Получатели уведомлений")
+	m.Linef("10) Шаблоны уведомлений")
+	m.Linef("11) Наборы правил")
+	m.Linef("12) Классификатор релевантности")
+	m.Linef("13) Вступление в чаты и поиск групп")
 	m.Linef("0) Выход")
 	s, err := m.Prompt("Выберите пункт меню")
 	if err != nil {
@@ -103,7 +113,7 @@ func (m *Menu) Choose(ctx context.Context, info string) (Action, error) {
 	case "2":
 		return ActionAddAccount, nil
 	case "3":
-		return ActionRemoveAccount, nil
+		return ActionAccounts, nil
 	case "4":
 		return ActionAppSettings, nil
 	case "5":
@@ -114,6 +124,16 @@ func (m *Menu) Choose(ctx context.Context, info string) (Action, error) {
 		return ActionStopwordsAdd, nil
 	case "8":
 		return ActionResetBase, nil
+	case "9":
+		return ActionDestinations, nil
+	case "10":
+		return ActionTemplates, nil
+	case "11":
+		return ActionRuleSets, nil
+	case "12":
+		return ActionClassifier, nil
+	case "13":
+		return ActionJoin, nil
 	default:
 		return ActionExit, nil
 	}